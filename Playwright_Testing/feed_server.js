@@ -0,0 +1,224 @@
+// Small HTTP server standing in for remote publishers during the tests.
+// Every path is accepted so each test can use its own unique feed URL:
+//   /rss/{id}   RSS 2.0 document
+//   /atom/{id}  Atom document
+//   /page/{id}  HTML page advertising /rss/{id} through autodiscovery
+//   /html/{id}  HTML page without any feed
+//   /latin1/{id} RSS 2.0 document encoded in ISO-8859-1
+//   /websub/{id} RSS 2.0 document advertising the stand-in WebSub hub
+// The stand-in hub lives under /hub:
+//   POST /hub                         subscription requests, verified right away
+//   GET  /hub/subscriptions?topic=    state and callback of the subscription to a topic
+//   POST /hub/publish?topic=&title=   adds an item and pushes the feed to the
+//                                     subscriber, &signature=bad signs it wrong
+const crypto = require("crypto");
+const http = require("http");
+
+const port = Number(process.env.FEED_SERVER_PORT || 8081);
+const base = `http://127.0.0.1:${port}`;
+
+// Hub state, by topic URL
+const subscriptions = {};
+const published = {};
+
+function hubItems(topic) {
+  return (published[topic] || [])
+    .map(
+      (item) => `
+    <item>
+      <title>${item.title}</title>
+      <link>${item.link}</link>
+      <description>Pushed by the stand-in hub</description>
+      <pubDate>${item.date}</pubDate>
+      <guid isPermaLink="false">${item.link}</guid>
+    </item>`
+    )
+    .join("");
+}
+
+function rss(id, kind = "rss") {
+  const topic = `${base}/${kind}/${id}`;
+  const hub =
+    kind === "websub"
+      ? `<atom:link rel="hub" href="${base}/hub"/>
+    <atom:link rel="self" href="${topic}"/>`
+      : "";
+  return `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:atom="http://www.w3.org/2005/Atom">
+  <channel>
+    ${hub}
+    <title>Fixture feed ${id}</title>
+    <link>http://127.0.0.1:${port}/page/${id}</link>
+    <description>Feed served by the Playwright fixture server</description>
+    <language>en</language>
+    <image>
+      <url>http://127.0.0.1:${port}/images/${id}.png</url>
+    </image>
+    <ttl>60</ttl>
+    <skipHours>
+      <hour>1</hour>
+      <hour>2</hour>
+    </skipHours>
+    <item>
+      <title>First post</title>
+      <link>http://127.0.0.1:${port}/posts/${id}/1</link>
+      <description>Hello from the fixture server</description>
+      <pubDate>Mon, 02 Jan 2006 15:04:05 +0000</pubDate>
+      <guid isPermaLink="false">${id}-1</guid>
+      <dc:creator>Fixture Author</dc:creator>
+      <category>testing</category>
+      <content:encoded><![CDATA[<p>Hello from the <b>fixture</b> server</p>]]></content:encoded>
+      <enclosure url="http://127.0.0.1:${port}/media/${id}.mp3" type="audio/mpeg" length="1024"/>
+    </item>${hubItems(topic)}
+  </channel>
+</rss>`;
+}
+
+function latin1(id) {
+  return `<?xml version="1.0" encoding="ISO-8859-1"?>
+<rss version="2.0">
+  <channel>
+    <title>Café crème ${id}</title>
+    <link>${base}/page/${id}</link>
+    <description>Déjà vu</description>
+  </channel>
+</rss>`;
+}
+
+function atom(id) {
+  return `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Fixture atom ${id}</title>
+  <link href="http://127.0.0.1:${port}/page/${id}"/>
+  <entry>
+    <title>First entry</title>
+    <link href="http://127.0.0.1:${port}/posts/${id}/1"/>
+    <summary>Hello from the fixture server</summary>
+    <published>2006-01-02T15:04:05Z</published>
+  </entry>
+</feed>`;
+}
+
+function page(id, withFeed) {
+  const link = withFeed
+    ? `<link rel="alternate" type="application/rss+xml" href="/rss/${id}">`
+    : "";
+  return `<!DOCTYPE html><html><head><title>Page ${id}</title>${link}</head><body></body></html>`;
+}
+
+function readBody(req) {
+  return new Promise((resolve) => {
+    let body = "";
+    req.on("data", (chunk) => (body += chunk));
+    req.on("end", () => resolve(body));
+  });
+}
+
+function json(res, code, payload) {
+  res.writeHead(code, { "Content-Type": "application/json" });
+  res.end(JSON.stringify(payload));
+}
+
+// Subscription requests are answered with 202, then verified like a real hub
+// would by sending a challenge to the callback
+async function hubSubscribe(req, res) {
+  const form = new URLSearchParams(await readBody(req));
+  const topic = form.get("hub.topic");
+  const subscription = {
+    callback: form.get("hub.callback"),
+    secret: form.get("hub.secret"),
+    lease: form.get("hub.lease_seconds") || "3600",
+    verified: false,
+  };
+  subscriptions[topic] = subscription;
+  res.writeHead(202);
+  res.end();
+
+  const challenge = crypto.randomUUID();
+  const verify = new URL(subscription.callback);
+  verify.searchParams.set("hub.mode", form.get("hub.mode"));
+  verify.searchParams.set("hub.topic", topic);
+  verify.searchParams.set("hub.challenge", challenge);
+  verify.searchParams.set("hub.lease_seconds", subscription.lease);
+  try {
+    const answer = await fetch(verify);
+    subscription.verified = answer.ok && (await answer.text()) === challenge;
+  } catch {
+    subscription.verified = false;
+  }
+}
+
+// Adds an item to the topic and pushes the whole feed, signed with the
+// subscription secret
+async function hubPublish(url, res) {
+  const topic = url.searchParams.get("topic");
+  const subscription = subscriptions[topic];
+  if (!subscription) {
+    return json(res, 404, { error: "No subscription" });
+  }
+  const id = topic.split("/").pop();
+  published[topic] = published[topic] || [];
+  published[topic].push({
+    title: url.searchParams.get("title"),
+    link: `${base}/posts/${id}/${published[topic].length + 2}`,
+    date: new Date().toUTCString(),
+  });
+  const body = rss(id, "websub");
+  const secret = url.searchParams.get("signature") === "bad" ? "wrong secret" : subscription.secret;
+  const signature = crypto.createHmac("sha256", secret).update(body).digest("hex");
+  const answer = await fetch(subscription.callback, {
+    method: "POST",
+    headers: {
+      "Content-Type": "application/rss+xml",
+      "X-Hub-Signature": `sha256=${signature}`,
+    },
+    body: body,
+  });
+  return json(res, 200, { status: answer.status });
+}
+
+function handler(req, res) {
+  const url = new URL(req.url, base);
+  const [, kind, id] = url.pathname.split("/");
+  switch (kind) {
+    case "rss":
+    case "websub":
+      res.writeHead(200, { "Content-Type": "application/rss+xml" });
+      return res.end(rss(id, kind));
+    case "hub":
+      if (id === undefined && req.method === "POST") {
+        return hubSubscribe(req, res);
+      }
+      if (id === "subscriptions") {
+        const subscription = subscriptions[url.searchParams.get("topic")];
+        return subscription
+          ? json(res, 200, { verified: subscription.verified, callback: subscription.callback })
+          : json(res, 404, {});
+      }
+      if (id === "publish" && req.method === "POST") {
+        return hubPublish(url, res);
+      }
+      break;
+    case "latin1":
+      res.writeHead(200, { "Content-Type": "application/rss+xml" });
+      return res.end(Buffer.from(latin1(id), "latin1"));
+    case "atom":
+      res.writeHead(200, { "Content-Type": "application/atom+xml" });
+      return res.end(atom(id));
+    case "page":
+      res.writeHead(200, { "Content-Type": "text/html" });
+      return res.end(page(id, true));
+    case "html":
+      res.writeHead(200, { "Content-Type": "text/html" });
+      return res.end(page(id, false));
+  }
+  res.writeHead(404);
+  res.end();
+}
+
+function startFeedServer() {
+  const server = http.createServer(handler);
+  return new Promise((resolve) => server.listen(port, "127.0.0.1", () => resolve(server)));
+}
+
+module.exports = { startFeedServer, port };
@@ -0,0 +1,8 @@
+const { startFeedServer } = require("./feed_server");
+
+// Start the fixture feed server for the whole run, the returned function
+// is used by Playwright as the global teardown
+module.exports = async () => {
+  const server = await startFeedServer();
+  return () => new Promise((resolve) => server.close(resolve));
+};
@@ -0,0 +1,31 @@
+import { expect } from "@playwright/test";
+import { faker } from "@faker-js/faker";
+
+export const feedServer = `http://127.0.0.1:${process.env.FEED_SERVER_PORT || 8081}`;
+
+// Unique URL on the fixture feed server, kind is rss, atom, page or html
+export function feedURL(kind = "rss") {
+  return `${feedServer}/${kind}/${faker.string.uuid()}`;
+}
+
+// Signs a new user up and logs them in
+export async function createUser(request, name = faker.person.firstName()) {
+  const email = faker.internet.email();
+  const password = faker.internet.password();
+  const response = await request.post("/v1/user", {
+    data: {
+      email: email,
+      password: password,
+      name: name,
+    },
+  });
+  expect(response.status()).toBe(201);
+  const loginResponse = await request.post("/v1/login", {
+    form: {
+      username: email,
+      password: password,
+    },
+  });
+  expect(loginResponse.status()).toBe(200);
+  return { id: (await response.json()).id, token: (await loginResponse.json()).token };
+}
@@ -0,0 +1,308 @@
+import { test, expect } from "@playwright/test";
+import { faker } from "@faker-js/faker";
+import { feedURL } from "./helpers";
+
+let authToken, email, password, feed_id, url;
+
+test.beforeEach("Credentials - User and Feed", async ({ request }) => {
+  email = faker.internet.email();
+  password = faker.internet.password();
+  const response = await request.post("/v1/user", {
+    data: {
+      email: email,
+      password: password,
+      name: faker.person.firstName(),
+    },
+  });
+  expect(response.status()).toBe(201);
+  const loginResponse = await request.post("/v1/login", {
+    form: {
+      username: email,
+      password: password,
+    },
+  });
+  expect(loginResponse.status()).toBe(200);
+  authToken = (await loginResponse.json()).token;
+
+  url = feedURL();
+  const feedResponse = await request.post("/v2/feeds", {
+    headers: {
+      Authorization: `Bearer ${authToken}`,
+    },
+    data: {
+      name: faker.lorem.word(),
+      url: url,
+    },
+  });
+  expect(feedResponse.status()).toBe(201);
+  feed_id = (await feedResponse.json()).id;
+});
+
+test.afterEach("Remove Credentials", async ({ request }) => {
+  // The user may have been deleted by the test already
+  await request.delete("/v1/user", {
+    headers: {
+      Authorization: `Bearer ${authToken}`,
+    },
+  });
+});
+
+test.describe("Restore Feeds", () => {
+  test("Restore Feed", async ({ request }) => {
+    const remove = await request.delete(`/v2/feeds/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(remove.status()).toBe(204);
+    const deleted = await request.get(`/v2/feeds/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(deleted.status()).toBe(404);
+
+    const response = await request.post(`/v2/feeds/${feed_id}/restore`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate response body
+    expect(await response.json()).toHaveProperty("id", feed_id);
+
+    const restored = await request.get(`/v2/feeds/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(restored.status()).toBe(200);
+  });
+
+  test("Restore Feed - Added again", async ({ request }) => {
+    const remove = await request.delete(`/v2/feeds/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(remove.status()).toBe(204);
+    // The deleted feed doesn't hold on to its URL
+    const again = await request.post("/v2/feeds", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        name: faker.lorem.word(),
+        url: url,
+      },
+    });
+    expect(again.status()).toBe(201);
+
+    const response = await request.post(`/v2/feeds/${feed_id}/restore`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(409);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Feed exist");
+  });
+
+  test("Restore Feed - Not deleted", async ({ request }) => {
+    const response = await request.post(`/v2/feeds/${feed_id}/restore`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(404);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Deleted feed not found");
+  });
+});
+
+test.describe("Restore Follows", () => {
+  test("Restore Follow", async ({ request }) => {
+    const follow = await request.post("/v3/follow", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        feed_id: feed_id,
+      },
+    });
+    expect(follow.status()).toBe(201);
+    const settings = await request.patch(`/v3/follow/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        title: "Kept",
+      },
+    });
+    expect(settings.status()).toBe(200);
+    const unfollow = await request.delete(`/v3/follow/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(unfollow.status()).toBe(204);
+
+    const response = await request.post(`/v3/follow/${feed_id}/restore`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate response body, the settings come back too
+    expect(await response.json()).toHaveProperty("title", "Kept");
+
+    const again = await request.post(`/v3/follow/${feed_id}/restore`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(again.status()).toBe(404);
+  });
+
+  test("Follow again after unfollowing", async ({ request }) => {
+    for (const status of [201, 409]) {
+      const follow = await request.post("/v3/follow", {
+        headers: {
+          Authorization: `Bearer ${authToken}`,
+        },
+        data: {
+          feed_id: feed_id,
+        },
+      });
+      expect(follow.status()).toBe(status);
+    }
+    const unfollow = await request.delete(`/v3/follow/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(unfollow.status()).toBe(204);
+
+    const response = await request.post("/v3/follow", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        feed_id: feed_id,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(201);
+  });
+});
+
+test.describe("Restore Users", () => {
+  test("Restore User", async ({ request }) => {
+    const remove = await request.delete("/v1/user", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(remove.status()).toBe(204);
+    const login = await request.post("/v1/login", {
+      form: {
+        username: email,
+        password: password,
+      },
+    });
+    expect(login.status()).toBe(404);
+
+    const response = await request.post("/v1/user/restore", {
+      form: {
+        username: email,
+        password: password,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate response body
+    expect(await response.json()).toHaveProperty("email", email);
+
+    // The token still works and the feed deleted with the user is back
+    const feed = await request.get(`/v2/feeds/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(feed.status()).toBe(200);
+  });
+
+  test("Restore User - Signed up again", async ({ request }) => {
+    const remove = await request.delete("/v1/user", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(remove.status()).toBe(204);
+    // The deleted account doesn't hold on to its email
+    const signup = await request.post("/v1/user", {
+      data: {
+        email: email,
+        password: password,
+        name: faker.person.firstName(),
+      },
+    });
+    expect(signup.status()).toBe(201);
+
+    const response = await request.post("/v1/user/restore", {
+      form: {
+        username: email,
+        password: password,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(409);
+
+    const login = await request.post("/v1/login", {
+      form: {
+        username: email,
+        password: password,
+      },
+    });
+    expect(login.status()).toBe(200);
+    authToken = (await login.json()).token;
+  });
+
+  test("Restore User - Wrong password", async ({ request }) => {
+    const remove = await request.delete("/v1/user", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(remove.status()).toBe(204);
+
+    const response = await request.post("/v1/user/restore", {
+      form: {
+        username: email,
+        password: "wrong password",
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(401);
+  });
+
+  test("Restore User - Not deleted", async ({ request }) => {
+    const response = await request.post("/v1/user/restore", {
+      form: {
+        username: email,
+        password: password,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(404);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Deleted user not found");
+  });
+});
@@ -1,5 +1,6 @@
 import { test, expect } from "@playwright/test";
 import { faker } from "@faker-js/faker";
+import { feedURL } from "./helpers";
 
 let email, authToken, feed_id, url, url2, feed_id2, email2, authToken2;
 test.beforeEach("Credentials - User", async ({ request }) => {
@@ -50,8 +51,8 @@ test.beforeEach("Credentials - User", async ({ request }) => {
 });
 
 test.beforeEach("Credentials - Feed", async ({ request }) => {
-  url = faker.internet.url();
-  url2 = faker.internet.url();
+  url = feedURL();
+  url2 = feedURL();
   const response = await request.post("/v2/feeds", {
     headers: {
       Authorization: `Bearer ${authToken}`,
@@ -111,7 +112,7 @@ test.afterEach("Remove Credentials", async ({ request }) => {
 test.describe("Create Feed", () => {
   test("Create Feed", async ({ request }) => {
     const name = faker.lorem.word();
-    const url2 = faker.internet.url();
+    const url2 = feedURL();
     const response = await request.post("/v2/feeds", {
       headers: {
         Authorization: `Bearer ${authToken}`,
@@ -129,6 +130,11 @@ test.describe("Create Feed", () => {
     expect(json).toHaveProperty("name", name);
     expect(json).toHaveProperty("url", url2);
     expect(json).toHaveProperty("id");
+    // Channel metadata from the fetched document
+    expect(json).toHaveProperty("language", "en");
+    expect(json).toHaveProperty("ttl", 60);
+    expect(json).toHaveProperty("skip_hours", [1, 2]);
+    expect(json.image_url).toContain("/images/");
     await request.delete(`/v2/feeds/${json.id}`, {
       headers: {
         Authorization: `Bearer ${authToken}`,
@@ -140,7 +146,7 @@ test.describe("Create Feed", () => {
     const response = await request.post("/v2/feeds", {
       data: {
         name: faker.lorem.word(),
-        url: faker.internet.url(),
+        url: feedURL(),
       },
     });
     // Validate status code
@@ -183,12 +189,100 @@ test.describe("Create Feed", () => {
     const json = await response.json();
     expect(json).toHaveProperty("error", "Invalid URL");
   });
+
+  test("Create Feed - Autodiscovery from HTML page", async ({ request }) => {
+    const page = feedURL("page");
+    const response = await request.post("/v2/feeds", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        name: faker.lorem.word(),
+        url: page,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(201);
+    // Validate response body, the advertised feed URL is stored
+    const json = await response.json();
+    expect(json).toHaveProperty("url", page.replace("/page/", "/rss/"));
+    await request.delete(`/v2/feeds/${json.id}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+  });
+
+  test("Create Feed - Name from channel title", async ({ request }) => {
+    const atom = feedURL("atom");
+    const response = await request.post("/v2/feeds", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        name: "",
+        url: atom,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(201);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("name", `Fixture atom ${atom.split("/").pop()}`);
+    await request.delete(`/v2/feeds/${json.id}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+  });
+
+  test("Create Feed - ISO-8859-1 feed", async ({ request }) => {
+    const latin1URL = feedURL("latin1");
+    const response = await request.post("/v2/feeds", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        name: "",
+        url: latin1URL,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(201);
+    // Validate the channel was transcoded to UTF-8
+    const json = await response.json();
+    expect(json.name).toBe(`Café crème ${latin1URL.split("/").pop()}`);
+    expect(json.description).toBe("Déjà vu");
+
+    await request.delete(`/v2/feeds/${json.id}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+  });
+
+  test("Create Feed - Not a feed", async ({ request }) => {
+    const response = await request.post("/v2/feeds", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        name: faker.lorem.word(),
+        url: feedURL("html"),
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(400);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "URL is not a feed");
+  });
 });
 
 test.describe("Update Feed", () => {
   test("Update Feed", async ({ request }) => {
     const name = faker.lorem.word();
-    const url2 = faker.internet.url();
+    const url2 = feedURL();
     const response = await request.put(`/v2/feeds/${feed_id}`, {
       headers: {
         Authorization: `Bearer ${authToken}`,
@@ -212,7 +306,7 @@ test.describe("Update Feed", () => {
     const response = await request.put(`/v2/feeds/${feed_id}`, {
       data: {
         name: faker.lorem.word(),
-        url: faker.internet.url(),
+        url: feedURL(),
       },
     });
     // Validate status code
@@ -230,7 +324,7 @@ test.describe("Update Feed", () => {
       },
       data: {
         name: faker.lorem.word(),
-        url: faker.internet.url(),
+        url: feedURL(),
       },
     });
     // Validate status code
@@ -282,7 +376,7 @@ test.describe("Update Feed", () => {
       },
       data: {
         name: faker.lorem.word(),
-        url: faker.internet.url(),
+        url: feedURL(),
       },
     });
     // Validate status code
@@ -356,6 +450,158 @@ test.describe("Get Feeds", () => {
     expect(json.length).toBeGreaterThanOrEqual(2);
   });
 
+  test("Get all feeds - Compressed", async ({ request }) => {
+    const response = await request.get(`/v2/feeds`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+        "Accept-Encoding": "gzip",
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Two feeds are above the compression threshold
+    expect(response.headers()["content-encoding"]).toBe("gzip");
+    expect(response.headers()["vary"]).toContain("Accept-Encoding");
+    const json = await response.json();
+    expect(json.length).toBeGreaterThanOrEqual(2);
+  });
+
+  test("Get all feeds - Small responses are not compressed", async ({ request }) => {
+    const response = await request.get(`/ready`, {
+      headers: {
+        "Accept-Encoding": "gzip, br",
+      },
+    });
+    expect(response.status()).toBe(200);
+    expect(response.headers()["content-encoding"]).toBeUndefined();
+  });
+
+  test("Get feeds - Paginated", async ({ request }) => {
+    const response = await request.get(`/v2/feeds?mine=true&limit=1`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate headers, the user owns two feeds
+    expect(response.headers()["x-total-count"]).toBe("2");
+    expect(response.headers()["link"]).toContain('rel="next"');
+    expect(response.headers()["link"]).not.toContain('rel="prev"');
+    // Validate response body
+    const json = await response.json();
+    expect(json.length).toBe(1);
+
+    const next = await request.get(`/v2/feeds?mine=true&limit=1&page=2`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(next.status()).toBe(200);
+    expect(next.headers()["link"]).toContain('rel="prev"');
+    expect(next.headers()["link"]).not.toContain('rel="next"');
+    const nextJson = await next.json();
+    expect(nextJson.length).toBe(1);
+    expect(nextJson[0].id).not.toBe(json[0].id);
+  });
+
+  test("Get feeds - Page past the end", async ({ request }) => {
+    const response = await request.get(`/v2/feeds?mine=true&limit=1&page=5`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate headers, the total still counts the user's two feeds
+    expect(response.headers()["x-total-count"]).toBe("2");
+    expect(response.headers()["link"]).toContain("page=4");
+    expect(response.headers()["link"]).not.toContain('rel="next"');
+    // Validate response body
+    expect(await response.json()).toEqual([]);
+  });
+
+  test("Get feeds - Search", async ({ request }) => {
+    // Fixture URLs end with a unique id
+    const q = url.split("/").pop();
+    const response = await request.get(`/v2/feeds?q=${q}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate response body
+    const json = await response.json();
+    expect(json.length).toBe(1);
+    expect(json[0]).toHaveProperty("id", feed_id);
+    expect(json[0]).toHaveProperty("follower_count", 0);
+    expect(json[0]).toHaveProperty("last_post_at");
+  });
+
+  test("Get feeds - Followed", async ({ request }) => {
+    const follow = await request.post(`/v3/follow`, {
+      headers: {
+        Authorization: `Bearer ${authToken2}`,
+      },
+      data: {
+        feed_id: feed_id2,
+      },
+    });
+    expect(follow.status()).toBe(201);
+
+    const response = await request.get(`/v2/feeds?followed=true`, {
+      headers: {
+        Authorization: `Bearer ${authToken2}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate response body
+    const json = await response.json();
+    expect(json.map((feed) => feed.id)).toEqual([feed_id2]);
+    expect(json[0]).toHaveProperty("follower_count", 1);
+  });
+
+  test("Get feeds - Sorted", async ({ request }) => {
+    const response = await request.get(`/v2/feeds?mine=true&sort=created&order=asc`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate response body
+    const json = await response.json();
+    expect(json.map((feed) => feed.id)).toEqual([feed_id, feed_id2]);
+  });
+
+  test("Get feeds - Invalid sort", async ({ request }) => {
+    const response = await request.get(`/v2/feeds?sort=random`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(400);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Invalid sort");
+  });
+
+  test("Get feeds - Invalid limit", async ({ request }) => {
+    const response = await request.get(`/v2/feeds?limit=1000`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(400);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Invalid limit");
+  });
+
   test("Get all feeds - Not Authorized", async ({ request }) => {
     const response = await request.get(`/v2/feeds`);
     // Validate status code
@@ -364,4 +610,237 @@ test.describe("Get Feeds", () => {
     const json = await response.json();
     expect(json).toHaveProperty("error", "Unauthorized");
   });
-});
\ No newline at end of file
+});
+
+test.describe("Get Feed", () => {
+  test("Get Feed", async ({ request }) => {
+    const follow = await request.post(`/v3/follow`, {
+      headers: {
+        Authorization: `Bearer ${authToken2}`,
+      },
+      data: {
+        feed_id: feed_id,
+      },
+    });
+    expect(follow.status()).toBe(201);
+
+    const response = await request.get(`/v2/feeds/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${authToken2}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("id", feed_id);
+    expect(json).toHaveProperty("url", url);
+    expect(json).toHaveProperty("follower_count", 1);
+    expect(json).toHaveProperty("followed", true);
+    expect(json).toHaveProperty("post_count");
+    expect(json).toHaveProperty("last_post_at");
+    expect(json).toHaveProperty("owner_name");
+  });
+
+  test("Get Feed - Not Found", async ({ request }) => {
+    const response = await request.get(`/v2/feeds/${faker.string.uuid()}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(404);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Feed don't exsist");
+  });
+
+  test("Get Feed - Invalid id", async ({ request }) => {
+    const response = await request.get(`/v2/feeds/abc`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(400);
+  });
+
+  test("Get Feed - Not Authorized", async ({ request }) => {
+    const response = await request.get(`/v2/feeds/${feed_id}`);
+    // Validate status code
+    expect(response.status()).toBe(401);
+  });
+});
+test.describe("Refresh Feed", () => {
+  test("Refresh Feed", async ({ request }) => {
+    const response = await request.post(`/v2/feeds/${feed_id}/refresh`, {
+      headers: {
+        Authorization: `Bearer ${authToken2}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(202);
+    // Validate response body
+    const job = await response.json();
+    expect(job).toHaveProperty("feed_id", feed_id);
+    expect(["queued", "running"]).toContain(job.status);
+    expect(response.headers()["location"]).toBe(`/v2/feeds/${feed_id}/refresh/${job.id}`);
+
+    // Poll the job until the worker is done with it
+    await expect
+      .poll(
+        async () => {
+          const res = await request.get(`/v2/feeds/${feed_id}/refresh/${job.id}`, {
+            headers: {
+              Authorization: `Bearer ${authToken2}`,
+            },
+          });
+          return (await res.json()).status;
+        },
+        { timeout: 15000 }
+      )
+      .toBe("succeeded");
+  });
+
+  test("Refresh Feed - Rate limited", async ({ request }) => {
+    const first = await request.post(`/v2/feeds/${feed_id2}/refresh`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(first.status()).toBe(202);
+    const job = await first.json();
+
+    // Wait for the job to end, a pending job would be returned again
+    await expect
+      .poll(
+        async () => {
+          const res = await request.get(`/v2/feeds/${feed_id2}/refresh/${job.id}`, {
+            headers: {
+              Authorization: `Bearer ${authToken}`,
+            },
+          });
+          return (await res.json()).status;
+        },
+        { timeout: 15000 }
+      )
+      .toMatch(/succeeded|failed/);
+
+    const response = await request.post(`/v2/feeds/${feed_id2}/refresh`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(429);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Feed was refreshed recently");
+  });
+
+  test("Refresh Feed - Concurrent requests share the job", async ({ request }) => {
+    const responses = await Promise.all(
+      [authToken, authToken2].map((token) =>
+        request.post(`/v2/feeds/${feed_id}/refresh`, {
+          headers: {
+            Authorization: `Bearer ${token}`,
+          },
+        })
+      )
+    );
+    // Validate status codes
+    for (const response of responses) {
+      expect(response.status()).toBe(202);
+    }
+    // Validate both got the one pending job
+    const [first, second] = await Promise.all(responses.map((response) => response.json()));
+    expect(second.id).toBe(first.id);
+  });
+
+  test("Refresh Feed - Not Found", async ({ request }) => {
+    const response = await request.post(`/v2/feeds/${faker.string.uuid()}/refresh`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(404);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Feed don't exsist");
+  });
+
+  test("Refresh Feed - Not Authorized", async ({ request }) => {
+    const response = await request.post(`/v2/feeds/${feed_id}/refresh`);
+    // Validate status code
+    expect(response.status()).toBe(401);
+  });
+});
+
+test.describe("Feed Fetch History", () => {
+  test("Feed Fetch History", async ({ request }) => {
+    const refresh = await request.post(`/v2/feeds/${feed_id}/refresh`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(refresh.status()).toBe(202);
+
+    // The run is recorded once the refresh is done
+    await expect
+      .poll(
+        async () => {
+          const res = await request.get(`/v2/feeds/${feed_id}/fetches`, {
+            headers: {
+              Authorization: `Bearer ${authToken}`,
+            },
+          });
+          return (await res.json()).length;
+        },
+        { timeout: 15000 }
+      )
+      .toBe(1);
+
+    const response = await request.get(`/v2/feeds/${feed_id}/fetches`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate response body
+    const [run] = await response.json();
+    expect(run).toHaveProperty("source", "refresh");
+    expect(run).toHaveProperty("http_status", 200);
+    expect(run).toHaveProperty("items_seen", 1);
+    expect(run).toHaveProperty("new_posts", 1);
+    expect(run).toHaveProperty("error", null);
+    expect(run.bytes).toBeGreaterThan(0);
+  });
+
+  test("Feed Fetch History - Feed not owned by user", async ({ request }) => {
+    const response = await request.get(`/v2/feeds/${feed_id}/fetches`, {
+      headers: {
+        Authorization: `Bearer ${authToken2}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(403);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Forbidden");
+  });
+
+  test("Feed Fetch History - Invalid limit", async ({ request }) => {
+    const response = await request.get(`/v2/feeds/${feed_id}/fetches?limit=0`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(400);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Invalid limit");
+  });
+});
@@ -0,0 +1,348 @@
+import { test, expect } from "@playwright/test";
+import { faker } from "@faker-js/faker";
+import { feedURL } from "./helpers";
+
+let authToken, feed_id, folder_id;
+
+test.beforeEach("Credentials - User, Feed and Folder", async ({ request }) => {
+  const email = faker.internet.email();
+  const password = faker.internet.password();
+  const response = await request.post("/v1/user", {
+    data: {
+      email: email,
+      password: password,
+      name: faker.person.firstName(),
+    },
+  });
+  expect(response.status()).toBe(201);
+  const loginResponse = await request.post("/v1/login", {
+    form: {
+      username: email,
+      password: password,
+    },
+  });
+  expect(loginResponse.status()).toBe(200);
+  authToken = (await loginResponse.json()).token;
+
+  const feedResponse = await request.post("/v2/feeds", {
+    headers: {
+      Authorization: `Bearer ${authToken}`,
+    },
+    data: {
+      name: faker.lorem.word(),
+      url: feedURL(),
+    },
+  });
+  expect(feedResponse.status()).toBe(201);
+  feed_id = (await feedResponse.json()).id;
+
+  const folderResponse = await request.post("/v3/folders", {
+    headers: {
+      Authorization: `Bearer ${authToken}`,
+    },
+    data: {
+      name: "News",
+    },
+  });
+  expect(folderResponse.status()).toBe(201);
+  folder_id = (await folderResponse.json()).id;
+});
+
+test.afterEach("Remove Credentials", async ({ request }) => {
+  const res = await request.delete("/v1/user", {
+    headers: {
+      Authorization: `Bearer ${authToken}`,
+    },
+  });
+  expect(res.status()).toBe(204);
+});
+
+test.describe("Folders", () => {
+  test("Create Folder", async ({ request }) => {
+    const response = await request.post("/v3/folders", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        name: "Blogs",
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(201);
+    // Validate response body, new folders go last
+    const json = await response.json();
+    expect(json).toHaveProperty("name", "Blogs");
+    expect(json).toHaveProperty("position", 1);
+  });
+
+  test("Create Folder - Exist", async ({ request }) => {
+    const response = await request.post("/v3/folders", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        name: "News",
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(409);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Folder exist");
+  });
+
+  test("Create Folder - Invalid name", async ({ request }) => {
+    const response = await request.post("/v3/folders", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        name: "  ",
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(400);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Invalid folder name");
+  });
+
+  test("Get Folders - Ordered by position", async ({ request }) => {
+    const first = await request.post("/v3/folders", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        name: "Blogs",
+        position: -1,
+      },
+    });
+    expect(first.status()).toBe(201);
+
+    const response = await request.get("/v3/folders", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate response body
+    const json = await response.json();
+    expect(json.map((folder) => folder.name)).toEqual(["Blogs", "News"]);
+  });
+
+  test("Update Folder", async ({ request }) => {
+    const response = await request.put(`/v3/folders/${folder_id}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        name: "World news",
+        position: 5,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("name", "World news");
+    expect(json).toHaveProperty("position", 5);
+  });
+
+  test("Update Folder - Not found", async ({ request }) => {
+    const response = await request.put(`/v3/folders/${faker.string.uuid()}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        name: "World news",
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(404);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Folder not found");
+  });
+
+  test("Delete Folder - Follows are kept", async ({ request }) => {
+    const follow = await request.post("/v3/follow", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        feed_id: feed_id,
+        folder_id: folder_id,
+      },
+    });
+    expect(follow.status()).toBe(201);
+
+    const response = await request.delete(`/v3/folders/${folder_id}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(204);
+
+    // The feed is still followed, outside of any folder
+    const follows = await request.get("/v3/follow", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    const json = await follows.json();
+    expect(json).toHaveLength(1);
+    expect(json[0]).toHaveProperty("folder_id", null);
+  });
+
+  test("Folders - Not Authorized", async ({ request }) => {
+    const response = await request.get("/v3/folders");
+    // Validate status code
+    expect(response.status()).toBe(401);
+  });
+});
+
+test.describe("Follow Folder", () => {
+  test("Follow into Folder", async ({ request }) => {
+    const response = await request.post("/v3/follow", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        feed_id: feed_id,
+        folder_id: folder_id,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(201);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("folder_id", folder_id);
+  });
+
+  test("Follow into Folder - Folder not found", async ({ request }) => {
+    const response = await request.post("/v3/follow", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        feed_id: feed_id,
+        folder_id: faker.string.uuid(),
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(404);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Folder not found");
+  });
+
+  test("Move Follow", async ({ request }) => {
+    const follow = await request.post("/v3/follow", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        feed_id: feed_id,
+      },
+    });
+    expect(follow.status()).toBe(201);
+    expect(await follow.json()).toHaveProperty("folder_id", null);
+
+    const response = await request.put(`/v3/follow/${feed_id}/folder`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        folder_id: folder_id,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate response body
+    expect(await response.json()).toHaveProperty("folder_id", folder_id);
+
+    // A null folder takes the follow out of it
+    const out = await request.put(`/v3/follow/${feed_id}/folder`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        folder_id: null,
+      },
+    });
+    expect(out.status()).toBe(200);
+    expect(await out.json()).toHaveProperty("folder_id", null);
+  });
+
+  test("Move Follow - Not followed", async ({ request }) => {
+    const response = await request.put(`/v3/follow/${feed_id}/folder`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        folder_id: folder_id,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(404);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Feed not followed");
+  });
+});
+
+test.describe("Folder Timeline", () => {
+  test("Get Posts - Folder", async ({ request }) => {
+    const follow = await request.post("/v3/follow", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        feed_id: feed_id,
+        folder_id: folder_id,
+      },
+    });
+    expect(follow.status()).toBe(201);
+
+    const response = await request.get(`/v4/posts?folder_id=${folder_id}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate response body, only posts of the folder's feeds
+    const json = await response.json();
+    for (const post of json) {
+      expect(post).toHaveProperty("feed_id", feed_id);
+    }
+  });
+
+  test("Get Posts - Invalid folder id", async ({ request }) => {
+    const response = await request.get("/v4/posts?folder_id=abc", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(400);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Invalid folder id");
+  });
+
+  test("Get Posts - Folder not found", async ({ request }) => {
+    const response = await request.get(`/v4/posts?folder_id=${faker.string.uuid()}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(404);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Folder not found");
+  });
+});
@@ -1,5 +1,6 @@
 import { test, expect } from "@playwright/test";
 import { faker } from "@faker-js/faker";
+import { feedURL } from "./helpers";
 
 let email, password, authToken, feed_id, user_id, feed_id2;
 test.beforeAll("Credentials - User", async ({ request }) => {
@@ -35,7 +36,7 @@ test.beforeAll("Credentials - User", async ({ request }) => {
 test.beforeAll("Credentials - Feed", async ({ request }) => {
   // Feed 1
   const title = faker.lorem.word();
-  const url = faker.internet.url();
+  const url = feedURL();
   const response = await request.post("/v2/feeds", {
     headers: {
       Authorization: `Bearer ${authToken}`,
@@ -51,7 +52,7 @@ test.beforeAll("Credentials - Feed", async ({ request }) => {
 
   // Feed 2
   const title2 = faker.lorem.word();
-  const url2 = faker.internet.url();
+  const url2 = feedURL();
   const response2 = await request.post("/v2/feeds", {
     headers: {
       Authorization: `Bearer ${authToken}`,
@@ -229,6 +230,14 @@ test.describe("Get follows", () => {
     const json = await response.json();
     expect(Array.isArray(json)).toBe(true);
     expect(json.length).toBe(1);
+    // The feed comes with the follow
+    expect(json[0]).toHaveProperty("feed_id", feed_id2);
+    expect(json[0].feed).toHaveProperty("id", feed_id2);
+    expect(json[0].feed).toHaveProperty("owner_id", user_id);
+    expect(json[0].feed).toHaveProperty("name");
+    expect(json[0].feed).toHaveProperty("url");
+    expect(json[0]).toHaveProperty("post_count");
+    expect(json[0]).toHaveProperty("last_post_at");
   });
 
   test("Get all follows - Not Authorized", async ({ request }) => {
@@ -240,3 +249,83 @@ test.describe("Get follows", () => {
     expect(json).toHaveProperty("error", "Unauthorized");
   });
 });
+
+test.describe("Update follow", () => {
+  test("Update follow", async ({ request }) => {
+    const response = await request.patch(`/v3/follow/${feed_id2}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        title: "My feed",
+        muted: true,
+        priority: 10,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("title", "My feed");
+    expect(json).toHaveProperty("muted", true);
+    expect(json).toHaveProperty("priority", 10);
+
+    // Missing fields are kept, a blank title goes back to the feed name
+    const unmute = await request.patch(`/v3/follow/${feed_id2}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        title: "",
+        muted: false,
+      },
+    });
+    expect(unmute.status()).toBe(200);
+    const unmuted = await unmute.json();
+    expect(unmuted).toHaveProperty("title", null);
+    expect(unmuted).toHaveProperty("muted", false);
+    expect(unmuted).toHaveProperty("priority", 10);
+  });
+
+  test("Update follow - Invalid priority", async ({ request }) => {
+    const response = await request.patch(`/v3/follow/${feed_id2}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        priority: 1000,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(400);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Priority must be between -100 and 100");
+  });
+
+  test("Update follow - Never followed", async ({ request }) => {
+    const response = await request.patch(`/v3/follow/${faker.string.uuid()}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        muted: true,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(404);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Feed not followed");
+  });
+
+  test("Update follow - Not Authorized", async ({ request }) => {
+    const response = await request.patch(`/v3/follow/${feed_id2}`, {
+      data: {
+        muted: true,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(401);
+  });
+});
@@ -0,0 +1,414 @@
+import { test, expect } from "@playwright/test";
+import { faker } from "@faker-js/faker";
+import { createUser, feedURL } from "./helpers";
+
+const systemUserID = "00000000-0000-0000-0000-000000000000";
+
+let ownerToken, ownerID, otherToken, otherID, feed_id, url;
+
+test.beforeEach("Credentials - Users and Feed", async ({ request }) => {
+  const owner = await createUser(request);
+  ownerID = owner.id;
+  ownerToken = owner.token;
+  const other = await createUser(request);
+  otherID = other.id;
+  otherToken = other.token;
+
+  url = feedURL();
+  const feedResponse = await request.post("/v2/feeds", {
+    headers: {
+      Authorization: `Bearer ${ownerToken}`,
+    },
+    data: {
+      name: faker.lorem.word(),
+      url: url,
+    },
+  });
+  expect(feedResponse.status()).toBe(201);
+  feed_id = (await feedResponse.json()).id;
+});
+
+test.afterEach("Remove Credentials", async ({ request }) => {
+  // Either user may have been deleted by the test already
+  await request.delete("/v1/user", {
+    headers: {
+      Authorization: `Bearer ${ownerToken}`,
+    },
+  });
+  await request.delete("/v1/user", {
+    headers: {
+      Authorization: `Bearer ${otherToken}`,
+    },
+  });
+});
+
+test.describe("Feed Maintainers", () => {
+  test("Add Maintainer", async ({ request }) => {
+    const response = await request.post(`/v2/feeds/${feed_id}/maintainers`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+      data: {
+        user_id: otherID,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(201);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("user_id", otherID);
+
+    // The maintainer can update the feed but not delete it
+    const update = await request.put(`/v2/feeds/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${otherToken}`,
+      },
+      data: {
+        name: "Renamed by maintainer",
+        url: url,
+      },
+    });
+    expect(update.status()).toBe(200);
+    expect(await update.json()).toHaveProperty("user_id", ownerID);
+    const remove = await request.delete(`/v2/feeds/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${otherToken}`,
+      },
+    });
+    expect(remove.status()).toBe(403);
+
+    const maintainers = await request.get(`/v2/feeds/${feed_id}/maintainers`, {
+      headers: {
+        Authorization: `Bearer ${otherToken}`,
+      },
+    });
+    expect(maintainers.status()).toBe(200);
+    expect((await maintainers.json()).map((maintainer) => maintainer.user_id)).toEqual([otherID]);
+  });
+
+  test("Add Maintainer - Not the owner", async ({ request }) => {
+    const response = await request.post(`/v2/feeds/${feed_id}/maintainers`, {
+      headers: {
+        Authorization: `Bearer ${otherToken}`,
+      },
+      data: {
+        user_id: otherID,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(403);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Forbidden");
+  });
+
+  test("Add Maintainer - User not found", async ({ request }) => {
+    const response = await request.post(`/v2/feeds/${feed_id}/maintainers`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+      data: {
+        user_id: systemUserID,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(404);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "User not found");
+  });
+
+  test("Remove Maintainer - Themselves", async ({ request }) => {
+    const add = await request.post(`/v2/feeds/${feed_id}/maintainers`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+      data: {
+        user_id: otherID,
+      },
+    });
+    expect(add.status()).toBe(201);
+
+    const response = await request.delete(`/v2/feeds/${feed_id}/maintainers/${otherID}`, {
+      headers: {
+        Authorization: `Bearer ${otherToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(204);
+
+    const update = await request.put(`/v2/feeds/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${otherToken}`,
+      },
+      data: {
+        name: "Renamed",
+        url: url,
+      },
+    });
+    expect(update.status()).toBe(403);
+  });
+});
+
+test.describe("Ownership Transfer", () => {
+  test("Transfer and accept", async ({ request }) => {
+    const response = await request.post(`/v2/feeds/${feed_id}/transfers`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+      data: {
+        user_id: otherID,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(201);
+    // Validate response body
+    const transfer = await response.json();
+    expect(transfer).toHaveProperty("status", "pending");
+    expect(transfer).toHaveProperty("to_user_id", otherID);
+
+    // The recipient sees the transfer
+    const transfers = await request.get(`/v2/transfers`, {
+      headers: {
+        Authorization: `Bearer ${otherToken}`,
+      },
+    });
+    expect((await transfers.json()).map((t) => t.id)).toContain(transfer.id);
+
+    // Only the recipient accepts it
+    const forbidden = await request.post(`/v2/transfers/${transfer.id}/accept`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+    });
+    expect(forbidden.status()).toBe(403);
+
+    const accept = await request.post(`/v2/transfers/${transfer.id}/accept`, {
+      headers: {
+        Authorization: `Bearer ${otherToken}`,
+      },
+    });
+    expect(accept.status()).toBe(200);
+    expect(await accept.json()).toHaveProperty("status", "accepted");
+
+    // The feed changed owner and the previous owner maintains it
+    const feed = await request.get(`/v2/feeds/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${otherToken}`,
+      },
+    });
+    expect(await feed.json()).toHaveProperty("user_id", otherID);
+    const maintainers = await request.get(`/v2/feeds/${feed_id}/maintainers`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+    });
+    expect((await maintainers.json()).map((maintainer) => maintainer.user_id)).toEqual([ownerID]);
+
+    // Answered transfers can't be answered again
+    const again = await request.post(`/v2/transfers/${transfer.id}/decline`, {
+      headers: {
+        Authorization: `Bearer ${otherToken}`,
+      },
+    });
+    expect(again.status()).toBe(409);
+  });
+
+  test("Transfer and decline", async ({ request }) => {
+    const response = await request.post(`/v2/feeds/${feed_id}/transfers`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+      data: {
+        user_id: otherID,
+      },
+    });
+    expect(response.status()).toBe(201);
+    const transfer = await response.json();
+
+    const decline = await request.post(`/v2/transfers/${transfer.id}/decline`, {
+      headers: {
+        Authorization: `Bearer ${otherToken}`,
+      },
+    });
+    // Validate status code
+    expect(decline.status()).toBe(200);
+    // Validate response body
+    expect(await decline.json()).toHaveProperty("status", "declined");
+
+    const feed = await request.get(`/v2/feeds/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+    });
+    expect(await feed.json()).toHaveProperty("user_id", ownerID);
+  });
+
+  test("Transfer - Already pending", async ({ request }) => {
+    const first = await request.post(`/v2/feeds/${feed_id}/transfers`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+      data: {
+        user_id: otherID,
+      },
+    });
+    expect(first.status()).toBe(201);
+
+    const response = await request.post(`/v2/feeds/${feed_id}/transfers`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+      data: {
+        user_id: otherID,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(409);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Transfer already pending");
+  });
+
+  test("Transfer - Not the owner", async ({ request }) => {
+    const response = await request.post(`/v2/feeds/${feed_id}/transfers`, {
+      headers: {
+        Authorization: `Bearer ${otherToken}`,
+      },
+      data: {
+        user_id: otherID,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(403);
+  });
+});
+
+test.describe("Delete Owner", () => {
+  test("Delete Owner - Feed goes to a maintainer", async ({ request }) => {
+    const add = await request.post(`/v2/feeds/${feed_id}/maintainers`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+      data: {
+        user_id: otherID,
+      },
+    });
+    expect(add.status()).toBe(201);
+
+    const response = await request.delete("/v1/user", {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(204);
+
+    const feed = await request.get(`/v2/feeds/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${otherToken}`,
+      },
+    });
+    expect(feed.status()).toBe(200);
+    expect(await feed.json()).toHaveProperty("user_id", otherID);
+  });
+
+  test("Delete Owner - Followed feed goes to the system user", async ({ request }) => {
+    const follow = await request.post(`/v3/follow`, {
+      headers: {
+        Authorization: `Bearer ${otherToken}`,
+      },
+      data: {
+        feed_id: feed_id,
+      },
+    });
+    expect(follow.status()).toBe(201);
+
+    const response = await request.delete("/v1/user", {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(204);
+
+    // The follower keeps the feed
+    const feed = await request.get(`/v2/feeds/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${otherToken}`,
+      },
+    });
+    expect(feed.status()).toBe(200);
+    const json = await feed.json();
+    expect(json).toHaveProperty("user_id", systemUserID);
+    expect(json).toHaveProperty("followed", true);
+  });
+
+  test("Delete Owner - Deleted maintainer doesn't get the feed", async ({ request }) => {
+    const add = await request.post(`/v2/feeds/${feed_id}/maintainers`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+      data: {
+        user_id: otherID,
+      },
+    });
+    expect(add.status()).toBe(201);
+    const follower = await createUser(request);
+    const follow = await request.post(`/v3/follow`, {
+      headers: {
+        Authorization: `Bearer ${follower.token}`,
+      },
+      data: {
+        feed_id: feed_id,
+      },
+    });
+    expect(follow.status()).toBe(201);
+    const removeMaintainer = await request.delete("/v1/user", {
+      headers: {
+        Authorization: `Bearer ${otherToken}`,
+      },
+    });
+    expect(removeMaintainer.status()).toBe(204);
+
+    const response = await request.delete("/v1/user", {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(204);
+
+    // The feed goes to the system user, so purging the maintainer won't take it
+    const feed = await request.get(`/v2/feeds/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${follower.token}`,
+      },
+    });
+    expect(feed.status()).toBe(200);
+    expect(await feed.json()).toHaveProperty("user_id", systemUserID);
+
+    await request.delete("/v1/user", {
+      headers: {
+        Authorization: `Bearer ${follower.token}`,
+      },
+    });
+  });
+
+  test("Delete Owner - Unshared feed is deleted", async ({ request }) => {
+    const response = await request.delete("/v1/user", {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(204);
+
+    const feed = await request.get(`/v2/feeds/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${otherToken}`,
+      },
+    });
+    expect(feed.status()).toBe(404);
+  });
+});
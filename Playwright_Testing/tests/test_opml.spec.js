@@ -0,0 +1,231 @@
+import { test, expect } from "@playwright/test";
+import { faker } from "@faker-js/faker";
+import { createUser, feedURL } from "./helpers";
+
+let authToken, feed_id, url;
+
+function opml(outlines) {
+  return `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0">
+  <head><title>Test subscriptions</title></head>
+  <body>
+    ${outlines}
+  </body>
+</opml>`;
+}
+
+test.beforeEach("Credentials - User and Feed", async ({ request }) => {
+  const email = faker.internet.email();
+  const password = faker.internet.password();
+  const response = await request.post("/v1/user", {
+    data: {
+      email: email,
+      password: password,
+      name: faker.person.firstName(),
+    },
+  });
+  expect(response.status()).toBe(201);
+  const loginResponse = await request.post("/v1/login", {
+    form: {
+      username: email,
+      password: password,
+    },
+  });
+  expect(loginResponse.status()).toBe(200);
+  authToken = (await loginResponse.json()).token;
+
+  url = feedURL();
+  const feedResponse = await request.post("/v2/feeds", {
+    headers: {
+      Authorization: `Bearer ${authToken}`,
+    },
+    data: {
+      name: faker.lorem.word(),
+      url: url,
+    },
+  });
+  expect(feedResponse.status()).toBe(201);
+  feed_id = (await feedResponse.json()).id;
+});
+
+test.afterEach("Remove Credentials", async ({ request }) => {
+  // Feeds created by the import belong to the user and go away with it
+  const res = await request.delete("/v1/user", {
+    headers: {
+      Authorization: `Bearer ${authToken}`,
+    },
+  });
+  expect(res.status()).toBe(204);
+});
+
+// Polls an import until it is done and returns the finished job
+async function finishedImport(request, response) {
+  expect(response.status()).toBe(202);
+  const job = await response.json();
+  expect(response.headers()["location"]).toBe(`/v3/follow/opml/${job.id}`);
+  let finished;
+  await expect
+    .poll(
+      async () => {
+        const res = await request.get(`/v3/follow/opml/${job.id}`, {
+          headers: {
+            Authorization: `Bearer ${authToken}`,
+          },
+        });
+        finished = await res.json();
+        return finished.status;
+      },
+      { timeout: 15000 }
+    )
+    .toBe("succeeded");
+  return finished;
+}
+
+test.describe("Import OPML", () => {
+  test("Import OPML", async ({ request }) => {
+    const newURL = feedURL("atom");
+    const htmlURL = feedURL("html");
+    const response = await request.post("/v3/follow/opml", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+        "Content-Type": "text/x-opml",
+      },
+      data: opml(`
+    <outline text="Existing" xmlUrl="${url}"/>
+    <outline text="News">
+      <outline text="New feed" xmlUrl="${newURL}"/>
+      <outline text="Not a feed" xmlUrl="${htmlURL}"/>
+    </outline>
+    <outline text="Existing again" xmlUrl="${url}"/>`),
+    });
+    // Validate the queued job
+    expect((await response.json()).report).toBeNull();
+    // Validate the report
+    const { report } = await finishedImport(request, response);
+    expect(report).toHaveProperty("created", 1);
+    expect(report).toHaveProperty("followed", 1);
+    expect(report).toHaveProperty("failed", 1);
+    expect(report.entries.map((entry) => entry.status)).toEqual([
+      "followed",
+      "created",
+      "failed",
+      "duplicate",
+    ]);
+    expect(report.entries[0]).toHaveProperty("feed_id", feed_id);
+    expect(report.entries[1]).toHaveProperty("folder", "News");
+    expect(report.entries[2]).toHaveProperty("error", "URL is not a feed");
+
+    // Validate the follows
+    const follows = await request.get("/v3/follow", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    const feedIDs = (await follows.json()).map((follow) => follow.feed_id);
+    expect(feedIDs).toContain(feed_id);
+    expect(feedIDs).toContain(report.entries[1].feed_id);
+  });
+
+  test("Import OPML - Multipart upload", async ({ request }) => {
+    const response = await request.post("/v3/follow/opml", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      multipart: {
+        file: {
+          name: "subscriptions.opml",
+          mimeType: "text/x-opml",
+          buffer: Buffer.from(opml(`<outline text="Existing" xmlUrl="${url}"/>`)),
+        },
+      },
+    });
+    // Validate the report
+    const { report } = await finishedImport(request, response);
+    expect(report.entries[0]).toHaveProperty("status", "followed");
+
+    // Importing again changes nothing
+    const again = await request.post("/v3/follow/opml", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+        "Content-Type": "text/x-opml",
+      },
+      data: opml(`<outline text="Existing" xmlUrl="${url}"/>`),
+    });
+    expect((await finishedImport(request, again)).report.entries[0]).toHaveProperty(
+      "status",
+      "already_followed"
+    );
+  });
+
+  test("Import OPML - Job of another user", async ({ request }) => {
+    const response = await request.post("/v3/follow/opml", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+        "Content-Type": "text/x-opml",
+      },
+      data: opml(`<outline text="Existing" xmlUrl="${url}"/>`),
+    });
+    expect(response.status()).toBe(202);
+    const other = await createUser(request);
+    const job = await request.get(response.headers()["location"], {
+      headers: {
+        Authorization: `Bearer ${other.token}`,
+      },
+    });
+    // Validate status code
+    expect(job.status()).toBe(404);
+    // Validate response body
+    const json = await job.json();
+    expect(json).toHaveProperty("error", "Job not found");
+  });
+
+  test("Import OPML - Invalid file", async ({ request }) => {
+    const response = await request.post("/v3/follow/opml", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+        "Content-Type": "text/x-opml",
+      },
+      data: "<rss></rss>",
+    });
+    // Validate status code
+    expect(response.status()).toBe(400);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Invalid OPML file");
+  });
+
+  test("Import OPML - Not Authorized", async ({ request }) => {
+    const response = await request.post("/v3/follow/opml", {
+      data: opml(""),
+    });
+    // Validate status code
+    expect(response.status()).toBe(401);
+  });
+});
+
+test.describe("Export OPML", () => {
+  test("Export OPML", async ({ request }) => {
+    const follow = await request.post("/v3/follow", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        feed_id: feed_id,
+      },
+    });
+    expect(follow.status()).toBe(201);
+
+    const response = await request.get("/v3/follow/opml", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    expect(response.headers()["content-type"]).toContain("text/x-opml");
+    // Validate response body
+    const body = await response.text();
+    expect(body).toContain('<opml version="2.0">');
+    expect(body).toContain(`xmlUrl="${url}"`);
+  });
+});
@@ -0,0 +1,256 @@
+import { test, expect } from "@playwright/test";
+import { faker } from "@faker-js/faker";
+import { createUser, feedURL } from "./helpers";
+
+let ownerToken, otherToken, feed_id;
+
+async function timeline(request) {
+  const response = await request.get("/v4/posts", {
+    headers: {
+      Authorization: `Bearer ${ownerToken}`,
+    },
+  });
+  expect(response.status()).toBe(200);
+  return (await response.json()).map((post) => post.id);
+}
+
+test.beforeEach("Credentials - Users and Feed", async ({ request }) => {
+  ownerToken = (await createUser(request)).token;
+  otherToken = (await createUser(request)).token;
+
+  const feedResponse = await request.post("/v2/feeds", {
+    headers: {
+      Authorization: `Bearer ${ownerToken}`,
+    },
+    data: {
+      name: faker.lorem.word(),
+      url: feedURL(),
+    },
+  });
+  expect(feedResponse.status()).toBe(201);
+  feed_id = (await feedResponse.json()).id;
+
+  const follow = await request.post("/v3/follow", {
+    headers: {
+      Authorization: `Bearer ${ownerToken}`,
+    },
+    data: {
+      feed_id: feed_id,
+    },
+  });
+  expect(follow.status()).toBe(201);
+});
+
+test.afterEach("Remove Credentials", async ({ request }) => {
+  for (const token of [ownerToken, otherToken]) {
+    const res = await request.delete("/v1/user", {
+      headers: {
+        Authorization: `Bearer ${token}`,
+      },
+    });
+    expect(res.status()).toBe(204);
+  }
+});
+
+test.describe("Feed Posts", () => {
+  test("Create Post", async ({ request }) => {
+    const response = await request.post(`/v2/feeds/${feed_id}/posts`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+      data: {
+        title: "Release notes",
+        body: "Version **2** is out <script>alert(1)</script>",
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(201);
+    // Validate response body, the Markdown is rendered and sanitized
+    const json = await response.json();
+    expect(json).toHaveProperty("manual", true);
+    expect(json).toHaveProperty("feed_id", feed_id);
+    expect(json.description).toContain("<strong>2</strong>");
+    expect(json.description).not.toContain("<script>");
+
+    // Published posts show up in the timeline
+    expect(await timeline(request)).toContain(json.id);
+  });
+
+  test("Create Post - Scheduled", async ({ request }) => {
+    const publishAt = new Date(Date.now() + 60 * 60 * 1000).toISOString();
+    const response = await request.post(`/v2/feeds/${feed_id}/posts`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+      data: {
+        title: "Coming soon",
+        body: "Later",
+        publish_at: publishAt,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(201);
+    const json = await response.json();
+
+    // Scheduled posts stay out of the timeline but are listed for the owner
+    expect(await timeline(request)).not.toContain(json.id);
+    const posts = await request.get(`/v2/feeds/${feed_id}/posts`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+    });
+    expect(posts.status()).toBe(200);
+    expect(posts.headers()["x-total-count"]).toBe("1");
+    expect((await posts.json()).map((post) => post.id)).toEqual([json.id]);
+
+    // Nor do they count towards the feed's activity
+    const feed = await request.get(`/v2/feeds/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${otherToken}`,
+      },
+    });
+    expect(feed.status()).toBe(200);
+    const detail = await feed.json();
+    expect(detail).toHaveProperty("post_count", 0);
+    expect(detail).toHaveProperty("last_post_at", null);
+  });
+
+  test("Create Post - Missing title", async ({ request }) => {
+    const response = await request.post(`/v2/feeds/${feed_id}/posts`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+      data: {
+        title: " ",
+        body: "No title",
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(400);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Title is required");
+  });
+
+  test("Create Post - Not the owner", async ({ request }) => {
+    const response = await request.post(`/v2/feeds/${feed_id}/posts`, {
+      headers: {
+        Authorization: `Bearer ${otherToken}`,
+      },
+      data: {
+        title: "Hijack",
+        body: "Not mine",
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(403);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Forbidden");
+  });
+
+  test("Get Posts - Text format", async ({ request }) => {
+    const create = await request.post(`/v2/feeds/${feed_id}/posts`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+      data: {
+        title: "Plain",
+        body: "Some **bold** words",
+      },
+    });
+    expect(create.status()).toBe(201);
+
+    const response = await request.get(`/v2/feeds/${feed_id}/posts?format=text`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate response body, the HTML is turned into plain text
+    const json = await response.json();
+    expect(json[0].description).toContain("Some bold words");
+    expect(json[0].description).not.toContain("<strong>");
+
+    const invalid = await request.get(`/v2/feeds/${feed_id}/posts?format=pdf`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+    });
+    expect(invalid.status()).toBe(400);
+    expect(await invalid.json()).toHaveProperty("error", "Invalid format");
+  });
+
+  test("Update Post", async ({ request }) => {
+    const create = await request.post(`/v2/feeds/${feed_id}/posts`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+      data: {
+        title: "Draft",
+        body: "First",
+      },
+    });
+    expect(create.status()).toBe(201);
+    const post = await create.json();
+
+    const response = await request.put(`/v2/feeds/${feed_id}/posts/${post.id}`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+      data: {
+        title: "Final",
+        body: "_Second_",
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate response body, the publication time is kept
+    const json = await response.json();
+    expect(json).toHaveProperty("title", "Final");
+    expect(json).toHaveProperty("body", "_Second_");
+    expect(json).toHaveProperty("published_at", post.published_at);
+    expect(json.description).toContain("<em>Second</em>");
+  });
+
+  test("Update Post - Not found", async ({ request }) => {
+    const response = await request.put(`/v2/feeds/${feed_id}/posts/${faker.string.uuid()}`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+      data: {
+        title: "Final",
+        body: "Nothing",
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(404);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Post not found");
+  });
+
+  test("Delete Post", async ({ request }) => {
+    const create = await request.post(`/v2/feeds/${feed_id}/posts`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+      data: {
+        title: "Short lived",
+        body: "Gone soon",
+      },
+    });
+    expect(create.status()).toBe(201);
+    const post = await create.json();
+
+    const response = await request.delete(`/v2/feeds/${feed_id}/posts/${post.id}`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(204);
+    expect(await timeline(request)).not.toContain(post.id);
+  });
+});
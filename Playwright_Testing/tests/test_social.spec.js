@@ -0,0 +1,291 @@
+import { test, expect } from "@playwright/test";
+import { faker } from "@faker-js/faker";
+import { createUser, feedURL } from "./helpers";
+
+const systemUserID = "00000000-0000-0000-0000-000000000000";
+
+let authToken, userID, authorToken, authorID, authorName, feed_id;
+
+test.beforeEach("Credentials - Users and Feed", async ({ request }) => {
+  const user = await createUser(request);
+  userID = user.id;
+  authToken = user.token;
+  authorName = faker.person.firstName();
+  const author = await createUser(request, authorName);
+  authorID = author.id;
+  authorToken = author.token;
+
+  const feedResponse = await request.post("/v2/feeds", {
+    headers: {
+      Authorization: `Bearer ${authorToken}`,
+    },
+    data: {
+      name: faker.lorem.word(),
+      url: feedURL(),
+    },
+  });
+  expect(feedResponse.status()).toBe(201);
+  feed_id = (await feedResponse.json()).id;
+});
+
+test.afterEach("Remove Credentials", async ({ request }) => {
+  for (const token of [authToken, authorToken]) {
+    const res = await request.delete("/v1/user", {
+      headers: {
+        Authorization: `Bearer ${token}`,
+      },
+    });
+    expect(res.status()).toBe(204);
+  }
+});
+
+test.describe("User Profiles", () => {
+  test("Get Profile", async ({ request }) => {
+    const response = await request.get(`/v1/users/${authorID}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate response body, the email stays private
+    const json = await response.json();
+    expect(json).toHaveProperty("name", authorName);
+    expect(json).not.toHaveProperty("email");
+    expect(json).toHaveProperty("feed_count", 1);
+    expect(json).toHaveProperty("followed", false);
+    expect(json.feeds.map((feed) => feed.id)).toEqual([feed_id]);
+  });
+
+  test("Get Profile - Not found", async ({ request }) => {
+    const response = await request.get(`/v1/users/${faker.string.uuid()}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(404);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "User not found");
+  });
+
+  test("Get User Feeds", async ({ request }) => {
+    const response = await request.get(`/v1/users/${authorID}/feeds`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate headers and body
+    expect(response.headers()["x-total-count"]).toBe("1");
+    const json = await response.json();
+    expect(json[0]).toHaveProperty("user_id", authorID);
+  });
+});
+
+test.describe("User Follows", () => {
+  test("Follow User", async ({ request }) => {
+    const response = await request.post(`/v1/users/${authorID}/follow`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(201);
+    // Validate response body
+    expect(await response.json()).toHaveProperty("id", authorID);
+
+    const profile = await request.get(`/v1/users/${authorID}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    const json = await profile.json();
+    expect(json).toHaveProperty("followed", true);
+    expect(json).toHaveProperty("follower_count", 1);
+
+    const followers = await request.get(`/v1/users/${authorID}/followers`, {
+      headers: {
+        Authorization: `Bearer ${authorToken}`,
+      },
+    });
+    expect((await followers.json()).map((user) => user.id)).toEqual([userID]);
+    const following = await request.get(`/v1/users/${userID}/following`, {
+      headers: {
+        Authorization: `Bearer ${authorToken}`,
+      },
+    });
+    expect((await following.json()).map((user) => user.id)).toEqual([authorID]);
+  });
+
+  test("Follow User - Already followed", async ({ request }) => {
+    const first = await request.post(`/v1/users/${authorID}/follow`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(first.status()).toBe(201);
+
+    const response = await request.post(`/v1/users/${authorID}/follow`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(409);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "User already followed");
+  });
+
+  test("System user - Not found", async ({ request }) => {
+    const headers = {
+      Authorization: `Bearer ${authToken}`,
+    };
+    const profile = await request.get(`/v1/users/${systemUserID}`, { headers });
+    expect(profile.status()).toBe(404);
+    const feeds = await request.get(`/v1/users/${systemUserID}/feeds`, { headers });
+    expect(feeds.status()).toBe(404);
+    const follow = await request.post(`/v1/users/${systemUserID}/follow`, { headers });
+    expect(follow.status()).toBe(404);
+  });
+
+  test("Follow User - Themselves", async ({ request }) => {
+    const response = await request.post(`/v1/users/${userID}/follow`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(400);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Can't follow yourself");
+  });
+
+  test("Unfollow User", async ({ request }) => {
+    const follow = await request.post(`/v1/users/${authorID}/follow`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(follow.status()).toBe(201);
+
+    const response = await request.delete(`/v1/users/${authorID}/follow`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(204);
+
+    const again = await request.delete(`/v1/users/${authorID}/follow`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(again.status()).toBe(404);
+  });
+});
+
+test.describe("Network Timeline", () => {
+  test("Get Posts - Network", async ({ request }) => {
+    const post = await request.post(`/v2/feeds/${feed_id}/posts`, {
+      headers: {
+        Authorization: `Bearer ${authorToken}`,
+      },
+      data: {
+        title: "For my followers",
+        body: "Hello",
+      },
+    });
+    expect(post.status()).toBe(201);
+    const post_id = (await post.json()).id;
+
+    const follow = await request.post(`/v1/users/${authorID}/follow`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(follow.status()).toBe(201);
+
+    // The feed isn't followed, only its owner
+    const timeline = await request.get("/v4/posts", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect((await timeline.json()).map((p) => p.id)).not.toContain(post_id);
+
+    const response = await request.get("/v4/posts?scope=network", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate response body
+    expect((await response.json()).map((p) => p.id)).toContain(post_id);
+  });
+
+  test("Get Posts - Network skips unlisted feeds", async ({ request }) => {
+    const feedResponse = await request.post("/v2/feeds", {
+      headers: {
+        Authorization: `Bearer ${authorToken}`,
+      },
+      data: {
+        name: faker.lorem.word(),
+        url: feedURL(),
+        visibility: "unlisted",
+      },
+    });
+    expect(feedResponse.status()).toBe(201);
+    const unlisted_id = (await feedResponse.json()).id;
+
+    const post = await request.post(`/v2/feeds/${unlisted_id}/posts`, {
+      headers: {
+        Authorization: `Bearer ${authorToken}`,
+      },
+      data: {
+        title: "Only with the link",
+        body: "Hello",
+      },
+    });
+    expect(post.status()).toBe(201);
+    const post_id = (await post.json()).id;
+
+    const follow = await request.post(`/v1/users/${authorID}/follow`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(follow.status()).toBe(201);
+
+    const response = await request.get("/v4/posts?scope=network", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate response body
+    const posts = await response.json();
+    expect(posts.map((p) => p.id)).not.toContain(post_id);
+    expect(posts.map((p) => p.feed_id)).not.toContain(unlisted_id);
+  });
+
+  test("Get Posts - Invalid scope", async ({ request }) => {
+    const response = await request.get("/v4/posts?scope=everyone", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(400);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Invalid scope");
+  });
+});
@@ -0,0 +1,281 @@
+import { test, expect } from "@playwright/test";
+import { faker } from "@faker-js/faker";
+import { feedURL } from "./helpers";
+
+let authToken, feed_id, post_id;
+
+test.beforeEach("Credentials - User, Feed and Post", async ({ request }) => {
+  const email = faker.internet.email();
+  const password = faker.internet.password();
+  const response = await request.post("/v1/user", {
+    data: {
+      email: email,
+      password: password,
+      name: faker.person.firstName(),
+    },
+  });
+  expect(response.status()).toBe(201);
+  const loginResponse = await request.post("/v1/login", {
+    form: {
+      username: email,
+      password: password,
+    },
+  });
+  expect(loginResponse.status()).toBe(200);
+  authToken = (await loginResponse.json()).token;
+
+  const feedResponse = await request.post("/v2/feeds", {
+    headers: {
+      Authorization: `Bearer ${authToken}`,
+    },
+    data: {
+      name: faker.lorem.word(),
+      url: feedURL(),
+    },
+  });
+  expect(feedResponse.status()).toBe(201);
+  feed_id = (await feedResponse.json()).id;
+
+  const postResponse = await request.post(`/v2/feeds/${feed_id}/posts`, {
+    headers: {
+      Authorization: `Bearer ${authToken}`,
+    },
+    data: {
+      title: "Served back out",
+      body: "Hello **readers**",
+    },
+  });
+  expect(postResponse.status()).toBe(201);
+  post_id = (await postResponse.json()).id;
+});
+
+test.afterEach("Remove Credentials", async ({ request }) => {
+  const res = await request.delete("/v1/user", {
+    headers: {
+      Authorization: `Bearer ${authToken}`,
+    },
+  });
+  expect(res.status()).toBe(204);
+});
+
+test.describe("Feed Documents", () => {
+  test("Get Feed - RSS", async ({ request }) => {
+    const response = await request.get(`/feeds/${feed_id}.rss`);
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate headers and body
+    expect(response.headers()["content-type"]).toBe("application/rss+xml; charset=utf-8");
+    expect(response.headers()["etag"]).toBeTruthy();
+    expect(response.headers()["last-modified"]).toBeTruthy();
+    const body = await response.text();
+    expect(body).toContain('<rss version="2.0"');
+    expect(body).toContain(`<guid isPermaLink="false">${post_id}</guid>`);
+  });
+
+  test("Get Feed - Atom", async ({ request }) => {
+    const response = await request.get(`/feeds/${feed_id}.atom`);
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate headers and body
+    expect(response.headers()["content-type"]).toBe("application/atom+xml; charset=utf-8");
+    const body = await response.text();
+    expect(body).toContain('<feed xmlns="http://www.w3.org/2005/Atom"');
+    expect(body).toContain(`<id>urn:uuid:${post_id}</id>`);
+  });
+
+  test("Get Feed - JSON Feed", async ({ request }) => {
+    const response = await request.get(`/feeds/${feed_id}.json`);
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate headers and body
+    expect(response.headers()["content-type"]).toBe("application/feed+json; charset=utf-8");
+    const json = await response.json();
+    expect(json).toHaveProperty("version", "https://jsonfeed.org/version/1.1");
+    expect(json.items[0]).toHaveProperty("id", post_id);
+    expect(json.items[0].content_html).toContain("<strong>readers</strong>");
+  });
+
+  test("Get Feed - Not Modified", async ({ request }) => {
+    const first = await request.get(`/feeds/${feed_id}.rss`);
+    expect(first.status()).toBe(200);
+
+    const response = await request.get(`/feeds/${feed_id}.rss`, {
+      headers: {
+        "If-None-Match": first.headers()["etag"],
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(304);
+
+    const since = await request.get(`/feeds/${feed_id}.rss`, {
+      headers: {
+        "If-Modified-Since": first.headers()["last-modified"],
+      },
+    });
+    expect(since.status()).toBe(304);
+  });
+
+  test("Get Feed - Modified by a deleted post", async ({ request }) => {
+    const first = await request.get(`/feeds/${feed_id}.rss`);
+    expect(first.status()).toBe(200);
+    // Last-Modified has a one second resolution
+    await new Promise((resolve) => setTimeout(resolve, 1100));
+    const remove = await request.delete(`/v2/feeds/${feed_id}/posts/${post_id}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(remove.status()).toBe(204);
+
+    const response = await request.get(`/feeds/${feed_id}.rss`, {
+      headers: {
+        "If-Modified-Since": first.headers()["last-modified"],
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    expect(await response.text()).not.toContain(post_id);
+  });
+
+  test("Get Feed - Modified by an edited post", async ({ request }) => {
+    const first = await request.get(`/feeds/${feed_id}.rss`);
+    expect(first.status()).toBe(200);
+    // Last-Modified has a one second resolution
+    await new Promise((resolve) => setTimeout(resolve, 1100));
+    const edit = await request.put(`/v2/feeds/${feed_id}/posts/${post_id}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        title: "Edited",
+        body: "Changed",
+      },
+    });
+    expect(edit.status()).toBe(200);
+
+    const response = await request.get(`/feeds/${feed_id}.rss`, {
+      headers: {
+        "If-Modified-Since": first.headers()["last-modified"],
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    expect(await response.text()).toContain("Edited");
+  });
+
+  test("Get Feed - Unknown format", async ({ request }) => {
+    const response = await request.get(`/feeds/${feed_id}.xml`);
+    // Validate status code
+    expect(response.status()).toBe(404);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Unknown feed format");
+  });
+});
+
+test.describe("Timeline Documents", () => {
+  test("Timeline token - Create and revoke", async ({ request }) => {
+    const follow = await request.post("/v3/follow", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        feed_id: feed_id,
+      },
+    });
+    expect(follow.status()).toBe(201);
+
+    const response = await request.post("/v1/user/timeline-token", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(201);
+    const { token } = await response.json();
+    expect(token).toBeTruthy();
+
+    // The timeline is readable without credentials
+    const timeline = await request.get(`/timelines/${token}.json`);
+    expect(timeline.status()).toBe(200);
+    expect((await timeline.json()).items.map((item) => item.id)).toContain(post_id);
+
+    // A new token replaces the previous one
+    const rotate = await request.post("/v1/user/timeline-token", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(rotate.status()).toBe(201);
+    const old = await request.get(`/timelines/${token}.rss`);
+    expect(old.status()).toBe(404);
+
+    const revoke = await request.delete("/v1/user/timeline-token", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(revoke.status()).toBe(204);
+    const revoked = await request.get(`/timelines/${(await rotate.json()).token}.atom`);
+    expect(revoked.status()).toBe(404);
+  });
+
+  test("Timeline token - Forwarded host ignored", async ({ request }) => {
+    const response = await request.post("/v1/user/timeline-token", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+        "X-Forwarded-Host": "attacker.example",
+        "X-Forwarded-Proto": "https",
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(201);
+    // Validate the links point at the API
+    const json = await response.json();
+    expect(json.rss_url).not.toContain("attacker.example");
+    expect(json.rss_url).toContain(`/timelines/${json.token}.rss`);
+  });
+
+  test("Timeline - Modified by an unfollow", async ({ request }) => {
+    const follow = await request.post("/v3/follow", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        feed_id: feed_id,
+      },
+    });
+    expect(follow.status()).toBe(201);
+    const create = await request.post("/v1/user/timeline-token", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    const { token } = await create.json();
+    const first = await request.get(`/timelines/${token}.rss`);
+    expect(first.status()).toBe(200);
+    // Last-Modified has a one second resolution
+    await new Promise((resolve) => setTimeout(resolve, 1100));
+    const unfollow = await request.delete(`/v3/follow/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(unfollow.status()).toBe(204);
+
+    const response = await request.get(`/timelines/${token}.rss`, {
+      headers: {
+        "If-Modified-Since": first.headers()["last-modified"],
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    expect(await response.text()).not.toContain(post_id);
+  });
+
+  test("Timeline token - Not Authorized", async ({ request }) => {
+    const response = await request.post("/v1/user/timeline-token");
+    // Validate status code
+    expect(response.status()).toBe(401);
+  });
+});
@@ -0,0 +1,443 @@
+import { test, expect } from "@playwright/test";
+import { faker } from "@faker-js/faker";
+import { createUser, feedURL } from "./helpers";
+
+let authToken, userID, ownerToken, feed_id;
+
+test.beforeEach("Credentials - Users and Private Feed", async ({ request }) => {
+  const user = await createUser(request);
+  userID = user.id;
+  authToken = user.token;
+  ownerToken = (await createUser(request)).token;
+
+  const feedResponse = await request.post("/v2/feeds", {
+    headers: {
+      Authorization: `Bearer ${ownerToken}`,
+    },
+    data: {
+      name: faker.lorem.word(),
+      url: feedURL(),
+      visibility: "private",
+    },
+  });
+  expect(feedResponse.status()).toBe(201);
+  const feed = await feedResponse.json();
+  expect(feed).toHaveProperty("visibility", "private");
+  feed_id = feed.id;
+});
+
+test.afterEach("Remove Credentials", async ({ request }) => {
+  for (const token of [authToken, ownerToken]) {
+    const res = await request.delete("/v1/user", {
+      headers: {
+        Authorization: `Bearer ${token}`,
+      },
+    });
+    expect(res.status()).toBe(204);
+  }
+});
+
+async function createInvite(request, data) {
+  const response = await request.post(`/v2/feeds/${feed_id}/invites`, {
+    headers: {
+      Authorization: `Bearer ${ownerToken}`,
+    },
+    data: data,
+  });
+  expect(response.status()).toBe(201);
+  return (await response.json()).token;
+}
+
+async function setVisibility(request, visibility) {
+  const current = await request.get(`/v2/feeds/${feed_id}`, {
+    headers: {
+      Authorization: `Bearer ${ownerToken}`,
+    },
+  });
+  const feed = await current.json();
+  const response = await request.put(`/v2/feeds/${feed_id}`, {
+    headers: {
+      Authorization: `Bearer ${ownerToken}`,
+    },
+    data: {
+      name: feed.name,
+      url: feed.url,
+      visibility: visibility,
+    },
+  });
+  expect(response.status()).toBe(200);
+  return response.json();
+}
+
+test.describe("Private Feeds", () => {
+  test("Private feed - Hidden from others", async ({ request }) => {
+    const response = await request.get(`/v2/feeds/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(404);
+
+    const listing = await request.get("/v2/feeds?limit=100", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect((await listing.json()).map((feed) => feed.id)).not.toContain(feed_id);
+
+    const document = await request.get(`/feeds/${feed_id}.rss`);
+    expect(document.status()).toBe(404);
+
+    const follow = await request.post("/v3/follow", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        feed_id: feed_id,
+      },
+    });
+    expect(follow.status()).toBe(404);
+  });
+
+  test("Private feed - Allowed user", async ({ request }) => {
+    const response = await request.post(`/v2/feeds/${feed_id}/access`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+      data: {
+        user_id: userID,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(201);
+    // Validate response body
+    expect(await response.json()).toHaveProperty("user_id", userID);
+
+    const again = await request.post(`/v2/feeds/${feed_id}/access`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+      data: {
+        user_id: userID,
+      },
+    });
+    expect(again.status()).toBe(409);
+
+    const feed = await request.get(`/v2/feeds/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(feed.status()).toBe(200);
+    const follow = await request.post("/v3/follow", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        feed_id: feed_id,
+      },
+    });
+    expect(follow.status()).toBe(201);
+  });
+
+  test("Private feed - Access revoked", async ({ request }) => {
+    const access = await request.post(`/v2/feeds/${feed_id}/access`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+      data: {
+        user_id: userID,
+      },
+    });
+    expect(access.status()).toBe(201);
+    const follow = await request.post("/v3/follow", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        feed_id: feed_id,
+      },
+    });
+    expect(follow.status()).toBe(201);
+    const post = await request.post(`/v2/feeds/${feed_id}/posts`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+      data: {
+        title: "Members only",
+        body: "Hello",
+      },
+    });
+    expect(post.status()).toBe(201);
+    const post_id = (await post.json()).id;
+
+    const timeline = await request.get("/v4/posts", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect((await timeline.json()).map((p) => p.id)).toContain(post_id);
+
+    const response = await request.delete(`/v2/feeds/${feed_id}/access/${userID}`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(204);
+
+    const after = await request.get("/v4/posts", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect((await after.json()).map((p) => p.id)).not.toContain(post_id);
+    const feed = await request.get(`/v2/feeds/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(feed.status()).toBe(404);
+  });
+
+  test("Public feed made private - Hidden from followers", async ({ request }) => {
+    const feed = await setVisibility(request, "public");
+    const follow = await request.post("/v3/follow", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        feed_id: feed_id,
+      },
+    });
+    expect(follow.status()).toBe(201);
+    await setVisibility(request, "private");
+
+    const response = await request.get(`/v2/feeds/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(404);
+
+    const listing = await request.get("/v2/feeds?limit=100", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect((await listing.json()).map((f) => f.id)).not.toContain(feed_id);
+    const follows = await request.get("/v3/follow", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect((await follows.json()).map((f) => f.feed_id)).not.toContain(feed_id);
+    const opml = await request.get("/v3/follow/opml", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(await opml.text()).not.toContain(feed.url);
+  });
+
+  test("Private feed - Refresh job hidden from others", async ({ request }) => {
+    const refresh = await request.post(`/v2/feeds/${feed_id}/refresh`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+    });
+    expect(refresh.status()).toBe(202);
+    const job = await refresh.json();
+
+    const response = await request.get(`/v2/feeds/${feed_id}/refresh/${job.id}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(404);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Job not found");
+  });
+
+  test("Access - Forbidden", async ({ request }) => {
+    const access = await request.get(`/v2/feeds/${feed_id}/access`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(access.status()).toBe(403);
+
+    const invite = await request.post(`/v2/feeds/${feed_id}/invites`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {},
+    });
+    expect(invite.status()).toBe(403);
+  });
+
+  test("Visibility - Invalid", async ({ request }) => {
+    const response = await request.post("/v2/feeds", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        name: faker.lorem.word(),
+        url: feedURL(),
+        visibility: "secret",
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(400);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Invalid visibility");
+  });
+});
+
+test.describe("Unlisted Feeds", () => {
+  test("Unlisted feed - Reachable by ID", async ({ request }) => {
+    const current = await request.get(`/v2/feeds/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+    });
+    const feed = await current.json();
+    const response = await request.put(`/v2/feeds/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+      data: {
+        name: feed.name,
+        url: feed.url,
+        visibility: "unlisted",
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    expect(await response.json()).toHaveProperty("visibility", "unlisted");
+
+    const listing = await request.get("/v2/feeds?limit=100", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect((await listing.json()).map((f) => f.id)).not.toContain(feed_id);
+
+    const get = await request.get(`/v2/feeds/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(get.status()).toBe(200);
+    const follow = await request.post("/v3/follow", {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+      data: {
+        feed_id: feed_id,
+      },
+    });
+    expect(follow.status()).toBe(201);
+  });
+});
+
+test.describe("Feed Invites", () => {
+  test("Accept Invite", async ({ request }) => {
+    const token = await createInvite(request, { max_uses: 1 });
+
+    const response = await request.post(`/v2/invites/${token}/accept`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate response body
+    expect(await response.json()).toHaveProperty("id", feed_id);
+
+    const feed = await request.get(`/v2/feeds/${feed_id}`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    expect(feed.status()).toBe(200);
+
+    const invites = await request.get(`/v2/feeds/${feed_id}/invites`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+    });
+    const json = await invites.json();
+    expect(json[0]).toHaveProperty("uses", 1);
+    expect(json[0]).toHaveProperty("token", null);
+  });
+
+  test("Accept Invite - Used up", async ({ request }) => {
+    const token = await createInvite(request, { max_uses: 1 });
+    const other = await createUser(request);
+    const first = await request.post(`/v2/invites/${token}/accept`, {
+      headers: {
+        Authorization: `Bearer ${other.token}`,
+      },
+    });
+    expect(first.status()).toBe(200);
+
+    const response = await request.post(`/v2/invites/${token}/accept`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(404);
+    // Validate response body
+    const json = await response.json();
+    expect(json).toHaveProperty("error", "Invite not found");
+
+    await request.delete("/v1/user", {
+      headers: {
+        Authorization: `Bearer ${other.token}`,
+      },
+    });
+  });
+
+  test("Accept Invite - Feed no longer private", async ({ request }) => {
+    const token = await createInvite(request, { max_uses: 1 });
+    await setVisibility(request, "unlisted");
+
+    const response = await request.post(`/v2/invites/${token}/accept`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(200);
+    // Validate the invite wasn't used up
+    const invites = await request.get(`/v2/feeds/${feed_id}/invites`, {
+      headers: {
+        Authorization: `Bearer ${ownerToken}`,
+      },
+    });
+    expect((await invites.json())[0]).toHaveProperty("uses", 0);
+  });
+
+  test("Accept Invite - Expired", async ({ request }) => {
+    const token = await createInvite(request, {
+      expires_at: new Date(Date.now() + 1500).toISOString(),
+    });
+    await new Promise((resolve) => setTimeout(resolve, 2000));
+
+    const response = await request.post(`/v2/invites/${token}/accept`, {
+      headers: {
+        Authorization: `Bearer ${authToken}`,
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(404);
+  });
+});
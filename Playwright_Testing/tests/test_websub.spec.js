@@ -0,0 +1,177 @@
+import { test, expect } from "@playwright/test";
+import { faker } from "@faker-js/faker";
+import { feedURL, feedServer } from "./helpers";
+
+// The API has to run with WEBSUB_CALLBACK_URL set to its own address,
+// e.g. http://127.0.0.1:8080, for the stand-in hub to reach it
+let authToken, feed_id, topic;
+
+test.beforeEach("Credentials - User and Feed", async ({ request }) => {
+  const email = faker.internet.email();
+  const password = faker.internet.password();
+  const response = await request.post("/v1/user", {
+    data: {
+      email: email,
+      password: password,
+      name: faker.person.firstName(),
+    },
+  });
+  expect(response.status()).toBe(201);
+  const loginResponse = await request.post("/v1/login", {
+    form: {
+      username: email,
+      password: password,
+    },
+  });
+  expect(loginResponse.status()).toBe(200);
+  authToken = (await loginResponse.json()).token;
+
+  topic = feedURL("websub");
+  const feedResponse = await request.post("/v2/feeds", {
+    headers: {
+      Authorization: `Bearer ${authToken}`,
+    },
+    data: {
+      name: faker.lorem.word(),
+      url: topic,
+    },
+  });
+  expect(feedResponse.status()).toBe(201);
+  feed_id = (await feedResponse.json()).id;
+
+  const followResponse = await request.post("/v3/follow", {
+    headers: {
+      Authorization: `Bearer ${authToken}`,
+    },
+    data: {
+      feed_id: feed_id,
+    },
+  });
+  expect(followResponse.status()).toBe(201);
+
+  // Creating the feed subscribes to its hub, which verifies the callback
+  await expect
+    .poll(async () => {
+      const res = await request.get(`${feedServer}/hub/subscriptions`, {
+        params: { topic: topic },
+      });
+      return res.ok() && (await res.json()).verified;
+    })
+    .toBe(true);
+});
+
+test.afterEach("Remove Credentials", async ({ request }) => {
+  await request.delete(`/v2/feeds/${feed_id}`, {
+    headers: {
+      Authorization: `Bearer ${authToken}`,
+    },
+  });
+  const res = await request.delete("/v1/user", {
+    headers: {
+      Authorization: `Bearer ${authToken}`,
+    },
+  });
+  expect(res.status()).toBe(204);
+});
+
+async function postTitles(request) {
+  const res = await request.get("/v4/posts", {
+    headers: {
+      Authorization: `Bearer ${authToken}`,
+    },
+  });
+  expect(res.status()).toBe(200);
+  return (await res.json()).map((post) => post.title);
+}
+
+test.describe("WebSub", () => {
+  test("WebSub - Pushed content is saved", async ({ request }) => {
+    const title = faker.lorem.sentence();
+    const response = await request.post(`${feedServer}/hub/publish`, {
+      params: { topic: topic, title: title },
+    });
+    // Validate the callback answer
+    expect((await response.json()).status).toBe(204);
+    // Validate the pushed post is in the timeline
+    expect(await postTitles(request)).toContain(title);
+  });
+
+  test("WebSub - Invalid signature is ignored", async ({ request }) => {
+    const title = faker.lorem.sentence();
+    const response = await request.post(`${feedServer}/hub/publish`, {
+      params: { topic: topic, title: title, signature: "bad" },
+    });
+    // The hub still gets a 2xx answer
+    expect((await response.json()).status).toBe(204);
+    // Validate nothing was saved
+    expect(await postTitles(request)).not.toContain(title);
+  });
+
+  test("WebSub - Denied subscription takes no content", async ({ request }) => {
+    const subscription = await request.get(`${feedServer}/hub/subscriptions`, {
+      params: { topic: topic },
+    });
+    const callback = (await subscription.json()).callback;
+    const deny = await request.get(callback, {
+      params: {
+        "hub.mode": "denied",
+        "hub.topic": topic,
+      },
+    });
+    expect(deny.status()).toBe(200);
+
+    const title = faker.lorem.sentence();
+    const response = await request.post(`${feedServer}/hub/publish`, {
+      params: { topic: topic, title: title },
+    });
+    // Validate the callback answer
+    expect((await response.json()).status).toBe(404);
+    // Validate nothing was saved
+    expect(await postTitles(request)).not.toContain(title);
+  });
+
+  test("WebSub - Verification nobody asked for", async ({ request }) => {
+    const subscription = await request.get(`${feedServer}/hub/subscriptions`, {
+      params: { topic: topic },
+    });
+    const callback = (await subscription.json()).callback;
+    // The subscription is already verified
+    const verify = await request.get(callback, {
+      params: {
+        "hub.mode": "subscribe",
+        "hub.topic": topic,
+        "hub.challenge": "challenge",
+      },
+    });
+    expect(verify.status()).toBe(404);
+
+    const deny = await request.get(callback, {
+      params: {
+        "hub.mode": "denied",
+        "hub.topic": topic,
+      },
+    });
+    expect(deny.status()).toBe(200);
+    // Nor can a denied one be brought back
+    const again = await request.get(callback, {
+      params: {
+        "hub.mode": "subscribe",
+        "hub.topic": topic,
+        "hub.challenge": "challenge",
+      },
+    });
+    expect(again.status()).toBe(404);
+  });
+
+  test("WebSub - Verification of an unknown subscription", async ({ request }) => {
+    const response = await request.get(`/websub/${faker.string.uuid()}`, {
+      params: {
+        "hub.mode": "subscribe",
+        "hub.topic": topic,
+        "hub.challenge": "challenge",
+      },
+    });
+    // Validate status code
+    expect(response.status()).toBe(404);
+  });
+});
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+// Encoding declared in the XML prolog, e.g. <?xml version="1.0" encoding="ISO-8859-1"?>
+var prologEncoding = regexp.MustCompile(`^\s*<\?xml[^>]*?encoding\s*=\s*["']([A-Za-z0-9._:-]+)["']`)
+
+// Byte-order marks, they win over any declared encoding
+var byteOrderMarks = []struct {
+	mark  []byte
+	label string
+}{
+	{[]byte{0xEF, 0xBB, 0xBF}, "utf-8"},
+	{[]byte{0xFF, 0xFE}, "utf-16le"},
+	{[]byte{0xFE, 0xFF}, "utf-16be"},
+}
+
+// documentCharset finds the encoding of a feed: a byte-order mark first, then
+// the Content-Type charset, then the XML prolog. The BOM is cut off the data.
+func documentCharset(data []byte, contentType string) ([]byte, string) {
+	for _, bom := range byteOrderMarks {
+		if bytes.HasPrefix(data, bom.mark) {
+			return data[len(bom.mark):], bom.label
+		}
+	}
+	if _, params, err := mime.ParseMediaType(contentType); err == nil && params["charset"] != "" {
+		return data, params["charset"]
+	}
+	if match := prologEncoding.FindSubmatch(data[:min(len(data), 1024)]); match != nil {
+		return data, string(match[1])
+	}
+	return data, "utf-8"
+}
+
+// isXMLChar reports whether r may appear in an XML 1.0 document
+func isXMLChar(r rune) bool {
+	return r == '\t' || r == '\n' || r == '\r' ||
+		(r >= 0x20 && r <= 0xD7FF) ||
+		(r >= 0xE000 && r <= 0xFFFD) ||
+		(r >= 0x10000 && r <= utf8.MaxRune)
+}
+
+// toUTF8 transcodes a feed to UTF-8. Unknown encodings are read as UTF-8,
+// invalid sequences become U+FFFD and characters XML forbids are dropped.
+func toUTF8(data []byte, contentType string) []byte {
+	data, label := documentCharset(data, contentType)
+	encoding, name := charset.Lookup(strings.TrimSpace(label))
+	if encoding != nil && name != "utf-8" {
+		if decoded, err := encoding.NewDecoder().Bytes(data); err == nil {
+			data = decoded
+		}
+	}
+	data = bytes.ToValidUTF8(data, []byte(string(utf8.RuneError)))
+	return bytes.Map(func(r rune) rune {
+		if !isXMLChar(r) {
+			return -1
+		}
+		return r
+	}, data)
+}
+
+// charsetReader lets encoding/xml accept any encoding declared in the prolog.
+// Documents went through toUTF8 already, so the input is UTF-8 whatever the
+// declaration says and is returned as is.
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	return input, nil
+}
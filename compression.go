@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Responses smaller than this are sent as they are, set with COMPRESSION_MIN_SIZE
+var compressionMinSize = 1024
+
+// Encodings the middleware can answer with, in order of preference when the
+// client weighs them the same
+var responseEncodings = []string{"br", "gzip"}
+
+// Media types worth compressing, anything else (images, archives) is sent as is
+var compressibleTypes = map[string]bool{
+	"application/json":       true,
+	"application/xml":        true,
+	"application/rss+xml":    true,
+	"application/atom+xml":   true,
+	"application/feed+json":  true,
+	"application/javascript": true,
+	"image/svg+xml":          true,
+}
+
+var errFeedTooBig = errors.New("feed is bigger than the size limit")
+
+// compressionFromEnv reads COMPRESSION_MIN_SIZE
+func compressionFromEnv() error {
+	if value := os.Getenv("COMPRESSION_MIN_SIZE"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 0 {
+			return fmt.Errorf("invalid COMPRESSION_MIN_SIZE value: %v", value)
+		}
+		compressionMinSize = size
+	}
+	return nil
+}
+
+// negotiateEncoding picks the best encoding we support from Accept-Encoding,
+// "" means the response is sent uncompressed
+func negotiateEncoding(header string) string {
+	weights := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		if name == "" {
+			continue
+		}
+		weight := 1.0
+		for _, param := range fields[1:] {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.TrimSpace(key) == "q" {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					weight = q
+				}
+			}
+		}
+		weights[name] = weight
+	}
+	best, bestWeight := "", 0.0
+	for _, encoding := range responseEncodings {
+		weight, ok := weights[encoding]
+		if !ok {
+			weight, ok = weights["*"]
+		}
+		if ok && weight > bestWeight {
+			best, bestWeight = encoding, weight
+		}
+	}
+	return best
+}
+
+func isCompressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") || compressibleTypes[mediaType]
+}
+
+// compressWriter holds the response back until it knows whether it reaches
+// compressionMinSize, then either compresses it or writes it as is
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	status   int
+	buffer   bytes.Buffer
+	encoder  io.WriteCloser
+	decided  bool
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.status == 0 {
+		cw.status = status
+	}
+}
+
+func (cw *compressWriter) Write(data []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if cw.decided {
+		if cw.encoder != nil {
+			return cw.encoder.Write(data)
+		}
+		return cw.ResponseWriter.Write(data)
+	}
+	cw.buffer.Write(data)
+	if cw.buffer.Len() >= compressionMinSize {
+		err := cw.decide(true)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// decide sends the headers, compressing when the body is big enough and its
+// type is worth it, then writes out what was buffered. A 204 or 304 has no
+// body, whatever the handler wrote is dropped as net/http would
+func (cw *compressWriter) decide(bigEnough bool) error {
+	cw.decided = true
+	if cw.status == http.StatusNoContent || cw.status == http.StatusNotModified || cw.buffer.Len() == 0 {
+		cw.ResponseWriter.WriteHeader(cw.status)
+		cw.buffer.Reset()
+		return nil
+	}
+	header := cw.Header()
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", http.DetectContentType(cw.buffer.Bytes()))
+	}
+	compress := bigEnough && header.Get("Content-Encoding") == "" &&
+		isCompressible(header.Get("Content-Type"))
+	if compress {
+		header.Set("Content-Encoding", cw.encoding)
+		header.Del("Content-Length")
+		switch cw.encoding {
+		case "br":
+			cw.encoder = brotli.NewWriterLevel(cw.ResponseWriter, brotli.DefaultCompression)
+		case "gzip":
+			cw.encoder = gzip.NewWriter(cw.ResponseWriter)
+		}
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+	var err error
+	if cw.encoder != nil {
+		_, err = cw.encoder.Write(cw.buffer.Bytes())
+	} else {
+		_, err = cw.ResponseWriter.Write(cw.buffer.Bytes())
+	}
+	cw.buffer.Reset()
+	return err
+}
+
+// close flushes a small response or ends the compressed stream
+func (cw *compressWriter) close() error {
+	if !cw.decided {
+		if cw.status == 0 {
+			// Nothing was written, net/http sends its default response
+			return nil
+		}
+		return cw.decide(false)
+	}
+	if cw.encoder != nil {
+		return cw.encoder.Close()
+	}
+	return nil
+}
+
+// middlewareCompress compresses responses with the encoding negotiated from
+// Accept-Encoding, gzip or br, when they are at least compressionMinSize bytes
+func middlewareCompress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding}
+		next.ServeHTTP(cw, r)
+		cw.close()
+	})
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.reader.Read(p)
+	cr.count += int64(n)
+	return n, err
+}
+
+// readBody reads a response sent with Accept-Encoding: gzip, deflate and
+// decodes it, returning the bytes received on the wire along with the body.
+// Bodies decoding to more than limit bytes are refused.
+func readBody(resp *http.Response, limit int64) ([]byte, int64, error) {
+	wire := &countingReader{reader: resp.Body}
+	var body io.Reader = wire
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+	case "gzip", "x-gzip":
+		reader, err := gzip.NewReader(wire)
+		if err != nil {
+			return nil, wire.count, err
+		}
+		defer reader.Close()
+		body = reader
+	case "deflate":
+		// deflate is meant to be zlib wrapped, some servers send raw deflate
+		buffered := bufio.NewReader(wire)
+		head, _ := buffered.Peek(2)
+		if len(head) == 2 && head[0]&0x0f == 8 && (uint16(head[0])<<8|uint16(head[1]))%31 == 0 {
+			reader, err := zlib.NewReader(buffered)
+			if err != nil {
+				return nil, wire.count, err
+			}
+			defer reader.Close()
+			body = reader
+		} else {
+			reader := flate.NewReader(buffered)
+			defer reader.Close()
+			body = reader
+		}
+	default:
+		return nil, 0, fmt.Errorf("unsupported content encoding %v", resp.Header.Get("Content-Encoding"))
+	}
+	data, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, wire.count, err
+	}
+	if int64(len(data)) > limit {
+		return nil, wire.count, errFeedTooBig
+	}
+	return data, wire.count, nil
+}
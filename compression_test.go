@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddlewareCompressHeaders(t *testing.T) {
+	big := strings.Repeat(`{"name":"feed"}`, compressionMinSize)
+	tests := []struct {
+		name         string
+		status       int
+		body         string
+		wantType     string
+		wantEncoding string
+	}{
+		{"empty body", 200, "", "", ""},
+		{"no content", 204, `{"status":"No Content"}`, "", ""},
+		{"not modified", 304, "", "", ""},
+		{"small body", 200, `{"a":1}`, "text/plain; charset=utf-8", ""},
+		{"big body", 200, big, "text/plain; charset=utf-8", "gzip"},
+		{"big no content", 204, big, "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := middlewareCompress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.status)
+				w.Write([]byte(test.body))
+			}))
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header.Set("Accept-Encoding", "gzip")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != test.status {
+				t.Errorf("status %v, want %v", w.Code, test.status)
+			}
+			if got := w.Header().Get("Content-Type"); got != test.wantType {
+				t.Errorf("Content-Type %q, want %q", got, test.wantType)
+			}
+			if got := w.Header().Get("Content-Encoding"); got != test.wantEncoding {
+				t.Errorf("Content-Encoding %q, want %q", got, test.wantEncoding)
+			}
+			if (test.status == 204 || test.status == 304) && w.Body.Len() != 0 {
+				t.Errorf("%v bytes of body on a %v", w.Body.Len(), test.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"project_1/internal/database"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// testConn opens TEST_DB_URL, a migrated database. Tests needing it are
+// skipped when it isn't set
+func testConn(t *testing.T) *sql.DB {
+	t.Helper()
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL is not set")
+	}
+	conn, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// testTx runs the test in a transaction of TEST_DB_URL that is rolled back
+func testTx(t *testing.T) (*sql.Tx, *database.Queries) {
+	t.Helper()
+	conn := testConn(t)
+	tx, err := conn.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+	return tx, database.New(conn).WithTx(tx)
+}
+
+// createTestFeed creates a feed and the user owning it
+func createTestFeed(t *testing.T, db *database.Queries, name string) database.Feed {
+	t.Helper()
+	ctx := context.Background()
+	user, err := db.CreateUser(ctx, database.CreateUserParams{
+		ID:       uuid.New(),
+		Name:     name,
+		Email:    uuid.NewString() + "@example.com",
+		Password: "password",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed, err := db.CreateFeed(ctx, database.CreateFeedParams{
+		ID:         uuid.New(),
+		Name:       name,
+		Url:        "https://example.com/" + uuid.NewString(),
+		UserID:     user.ID,
+		Visibility: feedPublic,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return feed
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"project_1/internal/database"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Deleted users, feeds and follows can be restored for this long, set with
+// DELETION_GRACE_PERIOD
+var deletionGracePeriod = 30 * 24 * time.Hour
+
+const deletionPurgeInterval = time.Hour
+
+// deletionGracePeriodFromEnv reads DELETION_GRACE_PERIOD
+func deletionGracePeriodFromEnv() error {
+	if value := os.Getenv("DELETION_GRACE_PERIOD"); value != "" {
+		grace, err := time.ParseDuration(value)
+		if err != nil || grace < time.Hour {
+			return fmt.Errorf("invalid DELETION_GRACE_PERIOD value: %v", value)
+		}
+		deletionGracePeriod = grace
+	}
+	return nil
+}
+
+// graceSeconds is the grace period as the queries take it
+func graceSeconds() int32 {
+	return int32(deletionGracePeriod / time.Second)
+}
+
+// startDeletionPurger deletes for good what was deleted before the grace period
+func startDeletionPurger(conn *sql.DB) {
+	db := database.New(conn)
+	ticker := time.NewTicker(deletionPurgeInterval)
+	for ; ; <-ticker.C {
+		ctx := context.Background()
+		userIDs, err := db.GetPurgeableUsers(ctx, graceSeconds())
+		if err != nil {
+			log.Printf("Error purging users: %v", err)
+			continue
+		}
+		for _, userID := range userIDs {
+			err = purgeUser(ctx, conn, userID)
+			if err != nil {
+				log.Printf("Error purging user %v: %v", userID, err)
+			}
+		}
+		feeds, err := db.PurgeDeletedFeeds(ctx, graceSeconds())
+		if err != nil {
+			log.Printf("Error purging feeds: %v", err)
+		}
+		follows, err := db.PurgeDeletedFollows(ctx, graceSeconds())
+		if err != nil {
+			log.Printf("Error purging follows: %v", err)
+		}
+		if len(userIDs) > 0 || feeds > 0 || follows > 0 {
+			log.Printf("Purged %v users, %v feeds and %v follows", len(userIDs), feeds, follows)
+		}
+	}
+}
+
+// purgeUser deletes a user for good along with their deleted feeds. The shared
+// ones were handed over when the user was deleted, but feeds handed to the user
+// since, while they were deleted, are handed over again to someone live first
+func purgeUser(ctx context.Context, conn *sql.DB, userID uuid.UUID) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := database.New(conn).WithTx(tx)
+
+	err = qtx.ReassignFeedsToMaintainers(ctx, userID)
+	if err != nil {
+		return err
+	}
+	err = qtx.OrphanSharedFeeds(ctx, database.OrphanSharedFeedsParams{
+		SystemUserID: systemUserID,
+		UserID:       userID,
+	})
+	if err != nil {
+		return err
+	}
+	err = qtx.DeleteUserFeeds(ctx, userID)
+	if err != nil {
+		return err
+	}
+	err = qtx.DeleteUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Maximum time spent validating a feed while the user waits on the request
+const feedDiscoveryTimeout = 10 * time.Second
+
+// Documents bigger than this are not read while validating
+const maxFeedSize = 10 << 20
+
+var errNotAFeed = errors.New("document is not an RSS or Atom feed")
+
+// Media types advertised by <link rel="alternate"> for feeds
+var feedLinkTypes = map[string]bool{
+	"application/rss+xml":  true,
+	"application/atom+xml": true,
+}
+
+// fetchDocument downloads rawURL and returns the body together with the final
+// URL after redirects and the response Content-Type
+func fetchDocument(ctx context.Context, rawURL string) ([]byte, *url.URL, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, text/html;q=0.8, */*;q=0.5")
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	httpClient := http.Client{
+		Transport: outboundTransport,
+		Timeout:   feedDiscoveryTimeout,
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, "", fmt.Errorf("unexpected status %v", resp.Status)
+	}
+	data, _, err := readBody(resp, maxFeedSize)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return data, resp.Request.URL, resp.Header.Get("Content-Type"), nil
+}
+
+// looksLikeHTML reports whether a document should be searched for feed links
+// instead of being parsed as a feed
+func looksLikeHTML(contentType string, data []byte) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "text/html" || mediaType == "application/xhtml+xml" {
+		return true
+	}
+	head := bytes.ToLower(bytes.TrimSpace(data[:min(len(data), 512)]))
+	return bytes.HasPrefix(head, []byte("<!doctype html")) || bytes.HasPrefix(head, []byte("<html"))
+}
+
+// feedLinks returns the feed URLs advertised by an HTML page through
+// <link rel="alternate" type="application/rss+xml|atom+xml">, resolved
+// against the page URL
+func feedLinks(page *url.URL, data []byte) []string {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	links := []string{}
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "link" {
+			var rel, linkType, href string
+			for _, attr := range n.Attr {
+				switch strings.ToLower(attr.Key) {
+				case "rel":
+					rel = strings.ToLower(attr.Val)
+				case "type":
+					linkType = strings.ToLower(strings.TrimSpace(attr.Val))
+				case "href":
+					href = strings.TrimSpace(attr.Val)
+				}
+			}
+			if href != "" && feedLinkTypes[linkType] && containsField(rel, "alternate") {
+				if ref, err := page.Parse(href); err == nil {
+					links = append(links, ref.String())
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+	return links
+}
+
+func containsField(list string, value string) bool {
+	for _, field := range strings.Fields(list) {
+		if field == value {
+			return true
+		}
+	}
+	return false
+}
+
+// discoverFeed fetches rawURL and makes sure it points at a feed. HTML pages are
+// followed through their autodiscovery links. It returns the canonical feed
+// URL and the parsed feed.
+func discoverFeed(ctx context.Context, rawURL string) (string, RSSFeed, error) {
+	ctx, cancel := context.WithTimeout(ctx, feedDiscoveryTimeout)
+	defer cancel()
+
+	data, finalURL, contentType, err := fetchDocument(ctx, rawURL)
+	if err != nil {
+		return "", RSSFeed{}, err
+	}
+	if !looksLikeHTML(contentType, data) {
+		rssFeed, err := parseFeed(data, contentType)
+		if errors.Is(err, errNotAFeed) {
+			return "", RSSFeed{}, err
+		}
+		if err != nil {
+			return "", RSSFeed{}, fmt.Errorf("%w: %v", errNotAFeed, err)
+		}
+		return finalURL.String(), rssFeed, nil
+	}
+
+	for _, link := range feedLinks(finalURL, data) {
+		data, feedURL, contentType, err := fetchDocument(ctx, link)
+		// A page pointing at an internal address is refused, not skipped
+		if errors.Is(err, errPrivateAddress) {
+			return "", RSSFeed{}, err
+		}
+		if err != nil {
+			continue
+		}
+		rssFeed, err := parseFeed(data, contentType)
+		if err != nil {
+			continue
+		}
+		return feedURL.String(), rssFeed, nil
+	}
+	return "", RSSFeed{}, errNotAFeed
+}
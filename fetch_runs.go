@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"project_1/internal/database"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// What started a fetch, stored in feed_fetch_runs.source
+const (
+	fetchScheduled = "scheduled"
+	fetchRefresh   = "refresh"
+	fetchPush      = "push"
+)
+
+// Fetch history older than this is deleted, set with FETCH_HISTORY_RETENTION
+var fetchHistoryRetention = 30 * 24 * time.Hour
+
+const fetchHistoryPruneInterval = time.Hour
+
+// Wraps the errors of the download itself, as opposed to saving the result
+var errFetchFailed = errors.New("fetch failed")
+
+// fetchHistoryRetentionFromEnv reads FETCH_HISTORY_RETENTION
+func fetchHistoryRetentionFromEnv() error {
+	if value := os.Getenv("FETCH_HISTORY_RETENTION"); value != "" {
+		retention, err := time.ParseDuration(value)
+		if err != nil || retention < time.Hour {
+			return fmt.Errorf("invalid FETCH_HISTORY_RETENTION value: %v", value)
+		}
+		fetchHistoryRetention = retention
+	}
+	return nil
+}
+
+// fetchFeed downloads a feed, saves it and records the run in the fetch
+// history. Download errors wrap errFetchFailed.
+func fetchFeed(ctx context.Context, conn *sql.DB, feed database.Feed, source string) (ingestResult, error) {
+	startedAt := time.Now()
+	result := ingestResult{}
+	rssFeed, stats, err := urlToFeed(ctx, feed.Url)
+	if err != nil {
+		err = fmt.Errorf("%w: %w", errFetchFailed, err)
+	} else {
+		result, err = ingestFeed(ctx, conn, feed, rssFeed)
+		if err != nil {
+			// Nothing was saved
+			result = ingestResult{ItemsSeen: len(rssFeed.Channel.Item)}
+		}
+	}
+	recordFetchRun(database.New(conn), feed.ID, source, startedAt, stats, result, err)
+	if err != nil {
+		return result, err
+	}
+	subscribeToHub(ctx, database.New(conn), feed, rssFeed)
+	return result, nil
+}
+
+// recordFetchRun saves one fetch in the history, failures are only logged
+func recordFetchRun(db *database.Queries, feedID uuid.UUID, source string, startedAt time.Time, stats fetchStats, result ingestResult, fetchErr error) {
+	run := database.CreateFeedFetchRunParams{
+		ID:              uuid.New(),
+		FeedID:          feedID,
+		Source:          source,
+		DurationSeconds: time.Since(startedAt).Seconds(),
+		HttpStatus:      sql.NullInt32{Int32: int32(stats.StatusCode), Valid: stats.StatusCode != 0},
+		Bytes:           stats.Bytes,
+		CompressedBytes: stats.CompressedBytes,
+		ItemsSeen:       int32(result.ItemsSeen),
+		NewPosts:        int32(result.NewPosts),
+		UpdatedPosts:    int32(result.UpdatedPosts),
+	}
+	if fetchErr != nil {
+		run.Error = sql.NullString{String: fetchErr.Error(), Valid: true}
+	}
+	// Recorded even when the fetch ran out of time
+	err := db.CreateFeedFetchRun(context.Background(), run)
+	if err != nil {
+		log.Printf("Error recording fetch of feed %v: %v", feedID, err)
+	}
+}
+
+// startFetchHistoryPruner deletes the fetch history past its retention
+func startFetchHistoryPruner(conn *sql.DB) {
+	db := database.New(conn)
+	ticker := time.NewTicker(fetchHistoryPruneInterval)
+	for ; ; <-ticker.C {
+		deleted, err := db.DeleteOldFeedFetchRuns(context.Background(), int32(fetchHistoryRetention/time.Second))
+		if err != nil {
+			log.Printf("Error pruning fetch history: %v", err)
+			continue
+		}
+		if deleted > 0 {
+			log.Printf("Pruned %v fetch runs", deleted)
+		}
+	}
+}
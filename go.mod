@@ -12,11 +12,15 @@ require (
 require github.com/lib/pq v1.10.9
 
 require (
+	github.com/andybalholm/brotli v1.1.0
 	github.com/badoux/checkmail v1.2.4
 	github.com/golang-jwt/jwt/v5 v5.2.1
 	github.com/swaggo/http-swagger v1.3.4
 	github.com/swaggo/swag v1.16.4
+	github.com/yuin/goldmark v1.7.8
 	golang.org/x/crypto v0.36.0
+	golang.org/x/net v0.25.0
+	golang.org/x/text v0.23.0
 )
 
 require (
@@ -28,8 +32,7 @@ require (
 	github.com/josharian/intern v1.0.0 // indirect
 	github.com/mailru/easyjson v0.7.6 // indirect
 	github.com/swaggo/files v1.0.1 // indirect
-	golang.org/x/net v0.23.0 // indirect
 	golang.org/x/sys v0.31.0 // indirect
-	golang.org/x/tools v0.7.0 // indirect
+	golang.org/x/tools v0.21.1-0.20240508182429-e35e4ccd0d2d // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
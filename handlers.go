@@ -3,6 +3,8 @@ package main
 import (
 	"net/http"
 	"project_1/internal/database"
+
+	"github.com/google/uuid"
 )
 
 // handlerGetPosts retrieves posts for the authenticated user
@@ -10,19 +12,90 @@ import (
 // @Description  Retrieve a list of posts belonging to the authenticated user
 // @Tags         posts
 // @Produce      json
+// @Param        format     query     string  false  "html (default) or text"
+// @Param        folder_id  query     string  false  "Only the feeds of this folder"
+// @Param        scope      query     string  false  "following (default) or network, which adds the feeds owned by the users followed"
 // @Success      200  {array}   map[string]interface{} "List of posts"
+// @Failure      400  {object}  map[string]interface{} "Invalid format or scope"
+// @Failure      404  {object}  map[string]interface{} "Folder not found"
 // @Failure      500  {object}  map[string]interface{} "Internal Server Error"
 // @Router       /v1/posts [get]
 // @Security     BearerAuth
 func (apiCfg *apiConfig) handlerGetPosts(w http.ResponseWriter, r *http.Request, user database.User) {
+	format, ok := postFormat(r)
+	if !ok {
+		responseWithError(w, http.StatusBadRequest, "Invalid format")
+		return
+	}
+
+	scope := r.URL.Query().Get("scope")
+	if scope != "" && scope != "following" && scope != "network" {
+		responseWithError(w, http.StatusBadRequest, "Invalid scope")
+		return
+	}
+	// Folders only hold the user's own follows
+	if scope == "network" && r.URL.Query().Get("folder_id") != "" {
+		responseWithError(w, http.StatusBadRequest, "Folders can't be read with the network scope")
+		return
+	}
+
+	var folderID uuid.NullUUID
+	if folder := r.URL.Query().Get("folder_id"); folder != "" {
+		id, err := uuid.Parse(folder)
+		if err != nil {
+			responseWithError(w, http.StatusBadRequest, "Invalid folder id")
+			return
+		}
+		folderID, ok = apiCfg.userFolderID(r, user, &id)
+		if !ok {
+			responseWithError(w, http.StatusNotFound, "Folder not found")
+			return
+		}
+	}
 
-	posts, err := apiCfg.DB.GetPosts(r.Context(), database.GetPostsParams{
-		UserID: user.ID,
-		Limit:  10,
-	})
+	var posts []database.Post
+	var err error
+	switch {
+	case folderID.Valid:
+		posts, err = apiCfg.DB.GetFolderPosts(r.Context(), database.GetFolderPostsParams{
+			UserID:   user.ID,
+			FolderID: folderID,
+			Limit:    10,
+		})
+	case scope == "network":
+		posts, err = apiCfg.DB.GetNetworkPosts(r.Context(), database.GetNetworkPostsParams{
+			UserID: user.ID,
+			Limit:  10,
+		})
+	default:
+		posts, err = apiCfg.DB.GetPosts(r.Context(), database.GetPostsParams{
+			UserID: user.ID,
+			Limit:  10,
+		})
+	}
+	if err != nil {
+		responseWithError(w, 500, "Can't get posts")
+		return
+	}
+	result, err := apiCfg.postsWithEnclosures(r.Context(), posts)
 	if err != nil {
 		responseWithError(w, 500, "Can't get posts")
 		return
 	}
-	responseWithJSON(w, 200, databasePoststoPosts(posts))
+	if format == "text" {
+		result = postsAsText(result)
+	}
+	responseWithJSON(w, 200, result)
+}
+
+// postFormat reads the format query parameter of the posts endpoints
+func postFormat(r *http.Request) (string, bool) {
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "", "html":
+		return "html", true
+	case "text":
+		return "text", true
+	}
+	return "", false
 }
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"project_1/internal/database"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// Feed visibilities
+const (
+	feedPublic   = "public"
+	feedUnlisted = "unlisted"
+	feedPrivate  = "private"
+)
+
+var feedVisibilities = map[string]bool{
+	feedPublic:   true,
+	feedUnlisted: true,
+	feedPrivate:  true,
+}
+
+// feedVisibility returns the visibility asked for, or current when none is
+func feedVisibility(visibility *string, current string) (string, bool) {
+	if visibility == nil {
+		return current, true
+	}
+	return *visibility, feedVisibilities[*visibility]
+}
+
+// canReadFeed reports whether the user may see the feed and its posts,
+// private feeds answer 404 to everybody else
+func (apiCfg *apiConfig) canReadFeed(ctx context.Context, feed database.Feed, user database.User) bool {
+	if feed.Visibility != feedPrivate {
+		return true
+	}
+	readable, err := apiCfg.DB.CanReadFeed(ctx, database.CanReadFeedParams{
+		FeedID: feed.ID,
+		UserID: user.ID,
+	})
+	return err == nil && readable
+}
+
+// handlerGetFeedAccess lists the users given access to a feed
+// @Summary      Get feed access
+// @Description  List the users allowed to read a private feed besides its owner and maintainers. Only the owner and the maintainers can see them.
+// @Tags         sharing
+// @Produce      json
+// @Param        feed_id  path      string  true  "Feed ID"
+// @Success      200      {array}   FeedAccess
+// @Failure      400      {object}  map[string]string  "Invalid feed id"
+// @Failure      403      {object}  map[string]string  "Forbidden"
+// @Failure      404      {object}  map[string]string  "Feed don't exsist"
+// @Router       /v2/feeds/{feed_id}/access [get]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerGetFeedAccess(w http.ResponseWriter, r *http.Request, user database.User) {
+	feed, ok := apiCfg.managedFeed(w, r, user)
+	if !ok {
+		return
+	}
+	rows, err := apiCfg.DB.GetFeedAccess(r.Context(), feed.ID)
+	if err != nil {
+		responseWithError(w, 500, "Can't get access")
+		return
+	}
+	responseWithJSON(w, 200, databaseFeedAccesstoFeedAccess(rows))
+}
+
+// handlerAddFeedAccess allows a user to read a private feed
+// @Summary      Add feed access
+// @Description  Allow a user to read and follow a private feed. The owner and the maintainers can share a feed.
+// @Tags         sharing
+// @Accept       json
+// @Produce      json
+// @Param        feed_id  path      string             true  "Feed ID"
+// @Param        access   body      map[string]string  true  "User ID of the user to allow"
+// @Success      201      {object}  FeedAccess
+// @Failure      400      {object}  map[string]string  "Bad request error"
+// @Failure      403      {object}  map[string]string  "Forbidden"
+// @Failure      404      {object}  map[string]string  "User not found"
+// @Failure      409      {object}  map[string]string  "User already has access"
+// @Router       /v2/feeds/{feed_id}/access [post]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerAddFeedAccess(w http.ResponseWriter, r *http.Request, user database.User) {
+	type params struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	var p params
+	err := decoder.Decode(&p)
+	if err != nil {
+		responseWithError(w, 400, "Invalid request payload")
+		return
+	}
+	feed, ok := apiCfg.managedFeed(w, r, user)
+	if !ok {
+		return
+	}
+	allowed, ok := apiCfg.otherUser(r.Context(), p.UserID)
+	if !ok {
+		responseWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	access, err := apiCfg.DB.AddFeedAccess(r.Context(), database.AddFeedAccessParams{
+		FeedID: feed.ID,
+		UserID: allowed.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		responseWithError(w, http.StatusConflict, "User already has access")
+		return
+	}
+	if err != nil {
+		responseWithError(w, 500, "Can't add access")
+		return
+	}
+	responseWithJSON(w, http.StatusCreated, FeedAccess{
+		FeedID:    access.FeedID,
+		UserID:    access.UserID,
+		UserName:  allowed.Name,
+		CreatedAt: access.CreatedAt,
+	})
+}
+
+// handlerRemoveFeedAccess takes a user off the allowlist of a feed
+// @Summary      Remove feed access
+// @Description  Take a user off the allowlist of a feed. While the feed is private the user stops following it.
+// @Tags         sharing
+// @Produce      json
+// @Param        feed_id  path      string  true  "Feed ID"
+// @Param        user_id  path      string  true  "User ID"
+// @Success      204      {object}  map[string]string  "status": "No Content"
+// @Failure      400      {object}  map[string]string  "Bad request error"
+// @Failure      403      {object}  map[string]string  "Forbidden"
+// @Failure      404      {object}  map[string]string  "User has no access"
+// @Router       /v2/feeds/{feed_id}/access/{user_id} [delete]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerRemoveFeedAccess(w http.ResponseWriter, r *http.Request, user database.User) {
+	userID, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid user id")
+		return
+	}
+	feed, ok := apiCfg.managedFeed(w, r, user)
+	if !ok {
+		return
+	}
+
+	tx, err := apiCfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		responseWithError(w, 500, "Can't remove access")
+		return
+	}
+	defer tx.Rollback()
+	qtx := apiCfg.DB.WithTx(tx)
+
+	removed, err := qtx.RemoveFeedAccess(r.Context(), database.RemoveFeedAccessParams{
+		FeedID: feed.ID,
+		UserID: userID,
+	})
+	if err != nil {
+		responseWithError(w, 500, "Can't remove access")
+		return
+	}
+	if removed == 0 {
+		responseWithError(w, http.StatusNotFound, "User has no access")
+		return
+	}
+	if feed.Visibility == feedPrivate {
+		err = qtx.Unfollow(r.Context(), database.UnfollowParams{
+			UserID: userID,
+			FeedID: feed.ID,
+		})
+		if err != nil {
+			responseWithError(w, 500, "Can't remove access")
+			return
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		responseWithError(w, 500, "Can't remove access")
+		return
+	}
+	responseWithJSON(w, 204, map[string]string{"status": "No Content"})
+}
+
+// handlerCreateFeedInvite creates an invite link to a feed
+// @Summary      Create feed invite
+// @Description  Create an invite adding whoever accepts it to the allowlist of a feed. The token is only shown in this response. expires_at and max_uses are optional.
+// @Tags         sharing
+// @Accept       json
+// @Produce      json
+// @Param        feed_id  path      string           true  "Feed ID"
+// @Param        invite   body      FeedInviteInput  true  "Invite limits"
+// @Success      201      {object}  FeedInvite
+// @Failure      400      {object}  map[string]string  "Bad request error"
+// @Failure      403      {object}  map[string]string  "Forbidden"
+// @Failure      404      {object}  map[string]string  "Feed don't exsist"
+// @Router       /v2/feeds/{feed_id}/invites [post]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerCreateFeedInvite(w http.ResponseWriter, r *http.Request, user database.User) {
+	decoder := json.NewDecoder(r.Body)
+	var p FeedInviteInput
+	err := decoder.Decode(&p)
+	if err != nil {
+		responseWithError(w, 400, "Invalid request payload")
+		return
+	}
+	feed, ok := apiCfg.managedFeed(w, r, user)
+	if !ok {
+		return
+	}
+	var expiresAt sql.NullTime
+	if p.ExpiresAt != nil {
+		if !p.ExpiresAt.After(time.Now()) {
+			responseWithError(w, 400, "Invite must expire in the future")
+			return
+		}
+		expiresAt = sql.NullTime{Time: p.ExpiresAt.UTC(), Valid: true}
+	}
+	var maxUses sql.NullInt32
+	if p.MaxUses != nil {
+		if *p.MaxUses < 1 {
+			responseWithError(w, 400, "Max uses must be at least 1")
+			return
+		}
+		maxUses = sql.NullInt32{Int32: *p.MaxUses, Valid: true}
+	}
+
+	token, err := newSecretToken()
+	if err != nil {
+		responseWithError(w, 500, "Can't create invite")
+		return
+	}
+	invite, err := apiCfg.DB.CreateFeedInvite(r.Context(), database.CreateFeedInviteParams{
+		ID:        uuid.New(),
+		FeedID:    feed.ID,
+		CreatedBy: uuid.NullUUID{UUID: user.ID, Valid: true},
+		TokenHash: hashSecretToken(token),
+		ExpiresAt: expiresAt,
+		MaxUses:   maxUses,
+	})
+	if err != nil {
+		responseWithError(w, 500, "Can't create invite")
+		return
+	}
+	result := databaseFeedInvitetoFeedInvite(invite)
+	result.Token = &token
+	responseWithJSON(w, http.StatusCreated, result)
+}
+
+// handlerGetFeedInvites lists the invites to a feed
+// @Summary      Get feed invites
+// @Description  List the invites to a feed, newest first, without their tokens. Only the owner and the maintainers can see them.
+// @Tags         sharing
+// @Produce      json
+// @Param        feed_id  path      string  true  "Feed ID"
+// @Success      200      {array}   FeedInvite
+// @Failure      400      {object}  map[string]string  "Invalid feed id"
+// @Failure      403      {object}  map[string]string  "Forbidden"
+// @Failure      404      {object}  map[string]string  "Feed don't exsist"
+// @Router       /v2/feeds/{feed_id}/invites [get]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerGetFeedInvites(w http.ResponseWriter, r *http.Request, user database.User) {
+	feed, ok := apiCfg.managedFeed(w, r, user)
+	if !ok {
+		return
+	}
+	invites, err := apiCfg.DB.GetFeedInvites(r.Context(), feed.ID)
+	if err != nil {
+		responseWithError(w, 500, "Can't get invites")
+		return
+	}
+	responseWithJSON(w, 200, databaseFeedInvitestoFeedInvites(invites))
+}
+
+// handlerDeleteFeedInvite revokes an invite to a feed
+// @Summary      Delete feed invite
+// @Description  Revoke an invite, users who already accepted it keep their access
+// @Tags         sharing
+// @Produce      json
+// @Param        feed_id    path      string  true  "Feed ID"
+// @Param        invite_id  path      string  true  "Invite ID"
+// @Success      204        {object}  map[string]string  "status": "No Content"
+// @Failure      400        {object}  map[string]string  "Bad request error"
+// @Failure      403        {object}  map[string]string  "Forbidden"
+// @Failure      404        {object}  map[string]string  "Invite not found"
+// @Router       /v2/feeds/{feed_id}/invites/{invite_id} [delete]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerDeleteFeedInvite(w http.ResponseWriter, r *http.Request, user database.User) {
+	inviteID, err := uuid.Parse(chi.URLParam(r, "invite_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid invite id")
+		return
+	}
+	feed, ok := apiCfg.managedFeed(w, r, user)
+	if !ok {
+		return
+	}
+	removed, err := apiCfg.DB.DeleteFeedInvite(r.Context(), database.DeleteFeedInviteParams{
+		ID:     inviteID,
+		FeedID: feed.ID,
+	})
+	if err != nil {
+		responseWithError(w, 500, "Can't delete invite")
+		return
+	}
+	if removed == 0 {
+		responseWithError(w, http.StatusNotFound, "Invite not found")
+		return
+	}
+	responseWithJSON(w, 204, map[string]string{"status": "No Content"})
+}
+
+// handlerAcceptFeedInvite adds the user to the allowlist of the invite's feed
+// @Summary      Accept feed invite
+// @Description  Use an invite token to get access to its feed, which can then be followed. Invites of feeds that aren't private, and users who can already read the feed, don't use up the invite.
+// @Tags         sharing
+// @Produce      json
+// @Param        token  path      string  true  "Invite token"
+// @Success      200    {object}  Feed
+// @Failure      404    {object}  map[string]string  "Invite not found"
+// @Router       /v2/invites/{token}/accept [post]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerAcceptFeedInvite(w http.ResponseWriter, r *http.Request, user database.User) {
+	tx, err := apiCfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		responseWithError(w, 500, "Can't accept invite")
+		return
+	}
+	defer tx.Rollback()
+	qtx := apiCfg.DB.WithTx(tx)
+
+	invite, err := qtx.UseFeedInvite(r.Context(), hashSecretToken(chi.URLParam(r, "token")))
+	if errors.Is(err, sql.ErrNoRows) {
+		// Unknown, expired and used up invites look the same
+		responseWithError(w, http.StatusNotFound, "Invite not found")
+		return
+	}
+	if err != nil {
+		responseWithError(w, 500, "Can't accept invite")
+		return
+	}
+	feed, err := qtx.GetFeed(r.Context(), invite.FeedID)
+	if err != nil {
+		responseWithError(w, 500, "Can't accept invite")
+		return
+	}
+	readable, err := qtx.CanReadFeed(r.Context(), database.CanReadFeedParams{
+		FeedID: feed.ID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		responseWithError(w, 500, "Can't accept invite")
+		return
+	}
+	if readable {
+		// Public and unlisted feeds need no invite, nor do users already
+		// allowed. The use counted above is rolled back
+		responseWithJSON(w, 200, databaseFeedtoFeed(feed))
+		return
+	}
+	_, err = qtx.AddFeedAccess(r.Context(), database.AddFeedAccessParams{
+		FeedID: feed.ID,
+		UserID: user.ID,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		responseWithError(w, 500, "Can't accept invite")
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		responseWithError(w, 500, "Can't accept invite")
+		return
+	}
+	responseWithJSON(w, 200, databaseFeedtoFeed(feed))
+}
@@ -1,10 +1,17 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"project_1/internal/database"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/google/uuid"
@@ -12,7 +19,7 @@ import (
 
 // handlerCreateFeed creates a new feed
 // @Summary      Create feed
-// @Description  Add a new RSS feed for the authenticated user
+// @Description  Add a new RSS or Atom feed for the authenticated user. The URL is fetched and HTML pages are followed through their feed autodiscovery links.
 // @Tags         feeds
 // @Accept       json
 // @Produce      json
@@ -36,43 +43,229 @@ func (apiCfg *apiConfig) handlerCreateFeed(w http.ResponseWriter, r *http.Reques
 		responseWithError(w, http.StatusBadRequest, "Invalid URL")
 		return
 	}
+	visibility, ok := feedVisibility(p.Visibility, feedPublic)
+	if !ok {
+		responseWithError(w, http.StatusBadRequest, "Invalid visibility")
+		return
+	}
+
+	// Fetch the URL to make sure it really is a feed
+	feedURL, rssFeed, err := discoverFeed(r.Context(), p.URL)
+	if err != nil {
+		responseWithFeedError(w, err)
+		return
+	}
 
 	feed, err := apiCfg.DB.CreateFeed(r.Context(), database.CreateFeedParams{
-		ID:     uuid.New(),
-		Name:   p.Name,
-		Url:    p.URL,
-		UserID: user.ID,
+		ID:         uuid.New(),
+		Name:       feedName(p.Name, rssFeed),
+		Url:        feedURL,
+		UserID:     user.ID,
+		Visibility: visibility,
 	})
 	if err != nil {
 		responseWithError(w, http.StatusConflict, "Feed exist")
 		return
 	}
 
+	// The metadata is refreshed on every scrape, so a failure here is not fatal
+	updated, err := apiCfg.DB.UpdateFeedMetadata(r.Context(), feedMetadataParams(feed.ID, rssFeed))
+	if err != nil {
+		log.Printf("Error updating feed metadata: %v", err)
+	} else {
+		feed = updated
+	}
+	// Hubs verify subscriptions through a callback, no need to wait for it
+	go subscribeToHub(context.Background(), apiCfg.DB, feed, rssFeed)
+
 	responseWithJSON(w, http.StatusCreated, databaseFeedtoFeed(feed))
 }
 
-// handlerGetFeeds returns all available feeds
-// @Summary      Get all feeds
-// @Description  Retrieve a list of all feeds (publicly available or owned)
+// feedName returns the name given by the user, or the channel title when it is blank
+func feedName(name string, rssFeed RSSFeed) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = strings.TrimSpace(rssFeed.Channel.Title)
+	}
+	return name
+}
+
+// fetchIntervalOverride validates the polling interval sent by an owner,
+// 0 removes the override
+func fetchIntervalOverride(seconds *int32) (sql.NullInt32, bool) {
+	if seconds == nil || *seconds == 0 {
+		return sql.NullInt32{}, true
+	}
+	interval := time.Duration(*seconds) * time.Second
+	if interval < minFetchInterval || interval > maxFetchInterval {
+		return sql.NullInt32{}, false
+	}
+	return sql.NullInt32{Int32: *seconds, Valid: true}, true
+}
+
+// responseWithFeedError reports why a URL could not be used as a feed
+func responseWithFeedError(w http.ResponseWriter, err error) {
+	responseWithError(w, http.StatusBadRequest, feedErrorMessage(err))
+}
+
+func feedErrorMessage(err error) string {
+	if errors.Is(err, errNotAFeed) {
+		return "URL is not a feed"
+	}
+	if errors.Is(err, errPrivateAddress) {
+		return "URL is not public"
+	}
+	return "Can't fetch feed"
+}
+
+// Default direction of each sort of the feed listing, true is descending
+var feedSorts = map[string]bool{
+	"name":      false,
+	"created":   true,
+	"followers": true,
+	"activity":  true,
+}
+
+// handlerGetFeeds returns one page of the feeds, optionally searched and filtered
+// @Summary      Get feeds
+// @Description  List feeds a page at a time. The Link header points at the previous and next pages and X-Total-Count holds the number of matching feeds.
 // @Tags         feeds
 // @Produce      json
-// @Success      200  {array}   map[string]interface{}    "List of feeds"
+// @Param        q         query     string  false  "Search in name, URL and description"
+// @Param        mine      query     bool    false  "Only feeds owned by the authenticated user"
+// @Param        followed  query     bool    false  "Only feeds the authenticated user follows"
+// @Param        language  query     string  false  "Only feeds in this language, e.g. en or en-us"
+// @Param        sort      query     string  false  "name (default), created, followers or activity"
+// @Param        order     query     string  false  "asc or desc, name sorts ascending by default and the others descending"
+// @Param        page      query     int     false  "Page number, starting at 1"
+// @Param        limit     query     int     false  "Feeds per page, 20 by default and at most 100"
+// @Success      200  {array}   FeedListItem
+// @Failure      400  {object}  map[string]interface{}    "Bad request error"
 // @Failure      500  {object}  map[string]interface{}    "Internal server error"
 // @Router       /v2/feeds [get]
 // @Security     BearerAuth
 func (apiCfg *apiConfig) handlerGetFeeds(w http.ResponseWriter, r *http.Request, user database.User) {
-	feeds, err := apiCfg.DB.GetAllFeeds(r.Context())
+	query := r.URL.Query()
+	p, message := pageFromRequest(r)
+	if message != "" {
+		responseWithError(w, 400, message)
+		return
+	}
+
+	sort := query.Get("sort")
+	if sort == "" {
+		sort = "name"
+	}
+	descending, ok := feedSorts[sort]
+	if !ok {
+		responseWithError(w, 400, "Invalid sort")
+		return
+	}
+	switch query.Get("order") {
+	case "":
+	case "asc":
+		descending = false
+	case "desc":
+		descending = true
+	default:
+		responseWithError(w, 400, "Invalid order")
+		return
+	}
+
+	mine, ok := boolQuery(r, "mine")
+	if !ok {
+		responseWithError(w, 400, "Invalid mine")
+		return
+	}
+	followed, ok := boolQuery(r, "followed")
+	if !ok {
+		responseWithError(w, 400, "Invalid followed")
+		return
+	}
+
+	filters := database.CountFeedsParams{
+		Q:        likeEscaper.Replace(strings.TrimSpace(query.Get("q"))),
+		Mine:     mine,
+		UserID:   user.ID,
+		Followed: followed,
+		Language: strings.TrimSpace(query.Get("language")),
+	}
+	rows, err := apiCfg.DB.ListFeeds(r.Context(), database.ListFeedsParams{
+		Q:          filters.Q,
+		Mine:       filters.Mine,
+		UserID:     filters.UserID,
+		Followed:   filters.Followed,
+		Language:   filters.Language,
+		Sort:       sort,
+		Descending: descending,
+		Limit:      int64(p.Size),
+		Offset:     p.offset(),
+	})
 	if err != nil {
 		responseWithError(w, 500, "Can't get feeds")
 		return
 	}
+	total, err := apiCfg.DB.CountFeeds(r.Context(), filters)
+	if err != nil {
+		responseWithError(w, 500, "Can't get feeds")
+		return
+	}
+	setPageHeaders(w, r, p, total)
+	responseWithJSON(w, 200, databaseFeedRowstoFeedListItems(rows))
+}
+
+// Escapes the wildcards of a LIKE pattern so search terms match literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// boolQuery reads a boolean query parameter, missing is false
+func boolQuery(r *http.Request, name string) (bool, bool) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return false, true
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, false
+	}
+	return parsed, true
+}
 
-	responseWithJSON(w, 200, databaseFeedstoFeeds(feeds))
+// handlerGetFeed returns one feed with its owner and activity
+// @Summary      Get feed
+// @Description  Retrieve a feed with its owner, follower count, post count, latest post and whether the authenticated user follows it
+// @Tags         feeds
+// @Produce      json
+// @Param        feed_id  path      string  true  "Feed ID"
+// @Success      200      {object}  FeedDetail
+// @Failure      400      {object}  map[string]string  "Invalid feed id"
+// @Failure      404      {object}  map[string]string  "Feed don't exsist"
+// @Failure      500      {object}  map[string]string  "Internal server error"
+// @Router       /v2/feeds/{feed_id} [get]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerGetFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	feedID, err := uuid.Parse(chi.URLParam(r, "feed_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid feed id")
+		return
+	}
+	feed, err := apiCfg.DB.GetFeedDetail(r.Context(), database.GetFeedDetailParams{
+		UserID: user.ID,
+		ID:     feedID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		responseWithError(w, http.StatusNotFound, "Feed don't exsist")
+		return
+	}
+	if err != nil {
+		responseWithError(w, 500, "Can't get feed")
+		return
+	}
+	responseWithJSON(w, 200, databaseFeedDetailtoFeedDetail(feed))
 }
 
 // handlerUpdateFeed updates an existing feed
 // @Summary      Update feed
-// @Description  Modify the name or URL of a feed
+// @Description  Modify the name or URL of a feed. A new URL is validated the same way as on creation. fetch_interval overrides the polling interval in seconds, 0 removes the override. visibility is public, unlisted or private. The owner and the maintainers can update a feed.
 // @Tags         feeds
 // @Accept       json
 // @Produce      json
@@ -106,7 +299,7 @@ func (apiCfg *apiConfig) handlerUpdateFeed(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if feed.UserID != user.ID {
+	if !apiCfg.canManageFeed(r.Context(), feed, user) {
 		responseWithError(w, 403, "Forbidden")
 		return
 	}
@@ -117,14 +310,52 @@ func (apiCfg *apiConfig) handlerUpdateFeed(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	feed, err = apiCfg.DB.UpdateFeed(r.Context(), database.UpdateFeedParams{
-		Name:   p.Name,
-		Url:    p.URL,
-		UserID: user.ID,
+	fetchInterval, ok := fetchIntervalOverride(p.FetchInterval)
+	if !ok {
+		responseWithError(w, http.StatusBadRequest, fmt.Sprintf("Fetch interval must be between %v and %v seconds", int(minFetchInterval.Seconds()), int(maxFetchInterval.Seconds())))
+		return
+	}
+	visibility, ok := feedVisibility(p.Visibility, feed.Visibility)
+	if !ok {
+		responseWithError(w, http.StatusBadRequest, "Invalid visibility")
+		return
+	}
+
+	// Only fetch again when the URL changes or the name has to be prefilled
+	name, feedURL := p.Name, p.URL
+	var rssFeed *RSSFeed
+	if p.URL != feed.Url || strings.TrimSpace(p.Name) == "" {
+		var discovered RSSFeed
+		feedURL, discovered, err = discoverFeed(r.Context(), p.URL)
+		if err != nil {
+			responseWithFeedError(w, err)
+			return
+		}
+		name = feedName(p.Name, discovered)
+		rssFeed = &discovered
+	}
+
+	// The feed is changed all at once or not at all
+	tx, err := apiCfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		responseWithError(w, 500, "Can't update feed")
+		return
+	}
+	defer tx.Rollback()
+	qtx := apiCfg.DB.WithTx(tx)
+
+	feed, err = qtx.UpdateFeed(r.Context(), database.UpdateFeedParams{
+		Name:   name,
+		Url:    feedURL,
+		UserID: feed.UserID,
 		ID:     feedID,
 	})
 
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			responseWithError(w, http.StatusNotFound, "Feed don't exsist")
+			return
+		}
 		if strings.Contains(err.Error(), "violates unique constraint") {
 			responseWithError(w, http.StatusConflict, "Duplicate feed exist")
 			return
@@ -133,12 +364,51 @@ func (apiCfg *apiConfig) handlerUpdateFeed(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	if rssFeed != nil {
+		feed, err = qtx.UpdateFeedMetadata(r.Context(), feedMetadataParams(feed.ID, *rssFeed))
+		if err != nil {
+			responseWithError(w, 500, "Can't update feed")
+			return
+		}
+	}
+
+	if p.FetchInterval != nil {
+		feed, err = qtx.SetFeedFetchIntervalOverride(r.Context(), database.SetFeedFetchIntervalOverrideParams{
+			ID:                    feed.ID,
+			FetchIntervalOverride: fetchInterval,
+		})
+		if err != nil {
+			responseWithError(w, 500, "Can't update feed")
+			return
+		}
+	}
+
+	if visibility != feed.Visibility {
+		feed, err = qtx.SetFeedVisibility(r.Context(), database.SetFeedVisibilityParams{
+			ID:         feed.ID,
+			Visibility: visibility,
+		})
+		if err != nil {
+			responseWithError(w, 500, "Can't update feed")
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		responseWithError(w, 500, "Can't update feed")
+		return
+	}
+	if rssFeed != nil {
+		go subscribeToHub(context.Background(), apiCfg.DB, feed, *rssFeed)
+	}
+
 	responseWithJSON(w, 200, databaseFeedtoFeed(feed))
 }
 
 // handlerDeleteFeed deletes an existing feed
 // @Summary      Delete feed
-// @Description  Remove a feed owned by the authenticated user
+// @Description  Remove a feed owned by the authenticated user, it can be restored with POST /v2/feeds/{feed_id}/restore during DELETION_GRACE_PERIOD
 // @Tags         feeds
 // @Produce      json
 // @Param        feed_id  path      string  true  "Feed ID"
@@ -178,3 +448,97 @@ func (apiCfg *apiConfig) handlerDeleteFeed(w http.ResponseWriter, r *http.Reques
 
 	responseWithJSON(w, 204, map[string]string{"status": "No Content"})
 }
+
+// handlerRestoreFeed restores a deleted feed
+// @Summary      Restore feed
+// @Description  Restore a feed the authenticated user deleted less than DELETION_GRACE_PERIOD ago, with its posts and follows
+// @Tags         feeds
+// @Produce      json
+// @Param        feed_id  path      string  true  "Feed ID"
+// @Success      200      {object}  Feed
+// @Failure      400      {object}  map[string]string  "Invalid feed id"
+// @Failure      403      {object}  map[string]string  "Forbidden"
+// @Failure      404      {object}  map[string]string  "Deleted feed not found"
+// @Failure      409      {object}  map[string]string  "Feed exist"
+// @Router       /v2/feeds/{feed_id}/restore [post]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerRestoreFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	feedID, err := uuid.Parse(chi.URLParam(r, "feed_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid feed id")
+		return
+	}
+	feed, err := apiCfg.DB.GetDeletedFeed(r.Context(), database.GetDeletedFeedParams{
+		ID:           feedID,
+		GraceSeconds: graceSeconds(),
+	})
+	if err != nil {
+		responseWithError(w, http.StatusNotFound, "Deleted feed not found")
+		return
+	}
+	if feed.UserID != user.ID {
+		responseWithError(w, 403, "Forbidden")
+		return
+	}
+	feed, err = apiCfg.DB.RestoreFeed(r.Context(), feed.ID)
+	if isUniqueViolation(err) {
+		// Added again since it was deleted
+		responseWithError(w, http.StatusConflict, "Feed exist")
+		return
+	}
+	if err != nil {
+		responseWithError(w, 500, "Can't restore feed")
+		return
+	}
+	responseWithJSON(w, 200, databaseFeedtoFeed(feed))
+}
+
+// handlerGetFeedFetches returns the fetch history of a feed
+// @Summary      Get feed fetch history
+// @Description  List the latest fetches of a feed owned or maintained by the authenticated user, newest first
+// @Tags         feeds
+// @Produce      json
+// @Param        feed_id  path      string  true   "Feed ID"
+// @Param        limit    query     int     false  "Number of runs, 20 by default and at most 100"
+// @Success      200      {array}   FetchRun
+// @Failure      400      {object}  map[string]string  "Bad request error"
+// @Failure      403      {object}  map[string]string  "Forbidden error"
+// @Failure      404      {object}  map[string]string  "Feed not found error"
+// @Failure      500      {object}  map[string]string  "Internal server error"
+// @Router       /v2/feeds/{feed_id}/fetches [get]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerGetFeedFetches(w http.ResponseWriter, r *http.Request, user database.User) {
+	feedID, err := uuid.Parse(chi.URLParam(r, "feed_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid feed id")
+		return
+	}
+	limit := 20
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > 100 {
+			responseWithError(w, 400, "Invalid limit")
+			return
+		}
+	}
+
+	feed, err := apiCfg.DB.GetFeed(r.Context(), feedID)
+	if err != nil {
+		responseWithError(w, http.StatusNotFound, "Feed don't exsist")
+		return
+	}
+	if !apiCfg.canManageFeed(r.Context(), feed, user) {
+		responseWithError(w, 403, "Forbidden")
+		return
+	}
+
+	runs, err := apiCfg.DB.GetFeedFetchRuns(r.Context(), database.GetFeedFetchRunsParams{
+		FeedID: feed.ID,
+		Limit:  int64(limit),
+	})
+	if err != nil {
+		responseWithError(w, 500, "Can't get fetch history")
+		return
+	}
+	responseWithJSON(w, 200, databaseFetchRunstoFetchRuns(runs))
+}
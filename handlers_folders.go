@@ -0,0 +1,257 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"project_1/internal/database"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// handlerCreateFolder creates a folder for the user's follows
+// @Summary      Create folder
+// @Description  Create a folder to sort followed feeds into. Without a position the folder goes last.
+// @Tags         folders
+// @Accept       json
+// @Produce      json
+// @Param        folder  body      FolderInput  true  "Folder data"
+// @Success      201     {object}  Folder
+// @Failure      400     {object}  map[string]string  "Invalid folder name"
+// @Failure      409     {object}  map[string]string  "Folder exist"
+// @Failure      500     {object}  map[string]string  "Internal server error"
+// @Router       /v3/folders [post]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerCreateFolder(w http.ResponseWriter, r *http.Request, user database.User) {
+	decoder := json.NewDecoder(r.Body)
+	var p FolderInput
+	err := decoder.Decode(&p)
+	if err != nil {
+		responseWithError(w, 400, "Invalid request payload")
+		return
+	}
+	name := strings.TrimSpace(p.Name)
+	if name == "" {
+		responseWithError(w, http.StatusBadRequest, "Invalid folder name")
+		return
+	}
+
+	var position int32
+	if p.Position != nil {
+		position = *p.Position
+	} else {
+		position, err = apiCfg.DB.GetNextFolderPosition(r.Context(), user.ID)
+		if err != nil {
+			responseWithError(w, 500, "Can't create folder")
+			return
+		}
+	}
+
+	folder, err := apiCfg.DB.CreateFolder(r.Context(), database.CreateFolderParams{
+		ID:       uuid.New(),
+		UserID:   user.ID,
+		Name:     name,
+		Position: position,
+	})
+	if isUniqueViolation(err) {
+		responseWithError(w, http.StatusConflict, "Folder exist")
+		return
+	}
+	if err != nil {
+		responseWithError(w, 500, "Can't create folder")
+		return
+	}
+	responseWithJSON(w, http.StatusCreated, databaseFoldertoFolder(folder))
+}
+
+// handlerGetFolders returns the user's folders
+// @Summary      Get folders
+// @Description  Retrieve the folders of the authenticated user, ordered by position then name
+// @Tags         folders
+// @Produce      json
+// @Success      200  {array}   Folder
+// @Failure      500  {object}  map[string]string
+// @Router       /v3/folders [get]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerGetFolders(w http.ResponseWriter, r *http.Request, user database.User) {
+	folders, err := apiCfg.DB.GetFolders(r.Context(), user.ID)
+	if err != nil {
+		responseWithError(w, 500, "Can't get folders")
+		return
+	}
+	responseWithJSON(w, 200, databaseFolderstoFolders(folders))
+}
+
+// handlerUpdateFolder renames or moves a folder
+// @Summary      Update folder
+// @Description  Rename a folder or change its position, a missing position keeps the current one
+// @Tags         folders
+// @Accept       json
+// @Produce      json
+// @Param        folder_id  path      string       true  "Folder ID"
+// @Param        folder     body      FolderInput  true  "Folder data"
+// @Success      200        {object}  Folder
+// @Failure      400        {object}  map[string]string  "Invalid folder id"
+// @Failure      404        {object}  map[string]string  "Folder not found"
+// @Failure      409        {object}  map[string]string  "Folder exist"
+// @Failure      500        {object}  map[string]string  "Internal server error"
+// @Router       /v3/folders/{folder_id} [put]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerUpdateFolder(w http.ResponseWriter, r *http.Request, user database.User) {
+	folderID, err := uuid.Parse(chi.URLParam(r, "folder_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid folder id")
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	var p FolderInput
+	err = decoder.Decode(&p)
+	if err != nil {
+		responseWithError(w, 400, "Invalid request payload")
+		return
+	}
+	name := strings.TrimSpace(p.Name)
+	if name == "" {
+		responseWithError(w, http.StatusBadRequest, "Invalid folder name")
+		return
+	}
+
+	folder, err := apiCfg.DB.GetFolder(r.Context(), database.GetFolderParams{
+		ID:     folderID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		responseWithError(w, http.StatusNotFound, "Folder not found")
+		return
+	}
+	position := folder.Position
+	if p.Position != nil {
+		position = *p.Position
+	}
+
+	folder, err = apiCfg.DB.UpdateFolder(r.Context(), database.UpdateFolderParams{
+		ID:       folderID,
+		UserID:   user.ID,
+		Name:     name,
+		Position: position,
+	})
+	if isUniqueViolation(err) {
+		responseWithError(w, http.StatusConflict, "Folder exist")
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		// Deleted in the meantime
+		responseWithError(w, http.StatusNotFound, "Folder not found")
+		return
+	}
+	if err != nil {
+		responseWithError(w, 500, "Can't update folder")
+		return
+	}
+	responseWithJSON(w, 200, databaseFoldertoFolder(folder))
+}
+
+// handlerDeleteFolder deletes a folder, its follows are kept outside of any folder
+// @Summary      Delete folder
+// @Description  Delete a folder. The feeds in it stay followed.
+// @Tags         folders
+// @Produce      json
+// @Param        folder_id  path      string  true  "Folder ID"
+// @Success      204        {object}  map[string]string "status": "No Content"
+// @Failure      400        {object}  map[string]string "error": "Invalid folder id"
+// @Failure      404        {object}  map[string]string "error": "Folder not found"
+// @Router       /v3/folders/{folder_id} [delete]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerDeleteFolder(w http.ResponseWriter, r *http.Request, user database.User) {
+	folderID, err := uuid.Parse(chi.URLParam(r, "folder_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid folder id")
+		return
+	}
+	_, err = apiCfg.DB.GetFolder(r.Context(), database.GetFolderParams{
+		ID:     folderID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		responseWithError(w, http.StatusNotFound, "Folder not found")
+		return
+	}
+	err = apiCfg.DB.DeleteFolder(r.Context(), database.DeleteFolderParams{
+		ID:     folderID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		responseWithError(w, 500, "Can't delete folder")
+		return
+	}
+	responseWithJSON(w, 204, map[string]string{"status": "No Content"})
+}
+
+// handlerSetFollowFolder moves a followed feed into a folder, or out of any
+// @Summary      Move follow to folder
+// @Description  Put a followed feed in a folder, a null folder_id takes it out of its folder
+// @Tags         folders
+// @Accept       json
+// @Produce      json
+// @Param        feed_id  path      string             true  "Feed ID"
+// @Param        folder   body      map[string]string  true  "Folder ID or null"
+// @Success      200      {object}  Follow
+// @Failure      400      {object}  map[string]string  "Invalid feed id"
+// @Failure      404      {object}  map[string]string  "Feed not followed"
+// @Router       /v3/follow/{feed_id}/folder [put]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerSetFollowFolder(w http.ResponseWriter, r *http.Request, user database.User) {
+	feedID, err := uuid.Parse(chi.URLParam(r, "feed_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid feed id")
+		return
+	}
+	type params struct {
+		FolderID *uuid.UUID `json:"folder_id"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	var p params
+	err = decoder.Decode(&p)
+	if err != nil {
+		responseWithError(w, 400, "Invalid request payload")
+		return
+	}
+	folderID, ok := apiCfg.userFolderID(r, user, p.FolderID)
+	if !ok {
+		responseWithError(w, http.StatusNotFound, "Folder not found")
+		return
+	}
+
+	follow, err := apiCfg.DB.SetFollowFolder(r.Context(), database.SetFollowFolderParams{
+		UserID:   user.ID,
+		FeedID:   feedID,
+		FolderID: folderID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		responseWithError(w, http.StatusNotFound, "Feed not followed")
+		return
+	}
+	if err != nil {
+		responseWithError(w, 500, "Can't move follow")
+		return
+	}
+	responseWithJSON(w, 200, databaseFollowtoFollow(follow))
+}
+
+// userFolderID checks that folderID belongs to the user, a nil folderID is no folder
+func (apiCfg *apiConfig) userFolderID(r *http.Request, user database.User, folderID *uuid.UUID) (uuid.NullUUID, bool) {
+	if folderID == nil {
+		return uuid.NullUUID{}, true
+	}
+	_, err := apiCfg.DB.GetFolder(r.Context(), database.GetFolderParams{
+		ID:     *folderID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		return uuid.NullUUID{}, false
+	}
+	return uuid.NullUUID{UUID: *folderID, Valid: true}, true
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"project_1/internal/database"
 	"strings"
@@ -10,13 +13,19 @@ import (
 	"github.com/google/uuid"
 )
 
+// Range of the priority weight of a follow
+const (
+	minFollowPriority = -100
+	maxFollowPriority = 100
+)
+
 // handlerFollowFeed allows the user to follow a feed
 // @Summary      Follow a feed
 // @Description  Create a follow relationship for a specific feed
 // @Tags         follow
 // @Accept       json
 // @Produce      json
-// @Param        follow  body      map[string]string  true  "Feed ID to follow"
+// @Param        follow  body      map[string]string  true  "Feed ID to follow and optional folder ID"
 // @Success      201     {object}  Follow
 // @Failure      400     {object}  map[string]string
 // @Failure      404     {object}  map[string]string
@@ -25,7 +34,8 @@ import (
 // @Security     BearerAuth
 func (apiCfg *apiConfig) handlerFollowFeed(w http.ResponseWriter, r *http.Request, user database.User) {
 	type params struct {
-		FeedID uuid.UUID `json:"feed_id"`
+		FeedID   uuid.UUID  `json:"feed_id"`
+		FolderID *uuid.UUID `json:"folder_id"`
 	}
 	decoder := json.NewDecoder(r.Body)
 	var p params
@@ -34,13 +44,25 @@ func (apiCfg *apiConfig) handlerFollowFeed(w http.ResponseWriter, r *http.Reques
 		responseWithError(w, 400, "Invalid request payload")
 		return
 	}
+	folderID, ok := apiCfg.userFolderID(r, user, p.FolderID)
+	if !ok {
+		responseWithError(w, http.StatusNotFound, "Folder not found")
+		return
+	}
+	feed, err := apiCfg.DB.GetFeed(r.Context(), p.FeedID)
+	if err != nil || !apiCfg.canReadFeed(r.Context(), feed, user) {
+		responseWithError(w, http.StatusNotFound, "Feed not found")
+		return
+	}
 	follow, err := apiCfg.DB.CreateFollow(r.Context(), database.CreateFollowParams{
-		ID:     uuid.New(),
-		UserID: user.ID,
-		FeedID: p.FeedID,
+		ID:       uuid.New(),
+		UserID:   user.ID,
+		FeedID:   p.FeedID,
+		FolderID: folderID,
 	})
 	if err != nil {
-		if strings.Contains(err.Error(), "violates unique constraint") {
+		// No row comes back when the feed is already followed
+		if errors.Is(err, sql.ErrNoRows) {
 			responseWithError(w, http.StatusConflict, "Feed already followed")
 			return
 		}
@@ -57,10 +79,10 @@ func (apiCfg *apiConfig) handlerFollowFeed(w http.ResponseWriter, r *http.Reques
 
 // handlerGetFollows returns all feeds followed by the user
 // @Summary      Get followed feeds
-// @Description  Retrieve a list of feeds the authenticated user is following
+// @Description  Retrieve the follows of the authenticated user with their feed, its owner, post count and latest post, highest priority first
 // @Tags         follow
 // @Produce      json
-// @Success      200  {array}   Follow
+// @Success      200  {array}   FollowDetail
 // @Failure      500  {object}  map[string]string
 // @Router       /v3/follow [get]
 // @Security     BearerAuth
@@ -70,12 +92,69 @@ func (apiCfg *apiConfig) handlerGetFollows(w http.ResponseWriter, r *http.Reques
 		responseWithError(w, 500, "Can't get follows")
 		return
 	}
-	responseWithJSON(w, 200, databaseFollowstoFollows(follows))
+	responseWithJSON(w, 200, databaseFollowRowstoFollowDetails(follows))
+}
+
+// handlerUpdateFollow changes the user's settings of a followed feed
+// @Summary      Update follow settings
+// @Description  Set the display title, muted flag or priority of a followed feed. Fields left out keep their value.
+// @Tags         follow
+// @Accept       json
+// @Produce      json
+// @Param        feed_id   path      string               true  "Followed feed ID"
+// @Param        settings  body      FollowSettingsInput  true  "Follow settings"
+// @Success      200       {object}  Follow
+// @Failure      400       {object}  map[string]string "error": "Invalid feed id"
+// @Failure      404       {object}  map[string]string "error": "Feed not followed"
+// @Router       /v3/follow/{feed_id} [patch]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerUpdateFollow(w http.ResponseWriter, r *http.Request, user database.User) {
+	feedID, err := uuid.Parse(chi.URLParam(r, "feed_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid feed id")
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	var p FollowSettingsInput
+	err = decoder.Decode(&p)
+	if err != nil {
+		responseWithError(w, 400, "Invalid request payload")
+		return
+	}
+	if p.Priority != nil && (*p.Priority < minFollowPriority || *p.Priority > maxFollowPriority) {
+		responseWithError(w, 400, fmt.Sprintf("Priority must be between %v and %v", minFollowPriority, maxFollowPriority))
+		return
+	}
+
+	params := database.UpdateFollowSettingsParams{
+		UserID: user.ID,
+		FeedID: feedID,
+	}
+	if p.Title != nil {
+		params.Title = sql.NullString{String: strings.TrimSpace(*p.Title), Valid: true}
+	}
+	if p.Muted != nil {
+		params.Muted = sql.NullBool{Bool: *p.Muted, Valid: true}
+	}
+	if p.Priority != nil {
+		params.Priority = sql.NullInt32{Int32: *p.Priority, Valid: true}
+	}
+
+	follow, err := apiCfg.DB.UpdateFollowSettings(r.Context(), params)
+	if errors.Is(err, sql.ErrNoRows) {
+		responseWithError(w, http.StatusNotFound, "Feed not followed")
+		return
+	}
+	if err != nil {
+		responseWithError(w, 500, "Can't update follow")
+		return
+	}
+	responseWithJSON(w, 200, databaseFollowtoFollow(follow))
 }
 
 // handlerUnfollow removes a feed from the user's followed list
 // @Summary      Unfollow a feed
-// @Description  Unfollow a feed by ID
+// @Description  Unfollow a feed by ID, the follow can be restored with POST /v3/follow/{feed_id}/restore during DELETION_GRACE_PERIOD
 // @Tags         follow
 // @Produce      json
 // @Param        feed_id  path      string  true  "Feed ID to unfollow"
@@ -118,3 +197,43 @@ func (apiCfg *apiConfig) handlerUnfollow(w http.ResponseWriter, r *http.Request,
 	}
 	responseWithJSON(w, 204, map[string]string{"status": "No Content"})
 }
+
+// handlerRestoreFollow restores a deleted follow
+// @Summary      Restore a follow
+// @Description  Follow a feed again with the folder and settings it had when it was unfollowed less than DELETION_GRACE_PERIOD ago
+// @Tags         follow
+// @Produce      json
+// @Param        feed_id  path      string  true  "Feed ID"
+// @Success      200      {object}  Follow
+// @Failure      400      {object}  map[string]string "error": "Invalid feed ID"
+// @Failure      404      {object}  map[string]string "error": "Deleted follow not found"
+// @Router       /v3/follow/{feed_id}/restore [post]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerRestoreFollow(w http.ResponseWriter, r *http.Request, user database.User) {
+	feedID, err := uuid.Parse(chi.URLParam(r, "feed_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid feed id")
+		return
+	}
+	// The feed may have been deleted or made private since
+	feed, err := apiCfg.DB.GetFeed(r.Context(), feedID)
+	if err != nil || !apiCfg.canReadFeed(r.Context(), feed, user) {
+		responseWithError(w, http.StatusNotFound, "Deleted follow not found")
+		return
+	}
+	follow, err := apiCfg.DB.GetDeletedFollow(r.Context(), database.GetDeletedFollowParams{
+		UserID:       user.ID,
+		FeedID:       feed.ID,
+		GraceSeconds: graceSeconds(),
+	})
+	if err != nil {
+		responseWithError(w, http.StatusNotFound, "Deleted follow not found")
+		return
+	}
+	follow, err = apiCfg.DB.RestoreFollow(r.Context(), follow.ID)
+	if err != nil {
+		responseWithError(w, 500, "Can't restore follow")
+		return
+	}
+	responseWithJSON(w, 200, databaseFollowtoFollow(follow))
+}
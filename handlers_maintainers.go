@@ -0,0 +1,412 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"project_1/internal/database"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// Owner of the shared feeds of deleted users, created by the 019 migration
+var systemUserID = uuid.MustParse("00000000-0000-0000-0000-000000000000")
+
+// Status of an ownership transfer
+const (
+	transferAccepted  = "accepted"
+	transferDeclined  = "declined"
+	transferCancelled = "cancelled"
+)
+
+// canManageFeed reports whether the user owns or maintains the feed
+func (apiCfg *apiConfig) canManageFeed(ctx context.Context, feed database.Feed, user database.User) bool {
+	if feed.UserID == user.ID {
+		return true
+	}
+	maintainer, err := apiCfg.DB.IsFeedMaintainer(ctx, database.IsFeedMaintainerParams{
+		FeedID: feed.ID,
+		UserID: user.ID,
+	})
+	return err == nil && maintainer
+}
+
+// handlerGetFeedMaintainers lists the maintainers of a feed
+// @Summary      Get feed maintainers
+// @Description  List the users maintaining a feed besides its owner. Only the owner and the maintainers can see them.
+// @Tags         feeds
+// @Produce      json
+// @Param        feed_id  path      string  true  "Feed ID"
+// @Success      200      {array}   Maintainer
+// @Failure      400      {object}  map[string]string  "Invalid feed id"
+// @Failure      403      {object}  map[string]string  "Forbidden"
+// @Failure      404      {object}  map[string]string  "Feed don't exsist"
+// @Router       /v2/feeds/{feed_id}/maintainers [get]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerGetFeedMaintainers(w http.ResponseWriter, r *http.Request, user database.User) {
+	feedID, err := uuid.Parse(chi.URLParam(r, "feed_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid feed id")
+		return
+	}
+	feed, err := apiCfg.DB.GetFeed(r.Context(), feedID)
+	if err != nil {
+		responseWithError(w, http.StatusNotFound, "Feed don't exsist")
+		return
+	}
+	if !apiCfg.canManageFeed(r.Context(), feed, user) {
+		responseWithError(w, 403, "Forbidden")
+		return
+	}
+	maintainers, err := apiCfg.DB.GetFeedMaintainers(r.Context(), feed.ID)
+	if err != nil {
+		responseWithError(w, 500, "Can't get maintainers")
+		return
+	}
+	responseWithJSON(w, 200, databaseMaintainerstoMaintainers(maintainers))
+}
+
+// handlerAddFeedMaintainer lets another user manage a feed
+// @Summary      Add feed maintainer
+// @Description  Let another user update and refresh a feed. Only the owner can add maintainers.
+// @Tags         feeds
+// @Accept       json
+// @Produce      json
+// @Param        feed_id     path      string             true  "Feed ID"
+// @Param        maintainer  body      map[string]string  true  "User ID of the new maintainer"
+// @Success      201         {object}  Maintainer
+// @Failure      400         {object}  map[string]string  "Bad request error"
+// @Failure      403         {object}  map[string]string  "Forbidden"
+// @Failure      404         {object}  map[string]string  "User not found"
+// @Failure      409         {object}  map[string]string  "User already maintains the feed"
+// @Router       /v2/feeds/{feed_id}/maintainers [post]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerAddFeedMaintainer(w http.ResponseWriter, r *http.Request, user database.User) {
+	feedID, err := uuid.Parse(chi.URLParam(r, "feed_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid feed id")
+		return
+	}
+	type params struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	var p params
+	err = decoder.Decode(&p)
+	if err != nil {
+		responseWithError(w, 400, "Invalid request payload")
+		return
+	}
+	feed, err := apiCfg.DB.GetFeed(r.Context(), feedID)
+	if err != nil {
+		responseWithError(w, http.StatusNotFound, "Feed don't exsist")
+		return
+	}
+	if feed.UserID != user.ID {
+		responseWithError(w, 403, "Forbidden")
+		return
+	}
+	if p.UserID == user.ID {
+		responseWithError(w, 400, "The owner already manages the feed")
+		return
+	}
+	maintainer, ok := apiCfg.otherUser(r.Context(), p.UserID)
+	if !ok {
+		responseWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	added, err := apiCfg.DB.AddFeedMaintainer(r.Context(), database.AddFeedMaintainerParams{
+		FeedID: feed.ID,
+		UserID: maintainer.ID,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "violates unique constraint") {
+			responseWithError(w, http.StatusConflict, "User already maintains the feed")
+			return
+		}
+		responseWithError(w, 500, "Can't add maintainer")
+		return
+	}
+	responseWithJSON(w, http.StatusCreated, Maintainer{
+		FeedID:    added.FeedID,
+		UserID:    added.UserID,
+		UserName:  maintainer.Name,
+		CreatedAt: added.CreatedAt,
+	})
+}
+
+// handlerRemoveFeedMaintainer stops a user from managing a feed
+// @Summary      Remove feed maintainer
+// @Description  Remove a maintainer of a feed. The owner can remove anyone, a maintainer only themselves.
+// @Tags         feeds
+// @Produce      json
+// @Param        feed_id  path      string  true  "Feed ID"
+// @Param        user_id  path      string  true  "User ID of the maintainer"
+// @Success      204      {object}  map[string]string  "status": "No Content"
+// @Failure      400      {object}  map[string]string  "Bad request error"
+// @Failure      403      {object}  map[string]string  "Forbidden"
+// @Failure      404      {object}  map[string]string  "Maintainer not found"
+// @Router       /v2/feeds/{feed_id}/maintainers/{user_id} [delete]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerRemoveFeedMaintainer(w http.ResponseWriter, r *http.Request, user database.User) {
+	feedID, err := uuid.Parse(chi.URLParam(r, "feed_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid feed id")
+		return
+	}
+	userID, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid user id")
+		return
+	}
+	feed, err := apiCfg.DB.GetFeed(r.Context(), feedID)
+	if err != nil {
+		responseWithError(w, http.StatusNotFound, "Feed don't exsist")
+		return
+	}
+	if feed.UserID != user.ID && userID != user.ID {
+		responseWithError(w, 403, "Forbidden")
+		return
+	}
+	removed, err := apiCfg.DB.RemoveFeedMaintainer(r.Context(), database.RemoveFeedMaintainerParams{
+		FeedID: feed.ID,
+		UserID: userID,
+	})
+	if err != nil {
+		responseWithError(w, 500, "Can't remove maintainer")
+		return
+	}
+	if removed == 0 {
+		responseWithError(w, http.StatusNotFound, "Maintainer not found")
+		return
+	}
+	responseWithJSON(w, 204, map[string]string{"status": "No Content"})
+}
+
+// handlerCreateOwnershipTransfer offers a feed to another user
+// @Summary      Transfer feed ownership
+// @Description  Offer the ownership of a feed to another user, who has to accept it. A feed has at most one pending transfer.
+// @Tags         feeds
+// @Accept       json
+// @Produce      json
+// @Param        feed_id   path      string             true  "Feed ID"
+// @Param        transfer  body      map[string]string  true  "User ID of the new owner"
+// @Success      201       {object}  OwnershipTransfer
+// @Failure      400       {object}  map[string]string  "Bad request error"
+// @Failure      403       {object}  map[string]string  "Forbidden"
+// @Failure      404       {object}  map[string]string  "User not found"
+// @Failure      409       {object}  map[string]string  "Transfer already pending"
+// @Router       /v2/feeds/{feed_id}/transfers [post]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerCreateOwnershipTransfer(w http.ResponseWriter, r *http.Request, user database.User) {
+	feedID, err := uuid.Parse(chi.URLParam(r, "feed_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid feed id")
+		return
+	}
+	type params struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	var p params
+	err = decoder.Decode(&p)
+	if err != nil {
+		responseWithError(w, 400, "Invalid request payload")
+		return
+	}
+	feed, err := apiCfg.DB.GetFeed(r.Context(), feedID)
+	if err != nil {
+		responseWithError(w, http.StatusNotFound, "Feed don't exsist")
+		return
+	}
+	if feed.UserID != user.ID {
+		responseWithError(w, 403, "Forbidden")
+		return
+	}
+	if p.UserID == user.ID {
+		responseWithError(w, 400, "Can't transfer a feed to its owner")
+		return
+	}
+	if _, ok := apiCfg.otherUser(r.Context(), p.UserID); !ok {
+		responseWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	transfer, err := apiCfg.DB.CreateOwnershipTransfer(r.Context(), database.CreateOwnershipTransferParams{
+		ID:         uuid.New(),
+		FeedID:     feed.ID,
+		FromUserID: user.ID,
+		ToUserID:   p.UserID,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "violates unique constraint") {
+			responseWithError(w, http.StatusConflict, "Transfer already pending")
+			return
+		}
+		responseWithError(w, 500, "Can't transfer feed")
+		return
+	}
+	responseWithJSON(w, http.StatusCreated, databaseTransfertoTransfer(transfer))
+}
+
+// handlerGetOwnershipTransfers lists the user's pending transfers
+// @Summary      Get ownership transfers
+// @Description  List the pending ownership transfers the authenticated user sent or received
+// @Tags         feeds
+// @Produce      json
+// @Success      200  {array}   OwnershipTransfer
+// @Failure      500  {object}  map[string]string
+// @Router       /v2/transfers [get]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerGetOwnershipTransfers(w http.ResponseWriter, r *http.Request, user database.User) {
+	transfers, err := apiCfg.DB.GetUserOwnershipTransfers(r.Context(), user.ID)
+	if err != nil {
+		responseWithError(w, 500, "Can't get transfers")
+		return
+	}
+	responseWithJSON(w, 200, databaseTransferstoTransfers(transfers))
+}
+
+// handlerAcceptOwnershipTransfer makes the recipient of a transfer the feed owner
+// @Summary      Accept ownership transfer
+// @Description  Become the owner of the feed. The previous owner stays on as a maintainer.
+// @Tags         feeds
+// @Produce      json
+// @Param        transfer_id  path      string  true  "Transfer ID"
+// @Success      200          {object}  OwnershipTransfer
+// @Failure      400          {object}  map[string]string  "Invalid transfer id"
+// @Failure      403          {object}  map[string]string  "Forbidden"
+// @Failure      404          {object}  map[string]string  "Transfer not found"
+// @Failure      409          {object}  map[string]string  "Transfer is not pending"
+// @Router       /v2/transfers/{transfer_id}/accept [post]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerAcceptOwnershipTransfer(w http.ResponseWriter, r *http.Request, user database.User) {
+	transfer, ok := apiCfg.userTransfer(w, r, user)
+	if !ok {
+		return
+	}
+	if transfer.ToUserID != user.ID {
+		responseWithError(w, 403, "Forbidden")
+		return
+	}
+
+	tx, err := apiCfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		responseWithError(w, 500, "Can't accept transfer")
+		return
+	}
+	defer tx.Rollback()
+	qtx := apiCfg.DB.WithTx(tx)
+
+	transfer, err = qtx.SetOwnershipTransferStatus(r.Context(), database.SetOwnershipTransferStatusParams{
+		ID:     transfer.ID,
+		Status: transferAccepted,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		responseWithError(w, http.StatusConflict, "Transfer is not pending")
+		return
+	}
+	if err != nil {
+		responseWithError(w, 500, "Can't accept transfer")
+		return
+	}
+	feed, err := qtx.GetFeed(r.Context(), transfer.FeedID)
+	if err != nil || feed.UserID != transfer.FromUserID {
+		// The sender gave the feed away since, through a deletion
+		responseWithError(w, http.StatusConflict, "Feed changed owner")
+		return
+	}
+	_, err = qtx.SetFeedOwner(r.Context(), database.SetFeedOwnerParams{
+		ID:     feed.ID,
+		UserID: user.ID,
+	})
+	if err == nil {
+		_, err = qtx.RemoveFeedMaintainer(r.Context(), database.RemoveFeedMaintainerParams{
+			FeedID: feed.ID,
+			UserID: user.ID,
+		})
+	}
+	if err == nil {
+		_, err = qtx.AddFeedMaintainer(r.Context(), database.AddFeedMaintainerParams{
+			FeedID: feed.ID,
+			UserID: transfer.FromUserID,
+		})
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		responseWithError(w, 500, "Can't accept transfer")
+		return
+	}
+	responseWithJSON(w, 200, databaseTransfertoTransfer(transfer))
+}
+
+// handlerDeclineOwnershipTransfer turns a transfer down, or takes it back
+// @Summary      Decline ownership transfer
+// @Description  The recipient declines a pending transfer, the sender cancels it
+// @Tags         feeds
+// @Produce      json
+// @Param        transfer_id  path      string  true  "Transfer ID"
+// @Success      200          {object}  OwnershipTransfer
+// @Failure      400          {object}  map[string]string  "Invalid transfer id"
+// @Failure      404          {object}  map[string]string  "Transfer not found"
+// @Failure      409          {object}  map[string]string  "Transfer is not pending"
+// @Router       /v2/transfers/{transfer_id}/decline [post]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerDeclineOwnershipTransfer(w http.ResponseWriter, r *http.Request, user database.User) {
+	transfer, ok := apiCfg.userTransfer(w, r, user)
+	if !ok {
+		return
+	}
+	status := transferDeclined
+	if transfer.FromUserID == user.ID {
+		status = transferCancelled
+	}
+	transfer, err := apiCfg.DB.SetOwnershipTransferStatus(r.Context(), database.SetOwnershipTransferStatusParams{
+		ID:     transfer.ID,
+		Status: status,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		responseWithError(w, http.StatusConflict, "Transfer is not pending")
+		return
+	}
+	if err != nil {
+		responseWithError(w, 500, "Can't decline transfer")
+		return
+	}
+	responseWithJSON(w, 200, databaseTransfertoTransfer(transfer))
+}
+
+// userTransfer loads the transfer of the URL, answering 404 when the user is
+// neither its sender nor its recipient
+func (apiCfg *apiConfig) userTransfer(w http.ResponseWriter, r *http.Request, user database.User) (database.FeedOwnershipTransfer, bool) {
+	transferID, err := uuid.Parse(chi.URLParam(r, "transfer_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid transfer id")
+		return database.FeedOwnershipTransfer{}, false
+	}
+	transfer, err := apiCfg.DB.GetOwnershipTransfer(r.Context(), transferID)
+	if err != nil || (transfer.FromUserID != user.ID && transfer.ToUserID != user.ID) {
+		responseWithError(w, http.StatusNotFound, "Transfer not found")
+		return database.FeedOwnershipTransfer{}, false
+	}
+	return transfer, true
+}
+
+// otherUser returns a user feeds can be handed to, never the system user
+func (apiCfg *apiConfig) otherUser(ctx context.Context, userID uuid.UUID) (database.User, bool) {
+	if userID == systemUserID {
+		return database.User{}, false
+	}
+	user, err := apiCfg.DB.GetUserByID(ctx, userID)
+	if err != nil {
+		return database.User{}, false
+	}
+	return user, true
+}
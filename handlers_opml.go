@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"project_1/internal/database"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// Outcome of one OPML entry
+const (
+	opmlCreated         = "created"
+	opmlFollowed        = "followed"
+	opmlAlreadyFollowed = "already_followed"
+	opmlDuplicate       = "duplicate"
+	opmlFailed          = "failed"
+)
+
+// handlerImportOPML queues the import of an OPML file
+// @Summary      Import OPML
+// @Description  Upload an OPML 2.0 file, as the "file" field of a multipart form or as the request body. The import runs in the background: missing feeds are created and every feed is followed. Poll the job at the Location header until its status is succeeded or failed, its report has one entry per feed of the file.
+// @Tags         follow
+// @Accept       xml
+// @Accept       mpfd
+// @Produce      json
+// @Param        file  formData  file  false  "OPML file"
+// @Success      202   {object}  OPMLImportJob
+// @Failure      400   {object}  map[string]string  "Invalid OPML file"
+// @Failure      500   {object}  map[string]string  "Internal server error"
+// @Router       /v3/follow/opml [post]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerImportOPML(w http.ResponseWriter, r *http.Request, user database.User) {
+	data, err := readOPMLUpload(w, r)
+	if err != nil {
+		responseWithError(w, 400, "Invalid OPML file")
+		return
+	}
+	entries, err := parseOPML(data)
+	if err != nil {
+		responseWithError(w, 400, "Invalid OPML file")
+		return
+	}
+	if len(entries) > maxOPMLEntries {
+		responseWithError(w, 400, fmt.Sprintf("OPML file has more than %v feeds", maxOPMLEntries))
+		return
+	}
+
+	encoded, err := json.Marshal(entries)
+	if err != nil {
+		responseWithError(w, 500, "Can't import OPML file")
+		return
+	}
+	job, err := apiCfg.DB.CreateOPMLImportJob(r.Context(), database.CreateOPMLImportJobParams{
+		ID:      uuid.New(),
+		UserID:  user.ID,
+		Entries: encoded,
+	})
+	if err != nil {
+		responseWithError(w, 500, "Can't import OPML file")
+		return
+	}
+	wakeOPMLImportWorker()
+
+	w.Header().Set("Location", fmt.Sprintf("/v3/follow/opml/%v", job.ID))
+	responseWithJSON(w, http.StatusAccepted, databaseOPMLImportJobtoOPMLImportJob(job))
+}
+
+// handlerGetOPMLImportJob returns the status of an OPML import
+// @Summary      Get OPML import
+// @Description  Poll an OPML import until its status is succeeded or failed, the report is filled in once it succeeded. Only the user who uploaded the file sees the import.
+// @Tags         follow
+// @Produce      json
+// @Param        job_id  path      string  true  "Job ID"
+// @Success      200     {object}  OPMLImportJob
+// @Failure      400     {object}  map[string]string  "Invalid job id"
+// @Failure      404     {object}  map[string]string  "Job not found"
+// @Router       /v3/follow/opml/{job_id} [get]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerGetOPMLImportJob(w http.ResponseWriter, r *http.Request, user database.User) {
+	jobID, err := uuid.Parse(chi.URLParam(r, "job_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid job id")
+		return
+	}
+	job, err := apiCfg.DB.GetOPMLImportJob(r.Context(), database.GetOPMLImportJobParams{
+		ID:     jobID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		responseWithError(w, http.StatusNotFound, "Job not found")
+		return
+	}
+	responseWithJSON(w, 200, databaseOPMLImportJobtoOPMLImportJob(job))
+}
+
+// readOPMLUpload returns the file of a multipart upload, or the body itself
+func readOPMLUpload(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxOPMLSize)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return io.ReadAll(r.Body)
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// findOrCreateFolder returns the user's folder with that name, new folders go last
+func (apiCfg *apiConfig) findOrCreateFolder(ctx context.Context, user database.User, name string) (database.Folder, error) {
+	position, err := apiCfg.DB.GetNextFolderPosition(ctx, user.ID)
+	if err != nil {
+		return database.Folder{}, err
+	}
+	return apiCfg.DB.UpsertFolder(ctx, database.UpsertFolderParams{
+		ID:       uuid.New(),
+		UserID:   user.ID,
+		Name:     name,
+		Position: position,
+	})
+}
+
+// importOPMLEntry finds or creates the feed of an entry and follows it in folder
+func (apiCfg *apiConfig) importOPMLEntry(ctx context.Context, user database.User, entry opmlEntry, folder uuid.NullUUID) OPMLImportEntry {
+	result := newOPMLImportEntry(entry, opmlFailed)
+	if !isValidURL(entry.URL) {
+		result.Error = "Invalid URL"
+		return result
+	}
+	feed, created, err := apiCfg.findOrCreateFeed(ctx, user, entry)
+	if err != nil {
+		result.Error = feedErrorMessage(err)
+		return result
+	}
+	if !apiCfg.canReadFeed(ctx, feed, user) {
+		result.Error = "Feed is private"
+		return result
+	}
+	result.FeedID = &feed.ID
+
+	_, err = apiCfg.DB.CreateFollow(ctx, database.CreateFollowParams{
+		ID:       uuid.New(),
+		UserID:   user.ID,
+		FeedID:   feed.ID,
+		FolderID: folder,
+	})
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		result.Status = opmlAlreadyFollowed
+	case err != nil:
+		result.Error = "Can't follow feed"
+	case created:
+		result.Status = opmlCreated
+	default:
+		result.Status = opmlFollowed
+	}
+	return result
+}
+
+// findOrCreateFeed returns the feed with the entry URL, or validates the URL
+// like handlerCreateFeed does and creates the feed for the user
+func (apiCfg *apiConfig) findOrCreateFeed(ctx context.Context, user database.User, entry opmlEntry) (database.Feed, bool, error) {
+	feed, err := apiCfg.DB.GetFeedByURL(ctx, entry.URL)
+	if err == nil {
+		return feed, false, nil
+	}
+	feedURL, rssFeed, err := discoverFeed(ctx, entry.URL)
+	if err != nil {
+		return database.Feed{}, false, err
+	}
+	// Autodiscovery may land on a feed that already exists
+	feed, err = apiCfg.DB.GetFeedByURL(ctx, feedURL)
+	if err == nil {
+		return feed, false, nil
+	}
+	feed, err = apiCfg.DB.CreateFeed(ctx, database.CreateFeedParams{
+		ID:         uuid.New(),
+		Name:       feedName(entry.Title, rssFeed),
+		Url:        feedURL,
+		UserID:     user.ID,
+		Visibility: feedPublic,
+	})
+	if err != nil {
+		// Created by someone else in the meantime
+		feed, err = apiCfg.DB.GetFeedByURL(ctx, feedURL)
+		return feed, false, err
+	}
+	updated, err := apiCfg.DB.UpdateFeedMetadata(ctx, feedMetadataParams(feed.ID, rssFeed))
+	if err != nil {
+		log.Printf("Error updating feed metadata: %v", err)
+	} else {
+		feed = updated
+	}
+	go subscribeToHub(context.Background(), apiCfg.DB, feed, rssFeed)
+	return feed, true, nil
+}
+
+// handlerExportOPML returns the user's follows as an OPML file
+// @Summary      Export OPML
+// @Description  Download the feeds followed by the authenticated user as an OPML 2.0 file, grouped by folder
+// @Tags         follow
+// @Produce      xml
+// @Success      200  {string}  string  "OPML file"
+// @Failure      500  {object}  map[string]string
+// @Router       /v3/follow/opml [get]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerExportOPML(w http.ResponseWriter, r *http.Request, user database.User) {
+	feeds, err := apiCfg.DB.GetFollowedFeeds(r.Context(), user.ID)
+	if err != nil {
+		responseWithError(w, 500, "Can't get follows")
+		return
+	}
+	outlines := []opmlFeed{}
+	for _, feed := range feeds {
+		outlines = append(outlines, opmlFeed{
+			Title:   feed.Name,
+			URL:     feed.Url,
+			SiteURL: feed.SiteUrl.String,
+			Folder:  feed.FolderName.String,
+		})
+	}
+	data, err := encodeOPML(fmt.Sprintf("Subscriptions of %v", user.Name), outlines)
+	if err != nil {
+		responseWithError(w, 500, "Can't export follows")
+		return
+	}
+	w.Header().Set("Content-Type", "text/x-opml; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="subscriptions.opml"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"project_1/internal/database"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// manualPostHash fingerprints the editable parts of a manual post, like
+// itemContentHash does for scraped items
+func manualPostHash(title string, url string, body string) string {
+	hash := sha256.New()
+	for _, part := range []string{title, url, body} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// decodePostInput reads and checks a PostInput, it returns the error message
+// to answer with when it is invalid
+func decodePostInput(r *http.Request) (PostInput, string) {
+	decoder := json.NewDecoder(r.Body)
+	var p PostInput
+	err := decoder.Decode(&p)
+	if err != nil {
+		return p, "Invalid request payload"
+	}
+	p.Title = strings.TrimSpace(p.Title)
+	p.URL = strings.TrimSpace(p.URL)
+	if p.Title == "" {
+		return p, "Title is required"
+	}
+	if p.URL != "" && !isValidURL(p.URL) {
+		return p, "Invalid URL"
+	}
+	return p, ""
+}
+
+// managedFeed loads the feed of the URL for a handler that needs the user to
+// own or maintain it, answering with the error otherwise
+func (apiCfg *apiConfig) managedFeed(w http.ResponseWriter, r *http.Request, user database.User) (database.Feed, bool) {
+	feedID, err := uuid.Parse(chi.URLParam(r, "feed_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid feed id")
+		return database.Feed{}, false
+	}
+	feed, err := apiCfg.DB.GetFeed(r.Context(), feedID)
+	if err != nil {
+		responseWithError(w, http.StatusNotFound, "Feed don't exsist")
+		return database.Feed{}, false
+	}
+	if !apiCfg.canManageFeed(r.Context(), feed, user) {
+		responseWithError(w, 403, "Forbidden")
+		return database.Feed{}, false
+	}
+	return feed, true
+}
+
+// handlerCreatePost writes a post on a feed
+// @Summary      Create post
+// @Description  Write a post on a feed owned or maintained by the authenticated user. The Markdown body is rendered to sanitized HTML. Posts with a future publish_at stay out of the timelines until then.
+// @Tags         posts
+// @Accept       json
+// @Produce      json
+// @Param        feed_id  path      string     true  "Feed ID"
+// @Param        post     body      PostInput  true  "Post data"
+// @Success      201      {object}  Post
+// @Failure      400      {object}  map[string]string  "Bad request error"
+// @Failure      403      {object}  map[string]string  "Forbidden"
+// @Failure      404      {object}  map[string]string  "Feed don't exsist"
+// @Router       /v2/feeds/{feed_id}/posts [post]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerCreatePost(w http.ResponseWriter, r *http.Request, user database.User) {
+	feed, ok := apiCfg.managedFeed(w, r, user)
+	if !ok {
+		return
+	}
+	p, message := decodePostInput(r)
+	if message != "" {
+		responseWithError(w, 400, message)
+		return
+	}
+	description, err := renderMarkdown(p.Body)
+	if err != nil {
+		responseWithError(w, 400, "Invalid body")
+		return
+	}
+	publishedAt := time.Now().UTC()
+	if p.PublishAt != nil {
+		publishedAt = p.PublishAt.UTC()
+	}
+
+	id := uuid.New()
+	post, err := apiCfg.DB.CreateManualPost(r.Context(), database.CreateManualPostParams{
+		ID:          id,
+		Title:       p.Title,
+		Description: nullString(description),
+		PublishedAt: publishedAt,
+		Url:         p.URL,
+		FeedID:      feed.ID,
+		ItemKey:     "manual:" + id.String(),
+		ContentHash: manualPostHash(p.Title, p.URL, p.Body),
+		Summary:     nullString(summarize(description, summaryLength)),
+		Body:        nullString(p.Body),
+		AuthorID:    uuid.NullUUID{UUID: user.ID, Valid: true},
+	})
+	if err != nil {
+		responseWithError(w, 500, "Can't create post")
+		return
+	}
+	responseWithJSON(w, http.StatusCreated, databasePosttoPost(post))
+}
+
+// handlerGetFeedPosts lists the posts written on a feed
+// @Summary      Get written posts
+// @Description  List the posts written on a feed through the API, scheduled ones included, newest first. The Link header points at the previous and next pages.
+// @Tags         posts
+// @Produce      json
+// @Param        feed_id  path      string  true   "Feed ID"
+// @Param        format   query     string  false  "html (default) or text"
+// @Param        page     query     int     false  "Page number, starting at 1"
+// @Param        limit    query     int     false  "Posts per page, 20 by default and at most 100"
+// @Success      200      {array}   Post
+// @Failure      400      {object}  map[string]string  "Bad request error"
+// @Failure      403      {object}  map[string]string  "Forbidden"
+// @Failure      404      {object}  map[string]string  "Feed don't exsist"
+// @Router       /v2/feeds/{feed_id}/posts [get]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerGetFeedPosts(w http.ResponseWriter, r *http.Request, user database.User) {
+	format, ok := postFormat(r)
+	if !ok {
+		responseWithError(w, http.StatusBadRequest, "Invalid format")
+		return
+	}
+	p, message := pageFromRequest(r)
+	if message != "" {
+		responseWithError(w, 400, message)
+		return
+	}
+	feed, ok := apiCfg.managedFeed(w, r, user)
+	if !ok {
+		return
+	}
+	posts, err := apiCfg.DB.GetManualPosts(r.Context(), database.GetManualPostsParams{
+		FeedID: feed.ID,
+		Limit:  int64(p.Size),
+		Offset: p.offset(),
+	})
+	if err != nil {
+		responseWithError(w, 500, "Can't get posts")
+		return
+	}
+	total, err := apiCfg.DB.CountManualPosts(r.Context(), feed.ID)
+	if err != nil {
+		responseWithError(w, 500, "Can't get posts")
+		return
+	}
+	setPageHeaders(w, r, p, total)
+	result := databasePoststoPosts(posts, nil)
+	if format == "text" {
+		result = postsAsText(result)
+	}
+	responseWithJSON(w, 200, result)
+}
+
+// handlerUpdatePost edits a post written on a feed
+// @Summary      Update post
+// @Description  Replace the title, body and link of a post written on a feed. A missing publish_at keeps the current publication time. Scraped posts can't be edited.
+// @Tags         posts
+// @Accept       json
+// @Produce      json
+// @Param        feed_id  path      string     true  "Feed ID"
+// @Param        post_id  path      string     true  "Post ID"
+// @Param        post     body      PostInput  true  "Post data"
+// @Success      200      {object}  Post
+// @Failure      400      {object}  map[string]string  "Bad request error"
+// @Failure      403      {object}  map[string]string  "Forbidden"
+// @Failure      404      {object}  map[string]string  "Post not found"
+// @Router       /v2/feeds/{feed_id}/posts/{post_id} [put]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerUpdatePost(w http.ResponseWriter, r *http.Request, user database.User) {
+	feed, ok := apiCfg.managedFeed(w, r, user)
+	if !ok {
+		return
+	}
+	post, ok := apiCfg.manualPost(w, r, feed)
+	if !ok {
+		return
+	}
+	p, message := decodePostInput(r)
+	if message != "" {
+		responseWithError(w, 400, message)
+		return
+	}
+	description, err := renderMarkdown(p.Body)
+	if err != nil {
+		responseWithError(w, 400, "Invalid body")
+		return
+	}
+	publishedAt := post.PublishedAt
+	if p.PublishAt != nil {
+		publishedAt = p.PublishAt.UTC()
+	}
+
+	post, err = apiCfg.DB.UpdateManualPost(r.Context(), database.UpdateManualPostParams{
+		ID:          post.ID,
+		FeedID:      feed.ID,
+		Title:       p.Title,
+		Description: nullString(description),
+		PublishedAt: publishedAt,
+		Url:         p.URL,
+		ContentHash: manualPostHash(p.Title, p.URL, p.Body),
+		Summary:     nullString(summarize(description, summaryLength)),
+		Body:        nullString(p.Body),
+	})
+	if err != nil {
+		responseWithError(w, 500, "Can't update post")
+		return
+	}
+	responseWithJSON(w, 200, databasePosttoPost(post))
+}
+
+// handlerDeletePost deletes a post written on a feed
+// @Summary      Delete post
+// @Description  Delete a post written on a feed. Scraped posts can't be deleted.
+// @Tags         posts
+// @Produce      json
+// @Param        feed_id  path      string  true  "Feed ID"
+// @Param        post_id  path      string  true  "Post ID"
+// @Success      204      {object}  map[string]string  "status": "No Content"
+// @Failure      400      {object}  map[string]string  "Bad request error"
+// @Failure      403      {object}  map[string]string  "Forbidden"
+// @Failure      404      {object}  map[string]string  "Post not found"
+// @Router       /v2/feeds/{feed_id}/posts/{post_id} [delete]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerDeletePost(w http.ResponseWriter, r *http.Request, user database.User) {
+	feed, ok := apiCfg.managedFeed(w, r, user)
+	if !ok {
+		return
+	}
+	post, ok := apiCfg.manualPost(w, r, feed)
+	if !ok {
+		return
+	}
+	_, err := apiCfg.DB.DeleteManualPost(r.Context(), database.DeleteManualPostParams{
+		ID:     post.ID,
+		FeedID: feed.ID,
+	})
+	if err != nil {
+		responseWithError(w, 500, "Can't delete post")
+		return
+	}
+	responseWithJSON(w, 204, map[string]string{"status": "No Content"})
+}
+
+// manualPost loads the post of the URL, answering 403 for scraped posts which
+// belong to the publisher
+func (apiCfg *apiConfig) manualPost(w http.ResponseWriter, r *http.Request, feed database.Feed) (database.Post, bool) {
+	postID, err := uuid.Parse(chi.URLParam(r, "post_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid post id")
+		return database.Post{}, false
+	}
+	post, err := apiCfg.DB.GetFeedPost(r.Context(), database.GetFeedPostParams{
+		ID:     postID,
+		FeedID: feed.ID,
+	})
+	if err != nil {
+		responseWithError(w, http.StatusNotFound, "Post not found")
+		return database.Post{}, false
+	}
+	if !post.Manual {
+		responseWithError(w, 403, "Scraped posts can't be changed")
+		return database.Post{}, false
+	}
+	return post, true
+}
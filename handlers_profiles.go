@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"project_1/internal/database"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// profileUser reads the user of the URL, answering with the error when it
+// doesn't exist. The system user holding orphaned feeds has no profile
+func (apiCfg *apiConfig) profileUser(w http.ResponseWriter, r *http.Request) (database.User, bool) {
+	userID, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid user id")
+		return database.User{}, false
+	}
+	if userID == systemUserID {
+		responseWithError(w, http.StatusNotFound, "User not found")
+		return database.User{}, false
+	}
+	user, err := apiCfg.DB.GetUserByID(r.Context(), userID)
+	if err != nil {
+		responseWithError(w, http.StatusNotFound, "User not found")
+		return database.User{}, false
+	}
+	return user, true
+}
+
+// ownedFeeds lists one page of the feeds owned by a user the viewer can
+// see, by name
+func (apiCfg *apiConfig) ownedFeeds(ctx context.Context, viewer database.User, ownerID uuid.UUID, p page) ([]database.ListFeedsRow, error) {
+	return apiCfg.DB.ListFeeds(ctx, database.ListFeedsParams{
+		UserID:  viewer.ID,
+		OwnerID: uuid.NullUUID{UUID: ownerID, Valid: true},
+		Sort:    "name",
+		Limit:   int64(p.Size),
+		Offset:  p.offset(),
+	})
+}
+
+// handlerGetUserProfile returns a user's public profile
+// @Summary      Get user profile
+// @Description  Return a user with their follower and following counts and the first page of the feeds they own, sorted by name
+// @Tags         users
+// @Produce      json
+// @Param        user_id  path      string  true  "User ID"
+// @Success      200      {object}  UserProfile
+// @Failure      400      {object}  map[string]string  "Invalid user id"
+// @Failure      404      {object}  map[string]string  "User not found"
+// @Router       /v1/users/{user_id} [get]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerGetUserProfile(w http.ResponseWriter, r *http.Request, user database.User) {
+	profileUser, ok := apiCfg.profileUser(w, r)
+	if !ok {
+		return
+	}
+	profile, err := apiCfg.DB.GetUserProfile(r.Context(), database.GetUserProfileParams{
+		ViewerID: user.ID,
+		UserID:   profileUser.ID,
+	})
+	if err != nil {
+		responseWithError(w, 500, "Can't get user")
+		return
+	}
+	rows, err := apiCfg.ownedFeeds(r.Context(), user, profileUser.ID, page{Number: 1, Size: defaultPageSize})
+	if err != nil {
+		responseWithError(w, 500, "Can't get user")
+		return
+	}
+	responseWithJSON(w, 200, databaseUserProfiletoUserProfile(profile, databaseFeedRowstoFeedListItems(rows)))
+}
+
+// handlerGetUserFeeds returns one page of the feeds a user owns
+// @Summary      Get user feeds
+// @Description  List the feeds owned by a user a page at a time, sorted by name. The Link header points at the previous and next pages and X-Total-Count holds the number of feeds.
+// @Tags         users
+// @Produce      json
+// @Param        user_id  path      string  true   "User ID"
+// @Param        page     query     int     false  "Page number, starting at 1"
+// @Param        limit    query     int     false  "Feeds per page, 20 by default and at most 100"
+// @Success      200      {array}   FeedListItem
+// @Failure      400      {object}  map[string]string  "Bad request error"
+// @Failure      404      {object}  map[string]string  "User not found"
+// @Router       /v1/users/{user_id}/feeds [get]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerGetUserFeeds(w http.ResponseWriter, r *http.Request, user database.User) {
+	p, message := pageFromRequest(r)
+	if message != "" {
+		responseWithError(w, 400, message)
+		return
+	}
+	profileUser, ok := apiCfg.profileUser(w, r)
+	if !ok {
+		return
+	}
+	rows, err := apiCfg.ownedFeeds(r.Context(), user, profileUser.ID, p)
+	if err != nil {
+		responseWithError(w, 500, "Can't get feeds")
+		return
+	}
+	total, err := apiCfg.DB.CountFeeds(r.Context(), database.CountFeedsParams{
+		UserID:  user.ID,
+		OwnerID: uuid.NullUUID{UUID: profileUser.ID, Valid: true},
+	})
+	if err != nil {
+		responseWithError(w, 500, "Can't get feeds")
+		return
+	}
+	setPageHeaders(w, r, p, total)
+	responseWithJSON(w, 200, databaseFeedRowstoFeedListItems(rows))
+}
+
+// handlerFollowUser follows another user
+// @Summary      Follow user
+// @Description  Follow another user, the posts of the feeds they own show up in GET /v4/posts?scope=network
+// @Tags         users
+// @Produce      json
+// @Param        user_id  path      string  true  "User ID"
+// @Success      201      {object}  UserFollow
+// @Failure      400      {object}  map[string]string  "Bad request error"
+// @Failure      404      {object}  map[string]string  "User not found"
+// @Failure      409      {object}  map[string]string  "User already followed"
+// @Router       /v1/users/{user_id}/follow [post]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerFollowUser(w http.ResponseWriter, r *http.Request, user database.User) {
+	followed, ok := apiCfg.profileUser(w, r)
+	if !ok {
+		return
+	}
+	if followed.ID == user.ID {
+		responseWithError(w, 400, "Can't follow yourself")
+		return
+	}
+	follow, err := apiCfg.DB.FollowUser(r.Context(), database.FollowUserParams{
+		FollowerID: user.ID,
+		FollowedID: followed.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// No row comes back when the follow already exists
+		responseWithError(w, http.StatusConflict, "User already followed")
+		return
+	}
+	if err != nil {
+		responseWithError(w, 500, "Can't follow user")
+		return
+	}
+	responseWithJSON(w, http.StatusCreated, UserFollow{
+		ID:         followed.ID,
+		Name:       followed.Name,
+		FollowedAt: follow.CreatedAt,
+	})
+}
+
+// handlerUnfollowUser stops following another user
+// @Summary      Unfollow user
+// @Description  Stop following another user
+// @Tags         users
+// @Produce      json
+// @Param        user_id  path      string  true  "User ID"
+// @Success      204      {object}  map[string]string  "status": "No Content"
+// @Failure      400      {object}  map[string]string  "Invalid user id"
+// @Failure      404      {object}  map[string]string  "User not followed"
+// @Router       /v1/users/{user_id}/follow [delete]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerUnfollowUser(w http.ResponseWriter, r *http.Request, user database.User) {
+	userID, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid user id")
+		return
+	}
+	removed, err := apiCfg.DB.UnfollowUser(r.Context(), database.UnfollowUserParams{
+		FollowerID: user.ID,
+		FollowedID: userID,
+	})
+	if err != nil {
+		responseWithError(w, 500, "Can't unfollow user")
+		return
+	}
+	if removed == 0 {
+		responseWithError(w, http.StatusNotFound, "User not followed")
+		return
+	}
+	responseWithJSON(w, 204, map[string]string{"status": "No Content"})
+}
+
+// handlerGetUserFollowers lists the users following a user
+// @Summary      Get followers
+// @Description  List the users following a user, latest first
+// @Tags         users
+// @Produce      json
+// @Param        user_id  path      string  true  "User ID"
+// @Success      200      {array}   UserFollow
+// @Failure      400      {object}  map[string]string  "Invalid user id"
+// @Failure      404      {object}  map[string]string  "User not found"
+// @Router       /v1/users/{user_id}/followers [get]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerGetUserFollowers(w http.ResponseWriter, r *http.Request, user database.User) {
+	profileUser, ok := apiCfg.profileUser(w, r)
+	if !ok {
+		return
+	}
+	rows, err := apiCfg.DB.GetUserFollowers(r.Context(), profileUser.ID)
+	if err != nil {
+		responseWithError(w, 500, "Can't get followers")
+		return
+	}
+	responseWithJSON(w, 200, databaseFollowerstoUserFollows(rows))
+}
+
+// handlerGetFollowedUsers lists the users a user follows
+// @Summary      Get following
+// @Description  List the users a user follows, latest first
+// @Tags         users
+// @Produce      json
+// @Param        user_id  path      string  true  "User ID"
+// @Success      200      {array}   UserFollow
+// @Failure      400      {object}  map[string]string  "Invalid user id"
+// @Failure      404      {object}  map[string]string  "User not found"
+// @Router       /v1/users/{user_id}/following [get]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerGetFollowedUsers(w http.ResponseWriter, r *http.Request, user database.User) {
+	profileUser, ok := apiCfg.profileUser(w, r)
+	if !ok {
+		return
+	}
+	rows, err := apiCfg.DB.GetFollowedUsers(r.Context(), profileUser.ID)
+	if err != nil {
+		responseWithError(w, 500, "Can't get following")
+		return
+	}
+	responseWithJSON(w, 200, databaseFollowedUserstoUserFollows(rows))
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"project_1/internal/database"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// handlerRefreshFeed queues an immediate fetch of a feed
+// @Summary      Refresh feed
+// @Description  Queue a fetch of the feed right away. A feed that already has a job waiting or running returns that job. Refreshes are limited per feed and per user.
+// @Tags         feeds
+// @Produce      json
+// @Param        feed_id  path      string  true  "Feed ID"
+// @Success      202      {object}  RefreshJob
+// @Failure      400      {object}  map[string]string  "Bad request error"
+// @Failure      404      {object}  map[string]string  "Feed not found error"
+// @Failure      429      {object}  map[string]string  "Too many refreshes"
+// @Failure      500      {object}  map[string]string  "Internal server error"
+// @Router       /v2/feeds/{feed_id}/refresh [post]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerRefreshFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	feedID, err := uuid.Parse(chi.URLParam(r, "feed_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid feed id")
+		return
+	}
+
+	feed, err := apiCfg.DB.GetFeed(r.Context(), feedID)
+	if err != nil || !apiCfg.canReadFeed(r.Context(), feed, user) {
+		responseWithError(w, http.StatusNotFound, "Feed don't exsist")
+		return
+	}
+
+	pending, err := apiCfg.DB.GetPendingRefreshJob(r.Context(), feed.ID)
+	if err == nil {
+		responseWithRefreshJob(w, http.StatusAccepted, pending)
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		responseWithError(w, 500, "Can't refresh feed")
+		return
+	}
+
+	recent, err := apiCfg.DB.CountRecentFeedRefreshJobs(r.Context(), database.CountRecentFeedRefreshJobsParams{
+		FeedID:        feed.ID,
+		WindowSeconds: int32(refreshFeedCooldown.Seconds()),
+	})
+	if err != nil {
+		responseWithError(w, 500, "Can't refresh feed")
+		return
+	}
+	if recent > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(refreshFeedCooldown.Seconds())))
+		responseWithError(w, http.StatusTooManyRequests, "Feed was refreshed recently")
+		return
+	}
+
+	recent, err = apiCfg.DB.CountRecentUserRefreshJobs(r.Context(), database.CountRecentUserRefreshJobsParams{
+		UserID:        user.ID,
+		WindowSeconds: int32(refreshUserWindow.Seconds()),
+	})
+	if err != nil {
+		responseWithError(w, 500, "Can't refresh feed")
+		return
+	}
+	if recent >= int64(refreshUserLimit) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(refreshUserWindow.Seconds())))
+		responseWithError(w, http.StatusTooManyRequests, "Too many refreshes")
+		return
+	}
+
+	job, err := apiCfg.DB.CreateRefreshJob(r.Context(), database.CreateRefreshJobParams{
+		ID:     uuid.New(),
+		FeedID: feed.ID,
+		UserID: user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Queued by another request in the meantime
+		job, err = apiCfg.DB.GetPendingRefreshJob(r.Context(), feed.ID)
+		if err == nil {
+			responseWithRefreshJob(w, http.StatusAccepted, job)
+			return
+		}
+	}
+	if err != nil {
+		responseWithError(w, 500, "Can't refresh feed")
+		return
+	}
+	wakeRefreshWorker()
+
+	responseWithRefreshJob(w, http.StatusAccepted, job)
+}
+
+// handlerGetRefreshJob returns the status of a refresh job
+// @Summary      Get refresh job
+// @Description  Poll a refresh job until its status is succeeded or failed. Jobs of feeds the user may not read are not found.
+// @Tags         feeds
+// @Produce      json
+// @Param        feed_id  path      string  true  "Feed ID"
+// @Param        job_id   path      string  true  "Job ID"
+// @Success      200      {object}  RefreshJob
+// @Failure      400      {object}  map[string]string  "Bad request error"
+// @Failure      404      {object}  map[string]string  "Job not found error"
+// @Router       /v2/feeds/{feed_id}/refresh/{job_id} [get]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerGetRefreshJob(w http.ResponseWriter, r *http.Request, user database.User) {
+	feedID, err := uuid.Parse(chi.URLParam(r, "feed_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid feed id")
+		return
+	}
+	jobID, err := uuid.Parse(chi.URLParam(r, "job_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid job id")
+		return
+	}
+
+	feed, err := apiCfg.DB.GetFeed(r.Context(), feedID)
+	if err != nil || !apiCfg.canReadFeed(r.Context(), feed, user) {
+		responseWithError(w, http.StatusNotFound, "Job not found")
+		return
+	}
+	job, err := apiCfg.DB.GetRefreshJob(r.Context(), database.GetRefreshJobParams{
+		ID:     jobID,
+		FeedID: feed.ID,
+	})
+	if err != nil {
+		responseWithError(w, http.StatusNotFound, "Job not found")
+		return
+	}
+	responseWithJSON(w, 200, databaseRefreshJobtoRefreshJob(job))
+}
+
+// responseWithRefreshJob answers with a job and where to poll it
+func responseWithRefreshJob(w http.ResponseWriter, code int, job database.FeedRefreshJob) {
+	w.Header().Set("Location", fmt.Sprintf("/v2/feeds/%v/refresh/%v", job.FeedID, job.ID))
+	responseWithJSON(w, code, databaseRefreshJobtoRefreshJob(job))
+}
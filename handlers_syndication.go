@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"project_1/internal/database"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// syndicationFormat is one of the documents a feed is served back out as
+type syndicationFormat struct {
+	contentType string
+	encode      func(syndicationFeed) ([]byte, error)
+}
+
+// Formats by the extension of the URL
+var syndicationFormats = map[string]syndicationFormat{
+	"rss":  {contentType: rssContentType, encode: encodeRSS},
+	"atom": {contentType: atomContentType, encode: encodeAtom},
+	"json": {contentType: jsonFeedContentType, encode: encodeJSONFeed},
+}
+
+// handlerGetFeedDocument serves a feed's posts as RSS, Atom or JSON Feed
+// @Summary      Get feed document
+// @Description  Serve the latest posts of a feed as RSS 2.0 (.rss), Atom 1.0 (.atom) or JSON Feed 1.1 (.json). Answers 304 to a matching If-None-Match or If-Modified-Since.
+// @Tags         syndication
+// @Produce      xml
+// @Produce      json
+// @Param        feed_id  path      string  true  "Feed ID"
+// @Param        format   path      string  true  "rss, atom or json"
+// @Success      200      {string}  string  "Feed document"
+// @Success      304      {string}  string  "Not modified"
+// @Failure      400      {object}  map[string]string  "Invalid feed id"
+// @Failure      404      {object}  map[string]string  "Feed don't exsist"
+// @Router       /feeds/{feed_id}.{format} [get]
+func (apiCfg *apiConfig) handlerGetFeedDocument(w http.ResponseWriter, r *http.Request) {
+	format, ok := syndicationFormats[chi.URLParam(r, "format")]
+	if !ok {
+		responseWithError(w, http.StatusNotFound, "Unknown feed format")
+		return
+	}
+	feedID, err := uuid.Parse(chi.URLParam(r, "feed_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid feed id")
+		return
+	}
+	feed, err := apiCfg.DB.GetFeed(r.Context(), feedID)
+	// Nobody is signed in here, private feeds don't exist
+	if err != nil || feed.Visibility == feedPrivate {
+		responseWithError(w, http.StatusNotFound, "Feed don't exsist")
+		return
+	}
+	// A deleted owner keeps their shared feeds until they are purged, unnamed
+	var author string
+	owner, err := apiCfg.DB.GetUserByID(r.Context(), feed.UserID)
+	if err == nil {
+		author = owner.Name
+	}
+	dbPosts, err := apiCfg.DB.GetFeedPublishedPosts(r.Context(), database.GetFeedPublishedPostsParams{
+		FeedID: feed.ID,
+		Limit:  syndicationItems,
+	})
+	if err != nil {
+		responseWithError(w, 500, "Can't get posts")
+		return
+	}
+	posts, err := apiCfg.postsWithEnclosures(r.Context(), dbPosts)
+	if err != nil {
+		responseWithError(w, 500, "Can't get posts")
+		return
+	}
+	serveFeedDocument(w, r, format, "public, max-age=300", syndicationFeed{
+		ID:          "urn:uuid:" + feed.ID.String(),
+		Title:       feed.Name,
+		Description: feed.Description.String,
+		Language:    feed.Language.String,
+		SiteURL:     feed.SiteUrl.String,
+		SelfURL:     requestBaseURL(r) + r.URL.Path,
+		Author:      author,
+		Updated:     feed.UpdatedAt,
+		Posts:       posts,
+	})
+}
+
+// handlerGetTimelineDocument serves a user's timeline as RSS, Atom or JSON Feed
+// @Summary      Get timeline document
+// @Description  Serve the timeline of the user owning the secret token, as in GET /v4/posts, as RSS 2.0 (.rss), Atom 1.0 (.atom) or JSON Feed 1.1 (.json). Answers 304 to a matching If-None-Match or If-Modified-Since.
+// @Tags         syndication
+// @Produce      xml
+// @Produce      json
+// @Param        token   path      string  true  "Timeline token"
+// @Param        format  path      string  true  "rss, atom or json"
+// @Success      200     {string}  string  "Feed document"
+// @Success      304     {string}  string  "Not modified"
+// @Failure      404     {object}  map[string]string  "Timeline not found"
+// @Router       /timelines/{token}.{format} [get]
+func (apiCfg *apiConfig) handlerGetTimelineDocument(w http.ResponseWriter, r *http.Request) {
+	format, ok := syndicationFormats[chi.URLParam(r, "format")]
+	if !ok {
+		responseWithError(w, http.StatusNotFound, "Unknown feed format")
+		return
+	}
+	user, err := apiCfg.DB.GetUserByTimelineToken(r.Context(), nullString(hashSecretToken(chi.URLParam(r, "token"))))
+	if err != nil {
+		responseWithError(w, http.StatusNotFound, "Timeline not found")
+		return
+	}
+	dbPosts, err := apiCfg.DB.GetPosts(r.Context(), database.GetPostsParams{
+		UserID: user.ID,
+		Limit:  syndicationItems,
+	})
+	if err != nil {
+		responseWithError(w, 500, "Can't get posts")
+		return
+	}
+	posts, err := apiCfg.postsWithEnclosures(r.Context(), dbPosts)
+	if err != nil {
+		responseWithError(w, 500, "Can't get posts")
+		return
+	}
+	// Following or unfollowing a feed changes the timeline too
+	updatedAt, err := apiCfg.DB.GetTimelineUpdatedAt(r.Context(), user.ID)
+	if err != nil {
+		responseWithError(w, 500, "Can't get posts")
+		return
+	}
+	serveFeedDocument(w, r, format, "private, max-age=300", syndicationFeed{
+		ID:      "urn:uuid:" + user.ID.String(),
+		Title:   fmt.Sprintf("Timeline of %v", user.Name),
+		SelfURL: requestBaseURL(r) + r.URL.Path,
+		Author:  user.Name,
+		Updated: updatedAt,
+		Posts:   posts,
+	})
+}
+
+// serveFeedDocument encodes the feed and lets http.ServeContent answer 304
+// from the ETag and Last-Modified
+func serveFeedDocument(w http.ResponseWriter, r *http.Request, format syndicationFormat, cacheControl string, feed syndicationFeed) {
+	data, err := format.encode(feed)
+	if err != nil {
+		responseWithError(w, 500, "Can't encode feed")
+		return
+	}
+	sum := sha256.Sum256(data)
+	// Weak, the compression middleware may change the bytes sent
+	w.Header().Set("ETag", fmt.Sprintf(`W/"%v"`, hex.EncodeToString(sum[:16])))
+	w.Header().Set("Content-Type", format.contentType)
+	w.Header().Set("Cache-Control", cacheControl)
+	http.ServeContent(w, r, "", feed.lastModified(), bytes.NewReader(data))
+}
+
+// postsWithEnclosures converts posts with their enclosures loaded
+func (apiCfg *apiConfig) postsWithEnclosures(ctx context.Context, posts []database.Post) ([]Post, error) {
+	postIDs := []uuid.UUID{}
+	for _, post := range posts {
+		postIDs = append(postIDs, post.ID)
+	}
+	enclosures, err := apiCfg.DB.GetPostEnclosures(ctx, postIDs)
+	if err != nil {
+		return nil, err
+	}
+	return databasePoststoPosts(posts, enclosures), nil
+}
+
+// Public scheme and host of the API used in the links it hands out, set with
+// PUBLIC_BASE_URL. It has to be set behind a proxy
+var publicBaseURL string
+
+// requestBaseURL is PUBLIC_BASE_URL, or the scheme and host the request came
+// in on. X-Forwarded-* headers are ignored, any client can send them
+func requestBaseURL(r *http.Request) string {
+	if publicBaseURL != "" {
+		return publicBaseURL
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// handlerCreateTimelineToken creates the secret token of the user's timeline feeds
+// @Summary      Create timeline token
+// @Description  Create the secret token addressing the authenticated user's timeline as RSS, Atom and JSON Feed. The token is only shown in this response, creating a new one revokes the previous one.
+// @Tags         syndication
+// @Produce      json
+// @Success      201  {object}  TimelineToken
+// @Failure      500  {object}  map[string]string
+// @Router       /v1/user/timeline-token [post]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerCreateTimelineToken(w http.ResponseWriter, r *http.Request, user database.User) {
+	token, err := newSecretToken()
+	if err != nil {
+		responseWithError(w, 500, "Can't create token")
+		return
+	}
+	err = apiCfg.DB.SetTimelineToken(r.Context(), database.SetTimelineTokenParams{
+		ID:                user.ID,
+		TimelineTokenHash: nullString(hashSecretToken(token)),
+	})
+	if err != nil {
+		responseWithError(w, 500, "Can't create token")
+		return
+	}
+	base := requestBaseURL(r) + "/timelines/" + token
+	responseWithJSON(w, http.StatusCreated, TimelineToken{
+		Token:   token,
+		RSSURL:  base + ".rss",
+		AtomURL: base + ".atom",
+		JSONURL: base + ".json",
+	})
+}
+
+// handlerDeleteTimelineToken revokes the secret token of the user's timeline feeds
+// @Summary      Revoke timeline token
+// @Description  Revoke the token addressing the authenticated user's timeline feeds, their URLs answer 404 afterwards
+// @Tags         syndication
+// @Produce      json
+// @Success      204  {object}  map[string]string  "status": "No Content"
+// @Failure      500  {object}  map[string]string
+// @Router       /v1/user/timeline-token [delete]
+// @Security     BearerAuth
+func (apiCfg *apiConfig) handlerDeleteTimelineToken(w http.ResponseWriter, r *http.Request, user database.User) {
+	err := apiCfg.DB.SetTimelineToken(r.Context(), database.SetTimelineTokenParams{
+		ID: user.ID,
+	})
+	if err != nil {
+		responseWithError(w, 500, "Can't revoke token")
+		return
+	}
+	responseWithJSON(w, 204, map[string]string{"status": "No Content"})
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"project_1/internal/database"
@@ -65,7 +66,7 @@ func (apiCfg *apiConfig) handlerGetUser(w http.ResponseWriter, r *http.Request,
 
 // handlerDeleteUser deletes the authenticated user's account
 // @Summary      Delete user
-// @Description  Delete the current authenticated user's account
+// @Description  Delete the current authenticated user's account. Feeds with maintainers go to the longest standing one, feeds others follow go to the system user. The account, its follows and the other feeds can be restored with POST /v1/user/restore during DELETION_GRACE_PERIOD.
 // @Tags         user
 // @Produce      json
 // @Success      204  {object}  map[string]string
@@ -73,7 +74,7 @@ func (apiCfg *apiConfig) handlerGetUser(w http.ResponseWriter, r *http.Request,
 // @Router       /v1/user [delete]
 // @Security     BearerAuth
 func (apiCfg *apiConfig) handlerDeleteUser(w http.ResponseWriter, r *http.Request, user database.User) {
-	err := apiCfg.DB.DeleteUser(r.Context(), user.ID)
+	err := apiCfg.deleteUser(r.Context(), user.ID)
 	if err != nil {
 		responseWithError(w, 500, "Can't delete user")
 		return
@@ -81,6 +82,123 @@ func (apiCfg *apiConfig) handlerDeleteUser(w http.ResponseWriter, r *http.Reques
 	responseWithJSON(w, 204, map[string]string{"status": "No Content"})
 }
 
+// deleteUser hands the user's shared feeds over, then marks the user, their
+// follows and remaining feeds as deleted at the same time, which is how
+// handlerRestoreUser finds them again. All in one transaction
+func (apiCfg *apiConfig) deleteUser(ctx context.Context, userID uuid.UUID) error {
+	tx, err := apiCfg.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := apiCfg.DB.WithTx(tx)
+
+	err = qtx.ReassignFeedsToMaintainers(ctx, userID)
+	if err != nil {
+		return err
+	}
+	err = qtx.OrphanSharedFeeds(ctx, database.OrphanSharedFeedsParams{
+		SystemUserID: systemUserID,
+		UserID:       userID,
+	})
+	if err != nil {
+		return err
+	}
+	user, err := qtx.SoftDeleteUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	err = qtx.SoftDeleteUserFeeds(ctx, database.SoftDeleteUserFeedsParams{
+		UserID:    user.ID,
+		DeletedAt: user.DeletedAt,
+	})
+	if err != nil {
+		return err
+	}
+	err = qtx.SoftDeleteUserFollows(ctx, database.SoftDeleteUserFollowsParams{
+		UserID:    user.ID,
+		DeletedAt: user.DeletedAt,
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// handlerRestoreUser restores a deleted account
+// @Summary      Restore user
+// @Description  Restore an account deleted less than DELETION_GRACE_PERIOD ago, along with the follows and feeds deleted with it. Feeds handed over to others stay with them. Takes the same form as the login.
+// @Tags         user
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        username  formData  string  true  "Email"
+// @Param        password  formData  string  true  "Password"
+// @Success      200       {object}  User
+// @Failure      401       {object}  map[string]string  "Wrong password"
+// @Failure      404       {object}  map[string]string  "Deleted user not found"
+// @Failure      409       {object}  map[string]string  "The email or one of the feeds was taken again"
+// @Router       /v1/user/restore [post]
+func (apiCfg *apiConfig) handlerRestoreUser(w http.ResponseWriter, r *http.Request) {
+	user, err := apiCfg.DB.GetDeletedUser(r.Context(), database.GetDeletedUserParams{
+		Email:        r.FormValue("username"),
+		GraceSeconds: graceSeconds(),
+	})
+	if err != nil {
+		responseWithError(w, http.StatusNotFound, "Deleted user not found")
+		return
+	}
+	if !checkHash(r.FormValue("password"), user.Password) {
+		responseWithError(w, http.StatusUnauthorized, "Wrong password")
+		return
+	}
+
+	tx, err := apiCfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		responseWithError(w, 500, "Can't restore user")
+		return
+	}
+	defer tx.Rollback()
+	qtx := apiCfg.DB.WithTx(tx)
+
+	err = qtx.RestoreUserFeeds(r.Context(), database.RestoreUserFeedsParams{
+		UserID:    user.ID,
+		DeletedAt: user.DeletedAt,
+	})
+	if isUniqueViolation(err) {
+		// Added again since it was deleted
+		responseWithError(w, http.StatusConflict, "Feed exist")
+		return
+	}
+	if err != nil {
+		responseWithError(w, 500, "Can't restore user")
+		return
+	}
+	err = qtx.RestoreUserFollows(r.Context(), database.RestoreUserFollowsParams{
+		UserID:    user.ID,
+		DeletedAt: user.DeletedAt,
+	})
+	if err != nil {
+		responseWithError(w, 500, "Can't restore user")
+		return
+	}
+	user, err = qtx.RestoreUser(r.Context(), user.ID)
+	if isUniqueViolation(err) {
+		// Added again since it was deleted
+		responseWithError(w, http.StatusConflict, "Account already exists")
+		return
+	}
+	if err != nil {
+		responseWithError(w, 500, "Can't restore user")
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		responseWithError(w, 500, "Can't restore user")
+		return
+	}
+	responseWithJSON(w, 200, databaseUsertoUser(user))
+}
+
 // handlerUpdateUser updates the authenticated user's profile
 // @Summary      Update user
 // @Description  Update name and email of the authenticated user
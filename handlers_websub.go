@@ -0,0 +1,138 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"project_1/internal/database"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// handlerWebSubVerify answers the hub's verification of a subscription
+// @Summary      Verify WebSub subscription
+// @Description  Called by a hub to confirm a subscription request. The challenge is echoed back when the topic matches and the subscription is waiting for the hub.
+// @Tags         websub
+// @Produce      plain
+// @Param        subscription_id    path   string  true   "Subscription ID"
+// @Param        hub.mode           query  string  true   "subscribe or denied"
+// @Param        hub.topic          query  string  true   "Topic URL"
+// @Param        hub.challenge      query  string  false  "Challenge to echo"
+// @Param        hub.lease_seconds  query  int     false  "Lease granted by the hub"
+// @Success      200  {string}  string  "Challenge"
+// @Failure      404  {object}  map[string]string  "Subscription not found error"
+// @Router       /websub/{subscription_id} [get]
+func (apiCfg *apiConfig) handlerWebSubVerify(w http.ResponseWriter, r *http.Request) {
+	subscriptionID, err := uuid.Parse(chi.URLParam(r, "subscription_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid subscription id")
+		return
+	}
+	subscription, err := apiCfg.DB.GetWebSubSubscription(r.Context(), subscriptionID)
+	query := r.URL.Query()
+	if err != nil || query.Get("hub.topic") != subscription.Topic {
+		responseWithError(w, http.StatusNotFound, "Subscription not found")
+		return
+	}
+
+	switch query.Get("hub.mode") {
+	case "subscribe":
+		lease, err := strconv.Atoi(query.Get("hub.lease_seconds"))
+		if err != nil || lease <= 0 {
+			lease = webSubLeaseSeconds
+		}
+		_, err = apiCfg.DB.ActivateWebSubSubscription(r.Context(), database.ActivateWebSubSubscriptionParams{
+			ID:           subscription.ID,
+			LeaseSeconds: int32(lease),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			// Not asked for, or already verified
+			responseWithError(w, http.StatusNotFound, "Subscription not found")
+			return
+		}
+		if err != nil {
+			responseWithError(w, 500, "Can't verify subscription")
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(query.Get("hub.challenge")))
+	case "denied":
+		log.Printf("WebSub subscription %v denied: %v", subscription.ID, query.Get("hub.reason"))
+		err = apiCfg.DB.DenyWebSubSubscription(r.Context(), subscription.ID)
+		if err != nil {
+			responseWithError(w, 500, "Can't verify subscription")
+			return
+		}
+		responseWithJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	default:
+		// Unsubscribing is never asked for, the subscription is dropped with its feed
+		responseWithError(w, http.StatusNotFound, "Subscription not found")
+	}
+}
+
+// handlerWebSubPush ingests content pushed by a hub
+// @Summary      Receive WebSub content
+// @Description  Called by a hub with the new content of a feed. Only verified subscriptions with a running lease take content, and content without a valid X-Hub-Signature is acknowledged but ignored. The pushed items are saved, the feed's metadata and polling schedule are left to the next fetch.
+// @Tags         websub
+// @Accept       xml
+// @Produce      json
+// @Param        subscription_id  path    string  true  "Subscription ID"
+// @Param        X-Hub-Signature  header  string  true  "HMAC of the body, method=hex"
+// @Success      204  {object}  map[string]string  "Status: No Content"
+// @Failure      400  {object}  map[string]string  "Bad request error"
+// @Failure      404  {object}  map[string]string  "Subscription not found error"
+// @Router       /websub/{subscription_id} [post]
+func (apiCfg *apiConfig) handlerWebSubPush(w http.ResponseWriter, r *http.Request) {
+	subscriptionID, err := uuid.Parse(chi.URLParam(r, "subscription_id"))
+	if err != nil {
+		responseWithError(w, 400, "Invalid subscription id")
+		return
+	}
+	subscription, err := apiCfg.DB.GetLiveWebSubSubscription(r.Context(), subscriptionID)
+	if err != nil {
+		responseWithError(w, http.StatusNotFound, "Subscription not found")
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxFeedSize))
+	if err != nil {
+		responseWithError(w, 400, "Can't read content")
+		return
+	}
+
+	// The spec asks for a 2xx either way so a forger learns nothing
+	if !validWebSubSignature(subscription.Secret, r.Header.Get("X-Hub-Signature"), body) {
+		log.Printf("Ignoring WebSub content with an invalid signature for %v", subscription.ID)
+		responseWithJSON(w, 204, map[string]string{"status": "No Content"})
+		return
+	}
+
+	startedAt := time.Now()
+	stats := fetchStats{Bytes: int64(len(body)), CompressedBytes: int64(len(body))}
+	rssFeed, err := parseFeed(body, r.Header.Get("Content-Type"))
+	if err != nil {
+		recordFetchRun(apiCfg.DB, subscription.FeedID, fetchPush, startedAt, stats, ingestResult{}, err)
+		responseWithError(w, 400, "Content is not a feed")
+		return
+	}
+	feed, err := apiCfg.DB.GetFeed(r.Context(), subscription.FeedID)
+	if err != nil {
+		responseWithError(w, http.StatusNotFound, "Subscription not found")
+		return
+	}
+	result, err := ingestPushedItems(r.Context(), apiCfg.Conn, feed.ID, rssFeed)
+	if err != nil {
+		recordFetchRun(apiCfg.DB, feed.ID, fetchPush, startedAt, stats, ingestResult{ItemsSeen: len(rssFeed.Channel.Item)}, err)
+		responseWithError(w, 500, "Can't save content")
+		return
+	}
+	recordFetchRun(apiCfg.DB, feed.ID, fetchPush, startedAt, stats, result, nil)
+	log.Printf("Feed pushed %v, %v new, %v updated", feed.Name, result.NewPosts, result.UpdatedPosts)
+
+	responseWithJSON(w, 204, map[string]string{"status": "No Content"})
+}
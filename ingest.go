@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"project_1/internal/database"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ingestResult counts what a fetch changed in the database
+type ingestResult struct {
+	ItemsSeen    int
+	NewPosts     int
+	UpdatedPosts int
+}
+
+// itemCategories returns the trimmed, non empty categories of an item
+func itemCategories(item RSSItem) []string {
+	categories := []string{}
+	for _, category := range item.Categories {
+		category = strings.TrimSpace(category)
+		if category != "" {
+			categories = append(categories, category)
+		}
+	}
+	return categories
+}
+
+// itemKey identifies an item inside its feed, the GUID when the publisher
+// gives one and the link otherwise
+func itemKey(item RSSItem) string {
+	if guid := strings.TrimSpace(item.GUID); guid != "" {
+		return guid
+	}
+	return strings.TrimSpace(item.Link)
+}
+
+// itemContentHash fingerprints the parts of an item that can be edited by the
+// publisher, with the HTML as it is stored. A different hash means the stored
+// post has to be updated, which also rewrites posts saved before a sanitizer change
+func itemContentHash(item RSSItem, description string, content string, categories []string) string {
+	hash := sha256.New()
+	for _, part := range []string{item.Title, item.Link, description, item.author(), content, strings.Join(categories, ",")} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// postSummary is the plain text excerpt of a post, taken from the full
+// content when the description is empty
+func postSummary(description string, content string) string {
+	if strings.TrimSpace(description) == "" {
+		return summarize(content, summaryLength)
+	}
+	return summarize(description, summaryLength)
+}
+
+// postBatch turns the items of a feed into the parallel arrays taken by
+// UpsertPosts, with their HTML sanitized. Items without a date or identity are skipped, and only the
+// first of several items sharing a key is kept since one statement can't
+// update the same row twice.
+func postBatch(feedID uuid.UUID, items []RSSItem) (database.UpsertPostsParams, map[string]RSSItem) {
+	batch := database.UpsertPostsParams{FeedID: feedID}
+	byKey := map[string]RSSItem{}
+	for _, item := range items {
+		key := itemKey(item)
+		if key == "" {
+			continue
+		}
+		if _, ok := byKey[key]; ok {
+			continue
+		}
+		publishedAt, err := parsePubDate(item.PubDate)
+		if err != nil {
+			log.Printf("Error parsing date: %v", err)
+			continue
+		}
+		categories := itemCategories(item)
+		encodedCategories, err := json.Marshal(categories)
+		if err != nil {
+			continue
+		}
+		byKey[key] = item
+
+		description := sanitizeHTML(item.Description, item.Link)
+		content := sanitizeHTML(item.Content, item.Link)
+		batch.Ids = append(batch.Ids, uuid.New())
+		batch.Titles = append(batch.Titles, item.Title)
+		batch.Descriptions = append(batch.Descriptions, description)
+		batch.PublishedAt = append(batch.PublishedAt, publishedAt.UTC())
+		batch.Urls = append(batch.Urls, item.Link)
+		batch.Guids = append(batch.Guids, strings.TrimSpace(item.GUID))
+		batch.Authors = append(batch.Authors, strings.TrimSpace(item.author()))
+		batch.Categories = append(batch.Categories, string(encodedCategories))
+		batch.Contents = append(batch.Contents, content)
+		batch.ItemKeys = append(batch.ItemKeys, key)
+		batch.ContentHashes = append(batch.ContentHashes, itemContentHash(item, description, content, categories))
+		batch.Summaries = append(batch.Summaries, postSummary(description, item.Content))
+	}
+	return batch, byKey
+}
+
+// enclosureBatch collects the enclosures of the posts that were just saved
+func enclosureBatch(saved []database.UpsertPostsRow, byKey map[string]RSSItem) database.CreatePostEnclosuresParams {
+	batch := database.CreatePostEnclosuresParams{}
+	for _, post := range saved {
+		for _, enclosure := range byKey[post.ItemKey].Enclosures {
+			url := strings.TrimSpace(enclosure.URL)
+			if url == "" {
+				continue
+			}
+			length, err := strconv.ParseInt(strings.TrimSpace(enclosure.Length), 10, 64)
+			if err != nil || length < 0 {
+				length = 0
+			}
+			batch.Ids = append(batch.Ids, uuid.New())
+			batch.PostIds = append(batch.PostIds, post.ID)
+			batch.Urls = append(batch.Urls, url)
+			batch.Types = append(batch.Types, strings.TrimSpace(enclosure.Type))
+			batch.Lengths = append(batch.Lengths, length)
+		}
+	}
+	return batch
+}
+
+// savePosts upserts the items of a feed and their enclosures, the enclosures
+// of updated posts are replaced by the ones the items list now
+func savePosts(ctx context.Context, qtx *database.Queries, feedID uuid.UUID, items []RSSItem) (ingestResult, error) {
+	result := ingestResult{ItemsSeen: len(items)}
+	posts, byKey := postBatch(feedID, items)
+	if len(posts.Ids) == 0 {
+		return result, nil
+	}
+	saved, err := qtx.UpsertPosts(ctx, posts)
+	if err != nil {
+		return result, err
+	}
+	updated := []uuid.UUID{}
+	for _, post := range saved {
+		if post.Revision == 1 {
+			result.NewPosts++
+		} else {
+			result.UpdatedPosts++
+			updated = append(updated, post.ID)
+		}
+	}
+	enclosures := enclosureBatch(saved, byKey)
+	if len(updated) > 0 {
+		// Enclosures the publisher removed from an edited item go as well
+		err = qtx.DeleteStalePostEnclosures(ctx, database.DeleteStalePostEnclosuresParams{
+			PostIds:     updated,
+			KeptPostIds: enclosures.PostIds,
+			KeptUrls:    enclosures.Urls,
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+	if len(enclosures.Ids) > 0 {
+		err = qtx.CreatePostEnclosures(ctx, enclosures)
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// ingestFeed saves a fetched document in one transaction: the posts and their
+// enclosures, the channel metadata, the polling interval and the feed's last_fetch.
+// Nothing is saved when the feed was fetched under a claim that was lost
+func ingestFeed(ctx context.Context, conn *sql.DB, feed database.Feed, rssFeed RSSFeed) (ingestResult, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return ingestResult{ItemsSeen: len(rssFeed.Channel.Item)}, err
+	}
+	defer tx.Rollback()
+	qtx := database.New(conn).WithTx(tx)
+
+	result, err := savePosts(ctx, qtx, feed.ID, rssFeed.Channel.Item)
+	if err != nil {
+		return result, err
+	}
+	feed, err = qtx.UpdateFeedMetadata(ctx, feedMetadataParams(feed.ID, rssFeed))
+	if err != nil {
+		return result, err
+	}
+	feed.FetchInterval = int32(computeFetchInterval(rssFeed) / time.Second)
+	err = qtx.UpdateFeedFetchInterval(ctx, database.UpdateFeedFetchIntervalParams{
+		ID:            feed.ID,
+		FetchInterval: feed.FetchInterval,
+	})
+	if err != nil {
+		return result, err
+	}
+	_, err = qtx.MarkFeedAsFetched(ctx, feedFetchedParams(feed))
+	if errors.Is(err, sql.ErrNoRows) {
+		// The worker that claimed the feed since saves the posts instead
+		return result, errFeedClaimLost
+	}
+	if err != nil {
+		return result, err
+	}
+	return result, tx.Commit()
+}
+
+// ingestPushedItems saves the items a hub pushed. A push usually carries only
+// the new entries, so the channel metadata and the polling schedule are left
+// to the next fetch
+func ingestPushedItems(ctx context.Context, conn *sql.DB, feedID uuid.UUID, rssFeed RSSFeed) (ingestResult, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return ingestResult{ItemsSeen: len(rssFeed.Channel.Item)}, err
+	}
+	defer tx.Rollback()
+	qtx := database.New(conn).WithTx(tx)
+
+	result, err := savePosts(ctx, qtx, feedID, rssFeed.Channel.Item)
+	if err != nil {
+		return result, err
+	}
+	return result, tx.Commit()
+}
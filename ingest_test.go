@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"project_1/internal/database"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestPostBatchHashesSanitizedContent(t *testing.T) {
+	item := RSSItem{
+		Title:       "Title",
+		Link:        "https://example.com/post/1",
+		Description: `<p>Hello</p>`,
+		PubDate:     "Mon, 02 Jan 2006 15:04:05 GMT",
+	}
+	hash := func(description string) string {
+		item := item
+		item.Description = description
+		batch, _ := postBatch(uuid.New(), []RSSItem{item})
+		if len(batch.ContentHashes) != 1 {
+			t.Fatalf("postBatch kept %v items, want 1", len(batch.ContentHashes))
+		}
+		return batch.ContentHashes[0]
+	}
+
+	clean := hash(`<p>Hello</p>`)
+	// What the sanitizer removes doesn't make a new revision
+	if got := hash(`<p onclick="alert(1)">Hello</p><script>alert(1)</script>`); got != clean {
+		t.Errorf("hash changed by markup the sanitizer removes")
+	}
+	if got := hash(`<p>Hello again</p>`); got == clean {
+		t.Errorf("hash unchanged by an edited description")
+	}
+}
+
+// testItem is a dated item, identified by its GUID when it has one
+func testItem(guid string, link string, title string) RSSItem {
+	return RSSItem{
+		Title:   title,
+		Link:    link,
+		GUID:    guid,
+		PubDate: "Mon, 02 Jan 2006 15:04:05 GMT",
+	}
+}
+
+// storedPost is what a test checks of a saved post
+type storedPost struct {
+	ID        uuid.UUID
+	Title     string
+	URL       string
+	Revision  int32
+	UpdatedAt time.Time
+}
+
+// feedPosts returns the posts of a feed by item key
+func feedPosts(t *testing.T, tx *sql.Tx, feedID uuid.UUID) map[string]storedPost {
+	t.Helper()
+	rows, err := tx.Query("SELECT id, title, url, revision, updated_at, item_key FROM posts WHERE feed_id = $1", feedID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	posts := map[string]storedPost{}
+	for rows.Next() {
+		var post storedPost
+		var key string
+		err := rows.Scan(&post.ID, &post.Title, &post.URL, &post.Revision, &post.UpdatedAt, &key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		posts[key] = post
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return posts
+}
+
+// save runs savePosts and fails the test on an error
+func save(t *testing.T, qtx *database.Queries, feedID uuid.UUID, items ...RSSItem) ingestResult {
+	t.Helper()
+	result, err := savePosts(context.Background(), qtx, feedID, items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestSavePostsIdentifiesItemsByGUIDThenURL(t *testing.T) {
+	tx, qtx := testTx(t)
+	feed := createTestFeed(t, qtx, "Identity")
+
+	save(t, qtx, feed.ID,
+		testItem("guid-1", "https://example.com/1", "With a GUID"),
+		testItem("", "https://example.com/2", "Without a GUID"),
+	)
+	before := feedPosts(t, tx, feed.ID)
+	if len(before) != 2 {
+		t.Fatalf("saved %v posts, want 2", len(before))
+	}
+
+	// The GUID keeps the post when its link moves, the link is the identity otherwise
+	result := save(t, qtx, feed.ID,
+		testItem("guid-1", "https://example.com/moved", "With a GUID"),
+		testItem("", "https://example.com/2", "Without a GUID, edited"),
+	)
+	if result.NewPosts != 0 || result.UpdatedPosts != 2 {
+		t.Errorf("%v new and %v updated posts, want 0 and 2", result.NewPosts, result.UpdatedPosts)
+	}
+	after := feedPosts(t, tx, feed.ID)
+	if len(after) != 2 {
+		t.Fatalf("%v posts after the update, want 2", len(after))
+	}
+	if post := after["guid-1"]; post.ID != before["guid-1"].ID || post.URL != "https://example.com/moved" {
+		t.Errorf("GUID item saved as %+v, want %v at the new link", post, before["guid-1"].ID)
+	}
+	if post := after["https://example.com/2"]; post.ID != before["https://example.com/2"].ID || post.Title != "Without a GUID, edited" {
+		t.Errorf("link item saved as %+v, want %v with the new title", post, before["https://example.com/2"].ID)
+	}
+}
+
+func TestSavePostsSameURLInTwoFeeds(t *testing.T) {
+	tx, qtx := testTx(t)
+	first := createTestFeed(t, qtx, "First")
+	second := createTestFeed(t, qtx, "Second")
+	item := testItem("", "https://example.com/shared", "Shared")
+
+	for _, feed := range []database.Feed{first, second} {
+		if result := save(t, qtx, feed.ID, item); result.NewPosts != 1 {
+			t.Errorf("%v new posts in %v, want 1", result.NewPosts, feed.Name)
+		}
+	}
+	if a, b := feedPosts(t, tx, first.ID)[item.Link], feedPosts(t, tx, second.ID)[item.Link]; a.ID == uuid.Nil || b.ID == uuid.Nil || a.ID == b.ID {
+		t.Errorf("posts %v and %v, want one per feed", a.ID, b.ID)
+	}
+}
+
+func TestSavePostsKeepsUnchangedPosts(t *testing.T) {
+	tx, qtx := testTx(t)
+	feed := createTestFeed(t, qtx, "Unchanged")
+	item := testItem("guid-1", "https://example.com/1", "Title")
+
+	save(t, qtx, feed.ID, item)
+	// NOW() is the same for the whole transaction, the post is aged instead
+	_, err := tx.Exec("UPDATE posts SET updated_at = updated_at - interval '1 hour' WHERE feed_id = $1", feed.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := feedPosts(t, tx, feed.ID)["guid-1"]
+
+	result := save(t, qtx, feed.ID, item)
+	if result.NewPosts != 0 || result.UpdatedPosts != 0 {
+		t.Errorf("%v new and %v updated posts, want none", result.NewPosts, result.UpdatedPosts)
+	}
+	after := feedPosts(t, tx, feed.ID)["guid-1"]
+	if after.Revision != 1 || !after.UpdatedAt.Equal(before.UpdatedAt) {
+		t.Errorf("revision %v updated at %v, want 1 and %v", after.Revision, after.UpdatedAt, before.UpdatedAt)
+	}
+}
+
+func TestSavePostsBumpsRevision(t *testing.T) {
+	tx, qtx := testTx(t)
+	feed := createTestFeed(t, qtx, "Edited")
+
+	save(t, qtx, feed.ID, testItem("guid-1", "https://example.com/1", "Title"))
+	_, err := tx.Exec("UPDATE posts SET updated_at = updated_at - interval '1 hour' WHERE feed_id = $1", feed.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := feedPosts(t, tx, feed.ID)["guid-1"]
+
+	result := save(t, qtx, feed.ID, testItem("guid-1", "https://example.com/1", "Edited title"))
+	if result.NewPosts != 0 || result.UpdatedPosts != 1 {
+		t.Errorf("%v new and %v updated posts, want 0 and 1", result.NewPosts, result.UpdatedPosts)
+	}
+	after := feedPosts(t, tx, feed.ID)["guid-1"]
+	if after.Revision != 2 || !after.UpdatedAt.After(before.UpdatedAt) || after.Title != "Edited title" {
+		t.Errorf("revision %v updated at %v with title %q, want 2 after %v", after.Revision, after.UpdatedAt, after.Title, before.UpdatedAt)
+	}
+}
+
+// postEnclosureURLs returns the enclosure URLs saved for a post
+func postEnclosureURLs(t *testing.T, qtx *database.Queries, postID uuid.UUID) []string {
+	t.Helper()
+	enclosures, err := qtx.GetPostEnclosures(context.Background(), []uuid.UUID{postID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	urls := []string{}
+	for _, enclosure := range enclosures {
+		urls = append(urls, enclosure.Url)
+	}
+	return urls
+}
+
+func TestSavePostsBatch(t *testing.T) {
+	tx, qtx := testTx(t)
+	feed := createTestFeed(t, qtx, "Batch")
+	item := testItem("guid-1", "https://example.com/1", "First")
+	item.Enclosures = []RSSEnclosure{
+		{URL: "https://example.com/1.mp3", Type: "audio/mpeg"},
+		{URL: "https://example.com/1.mp3", Type: "audio/mpeg"},
+		{URL: "https://example.com/1.jpg", Type: "image/jpeg"},
+	}
+
+	// One statement can't touch a row twice, the first item of a key wins and
+	// a repeated enclosure is saved once
+	result := save(t, qtx, feed.ID, item, testItem("guid-1", "https://example.com/1", "Second"))
+	if result.NewPosts != 1 {
+		t.Errorf("%v new posts, want 1", result.NewPosts)
+	}
+	post := feedPosts(t, tx, feed.ID)["guid-1"]
+	if post.Title != "First" {
+		t.Errorf("saved %q, want the first item", post.Title)
+	}
+	if urls := postEnclosureURLs(t, qtx, post.ID); len(urls) != 2 {
+		t.Errorf("saved enclosures %v, want 2", urls)
+	}
+
+	// An edited item keeps the enclosures it still lists and drops the others
+	item.Title = "Edited"
+	item.Enclosures = item.Enclosures[:1]
+	save(t, qtx, feed.ID, item)
+	if urls := postEnclosureURLs(t, qtx, post.ID); len(urls) != 1 || urls[0] != "https://example.com/1.mp3" {
+		t.Errorf("enclosures %v after the edit, want only the mp3", urls)
+	}
+}
+
+func TestIngestFeedRollsBackOnEnclosureError(t *testing.T) {
+	conn := testConn(t)
+	feed := createTestFeed(t, database.New(conn), "Rollback")
+	t.Cleanup(func() { conn.Exec("DELETE FROM users WHERE id = $1", feed.UserID) })
+
+	broken := testItem("guid-2", "https://example.com/2", "Broken")
+	// Postgres refuses NUL bytes in text, after the posts were inserted
+	broken.Enclosures = []RSSEnclosure{{URL: "https://example.com/\x00.mp3"}}
+	rssFeed := RSSFeed{}
+	rssFeed.Channel.Item = []RSSItem{testItem("guid-1", "https://example.com/1", "Fine"), broken}
+
+	_, err := ingestFeed(context.Background(), conn, feed, rssFeed)
+	if err == nil {
+		t.Fatal("ingested an enclosure with a NUL byte")
+	}
+	var count int
+	err = conn.QueryRow("SELECT COUNT(*) FROM posts WHERE feed_id = $1", feed.ID).Scan(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("%v posts committed by a failed fetch, want 0", count)
+	}
+}
@@ -7,21 +7,148 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const canReadFeed = `-- name: CanReadFeed :one
+SELECT COALESCE(feed_readable($1::uuid, $2::uuid), FALSE)::bool AS readable
+`
+
+type CanReadFeedParams struct {
+	FeedID uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) CanReadFeed(ctx context.Context, arg CanReadFeedParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, canReadFeed, arg.FeedID, arg.UserID)
+	var readable bool
+	err := row.Scan(&readable)
+	return readable, err
+}
+
+const claimNextFeedsToFetch = `-- name: ClaimNextFeedsToFetch :many
+UPDATE feeds
+SET claimed_by = $1::text,
+    claim_expires_at = NOW() + make_interval(secs => $2::int)
+WHERE id IN (
+    SELECT id FROM feeds
+    WHERE deleted_at IS NULL
+    AND (claim_expires_at IS NULL OR claim_expires_at < NOW())
+    AND (next_fetch_at IS NULL OR next_fetch_at <= NOW())
+    ORDER BY next_fetch_at ASC NULLS FIRST
+    LIMIT $3::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetch, site_url, description, language, image_url, ttl, skip_hours, claimed_by, claim_expires_at, fetch_interval, fetch_interval_override, next_fetch_at, visibility, deleted_at
+`
+
+type ClaimNextFeedsToFetchParams struct {
+	WorkerID     string
+	LeaseSeconds int32
+	MaxFeeds     int32
+}
+
+func (q *Queries) ClaimNextFeedsToFetch(ctx context.Context, arg ClaimNextFeedsToFetchParams) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, claimNextFeedsToFetch, arg.WorkerID, arg.LeaseSeconds, arg.MaxFeeds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetch,
+			&i.SiteUrl,
+			&i.Description,
+			&i.Language,
+			&i.ImageUrl,
+			&i.Ttl,
+			pq.Array(&i.SkipHours),
+			&i.ClaimedBy,
+			&i.ClaimExpiresAt,
+			&i.FetchInterval,
+			&i.FetchIntervalOverride,
+			&i.NextFetchAt,
+			&i.Visibility,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countFeeds = `-- name: CountFeeds :one
+SELECT COUNT(*) FROM feeds
+WHERE feeds.deleted_at IS NULL
+    AND ($1::text = ''
+        OR feeds.name ILIKE '%' || $1 || '%'
+        OR feeds.url ILIKE '%' || $1 || '%'
+        OR feeds.description ILIKE '%' || $1 || '%')
+    AND feed_readable(feeds.id, $2)
+    AND (feeds.visibility <> 'unlisted' OR feeds.user_id = $2
+        OR EXISTS(SELECT 1 FROM feed_follow WHERE feed_follow.feed_id = feeds.id AND feed_follow.deleted_at IS NULL AND feed_follow.user_id = $2))
+    AND (NOT $3::bool OR feeds.user_id = $2)
+    AND ($4::uuid IS NULL OR feeds.user_id = $4)
+    AND (NOT $5::bool OR EXISTS(
+        SELECT 1 FROM feed_follow WHERE feed_follow.feed_id = feeds.id AND feed_follow.deleted_at IS NULL AND feed_follow.user_id = $2))
+    AND ($6::text = ''
+        OR lower(feeds.language) = lower($6)
+        OR lower(feeds.language) LIKE lower($6) || '-%')
+`
+
+type CountFeedsParams struct {
+	Q        string
+	UserID   uuid.UUID
+	Mine     bool
+	OwnerID  uuid.NullUUID
+	Followed bool
+	Language string
+}
+
+func (q *Queries) CountFeeds(ctx context.Context, arg CountFeedsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countFeeds,
+		arg.Q,
+		arg.UserID,
+		arg.Mine,
+		arg.OwnerID,
+		arg.Followed,
+		arg.Language,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createFeed = `-- name: CreateFeed :one
-INSERT INTO feeds (id, name, url, user_id) 
-VALUES ($1, $2, $3, $4) 
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetch
+INSERT INTO feeds (id, name, url, user_id, visibility) 
+VALUES ($1, $2, $3, $4, $5) 
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetch, site_url, description, language, image_url, ttl, skip_hours, claimed_by, claim_expires_at, fetch_interval, fetch_interval_override, next_fetch_at, visibility, deleted_at
 `
 
 type CreateFeedParams struct {
-	ID     uuid.UUID
-	Name   string
-	Url    string
-	UserID uuid.UUID
+	ID         uuid.UUID
+	Name       string
+	Url        string
+	UserID     uuid.UUID
+	Visibility string
 }
 
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
@@ -30,6 +157,7 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		arg.Name,
 		arg.Url,
 		arg.UserID,
+		arg.Visibility,
 	)
 	var i Feed
 	err := row.Scan(
@@ -40,12 +168,25 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetch,
+		&i.SiteUrl,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
+		&i.Ttl,
+		pq.Array(&i.SkipHours),
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.FetchInterval,
+		&i.FetchIntervalOverride,
+		&i.NextFetchAt,
+		&i.Visibility,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const deleteFeed = `-- name: DeleteFeed :exec
-DELETE FROM feeds WHERE id = $1 AND user_id = $2
+UPDATE feeds SET deleted_at = NOW() WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
 `
 
 type DeleteFeedParams struct {
@@ -58,43 +199,47 @@ func (q *Queries) DeleteFeed(ctx context.Context, arg DeleteFeedParams) error {
 	return err
 }
 
-const getAllFeeds = `-- name: GetAllFeeds :many
-SELECT id, created_at, updated_at, name, url, user_id, last_fetch FROM feeds
+const getDeletedFeed = `-- name: GetDeletedFeed :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetch, site_url, description, language, image_url, ttl, skip_hours, claimed_by, claim_expires_at, fetch_interval, fetch_interval_override, next_fetch_at, visibility, deleted_at FROM feeds
+WHERE id = $1
+    AND deleted_at > NOW() - make_interval(secs => $2::int)
 `
 
-func (q *Queries) GetAllFeeds(ctx context.Context) ([]Feed, error) {
-	rows, err := q.db.QueryContext(ctx, getAllFeeds)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Feed
-	for rows.Next() {
-		var i Feed
-		if err := rows.Scan(
-			&i.ID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.Name,
-			&i.Url,
-			&i.UserID,
-			&i.LastFetch,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+type GetDeletedFeedParams struct {
+	ID           uuid.UUID
+	GraceSeconds int32
+}
+
+func (q *Queries) GetDeletedFeed(ctx context.Context, arg GetDeletedFeedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getDeletedFeed, arg.ID, arg.GraceSeconds)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetch,
+		&i.SiteUrl,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
+		&i.Ttl,
+		pq.Array(&i.SkipHours),
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.FetchInterval,
+		&i.FetchIntervalOverride,
+		&i.NextFetchAt,
+		&i.Visibility,
+		&i.DeletedAt,
+	)
+	return i, err
 }
 
 const getFeed = `-- name: GetFeed :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetch FROM feeds WHERE id = $1
+SELECT id, created_at, updated_at, name, url, user_id, last_fetch, site_url, description, language, image_url, ttl, skip_hours, claimed_by, claim_expires_at, fetch_interval, fetch_interval_override, next_fetch_at, visibility, deleted_at FROM feeds WHERE id = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetFeed(ctx context.Context, id uuid.UUID) (Feed, error) {
@@ -108,33 +253,212 @@ func (q *Queries) GetFeed(ctx context.Context, id uuid.UUID) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetch,
+		&i.SiteUrl,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
+		&i.Ttl,
+		pq.Array(&i.SkipHours),
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.FetchInterval,
+		&i.FetchIntervalOverride,
+		&i.NextFetchAt,
+		&i.Visibility,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
-const getNextFeedsToFetch = `-- name: GetNextFeedsToFetch :many
-SELECT id, created_at, updated_at, name, url, user_id, last_fetch FROM feeds 
-ORDER BY last_fetch ASC NULLS FIRST
-LIMIT $1
+const getFeedByURL = `-- name: GetFeedByURL :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetch, site_url, description, language, image_url, ttl, skip_hours, claimed_by, claim_expires_at, fetch_interval, fetch_interval_override, next_fetch_at, visibility, deleted_at FROM feeds WHERE url = $1 AND deleted_at IS NULL
 `
 
-func (q *Queries) GetNextFeedsToFetch(ctx context.Context, limit int64) ([]Feed, error) {
-	rows, err := q.db.QueryContext(ctx, getNextFeedsToFetch, limit)
+func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByURL, url)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetch,
+		&i.SiteUrl,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
+		&i.Ttl,
+		pq.Array(&i.SkipHours),
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.FetchInterval,
+		&i.FetchIntervalOverride,
+		&i.NextFetchAt,
+		&i.Visibility,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getFeedDetail = `-- name: GetFeedDetail :one
+SELECT feeds.id, feeds.created_at, feeds.updated_at, feeds.name, feeds.url, feeds.user_id, feeds.last_fetch, feeds.site_url, feeds.description, feeds.language, feeds.image_url, feeds.ttl, feeds.skip_hours, feeds.claimed_by, feeds.claim_expires_at, feeds.fetch_interval, feeds.fetch_interval_override, feeds.next_fetch_at, feeds.visibility, feeds.deleted_at, users.name AS owner_name,
+    (SELECT COUNT(*) FROM feed_follow WHERE feed_follow.feed_id = feeds.id AND feed_follow.deleted_at IS NULL)::bigint AS follower_count,
+    EXISTS(SELECT 1 FROM feed_follow WHERE feed_follow.feed_id = feeds.id AND feed_follow.deleted_at IS NULL AND feed_follow.user_id = $1) AS followed,
+    COUNT(posts.id) AS post_count, MAX(posts.published_at) AS last_post_at
+FROM feeds
+JOIN users ON users.id = feeds.user_id
+LEFT JOIN posts ON posts.feed_id = feeds.id AND (NOT posts.manual OR posts.published_at <= NOW())
+WHERE feeds.id = $2 AND feed_readable(feeds.id, $1)
+GROUP BY feeds.id, users.id
+`
+
+type GetFeedDetailParams struct {
+	UserID uuid.UUID
+	ID     uuid.UUID
+}
+
+type GetFeedDetailRow struct {
+	Feed          Feed
+	OwnerName     string
+	FollowerCount int64
+	Followed      bool
+	PostCount     int64
+	LastPostAt    sql.NullTime
+}
+
+func (q *Queries) GetFeedDetail(ctx context.Context, arg GetFeedDetailParams) (GetFeedDetailRow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedDetail, arg.UserID, arg.ID)
+	var i GetFeedDetailRow
+	err := row.Scan(
+		&i.Feed.ID,
+		&i.Feed.CreatedAt,
+		&i.Feed.UpdatedAt,
+		&i.Feed.Name,
+		&i.Feed.Url,
+		&i.Feed.UserID,
+		&i.Feed.LastFetch,
+		&i.Feed.SiteUrl,
+		&i.Feed.Description,
+		&i.Feed.Language,
+		&i.Feed.ImageUrl,
+		&i.Feed.Ttl,
+		pq.Array(&i.Feed.SkipHours),
+		&i.Feed.ClaimedBy,
+		&i.Feed.ClaimExpiresAt,
+		&i.Feed.FetchInterval,
+		&i.Feed.FetchIntervalOverride,
+		&i.Feed.NextFetchAt,
+		&i.Feed.Visibility,
+		&i.Feed.DeletedAt,
+		&i.OwnerName,
+		&i.FollowerCount,
+		&i.Followed,
+		&i.PostCount,
+		&i.LastPostAt,
+	)
+	return i, err
+}
+
+const listFeeds = `-- name: ListFeeds :many
+SELECT feeds.id, feeds.created_at, feeds.updated_at, feeds.name, feeds.url, feeds.user_id, feeds.last_fetch, feeds.site_url, feeds.description, feeds.language, feeds.image_url, feeds.ttl, feeds.skip_hours, feeds.claimed_by, feeds.claim_expires_at, feeds.fetch_interval, feeds.fetch_interval_override, feeds.next_fetch_at, feeds.visibility, feeds.deleted_at,
+    (SELECT COUNT(*) FROM feed_follow WHERE feed_follow.feed_id = feeds.id AND feed_follow.deleted_at IS NULL)::bigint AS follower_count,
+    (SELECT MAX(posts.published_at) FROM posts
+        WHERE posts.feed_id = feeds.id AND (NOT posts.manual OR posts.published_at <= NOW())) AS last_post_at
+FROM feeds
+WHERE feeds.deleted_at IS NULL
+    AND ($1::text = ''
+        OR feeds.name ILIKE '%' || $1 || '%'
+        OR feeds.url ILIKE '%' || $1 || '%'
+        OR feeds.description ILIKE '%' || $1 || '%')
+    AND feed_readable(feeds.id, $2)
+    AND (feeds.visibility <> 'unlisted' OR feeds.user_id = $2
+        OR EXISTS(SELECT 1 FROM feed_follow WHERE feed_follow.feed_id = feeds.id AND feed_follow.deleted_at IS NULL AND feed_follow.user_id = $2))
+    AND (NOT $3::bool OR feeds.user_id = $2)
+    AND ($4::uuid IS NULL OR feeds.user_id = $4)
+    AND (NOT $5::bool OR EXISTS(
+        SELECT 1 FROM feed_follow WHERE feed_follow.feed_id = feeds.id AND feed_follow.deleted_at IS NULL AND feed_follow.user_id = $2))
+    AND ($6::text = ''
+        OR lower(feeds.language) = lower($6)
+        OR lower(feeds.language) LIKE lower($6) || '-%')
+ORDER BY
+    CASE WHEN $7::text = 'name' AND NOT $8::bool THEN lower(feeds.name) END ASC,
+    CASE WHEN $7 = 'name' AND $8 THEN lower(feeds.name) END DESC,
+    CASE WHEN $7 = 'created' AND NOT $8 THEN feeds.created_at END ASC,
+    CASE WHEN $7 = 'created' AND $8 THEN feeds.created_at END DESC,
+    CASE WHEN $7 = 'followers' AND NOT $8 THEN (SELECT COUNT(*) FROM feed_follow WHERE feed_follow.feed_id = feeds.id AND feed_follow.deleted_at IS NULL) END ASC,
+    CASE WHEN $7 = 'followers' AND $8 THEN (SELECT COUNT(*) FROM feed_follow WHERE feed_follow.feed_id = feeds.id AND feed_follow.deleted_at IS NULL) END DESC,
+    CASE WHEN $7 = 'activity' AND NOT $8 THEN (SELECT MAX(posts.published_at) FROM posts
+        WHERE posts.feed_id = feeds.id AND (NOT posts.manual OR posts.published_at <= NOW())) END ASC NULLS FIRST,
+    CASE WHEN $7 = 'activity' AND $8 THEN (SELECT MAX(posts.published_at) FROM posts
+        WHERE posts.feed_id = feeds.id AND (NOT posts.manual OR posts.published_at <= NOW())) END DESC NULLS LAST,
+    lower(feeds.name), feeds.id
+LIMIT $9 OFFSET $10
+`
+
+type ListFeedsParams struct {
+	Q          string
+	UserID     uuid.UUID
+	Mine       bool
+	OwnerID    uuid.NullUUID
+	Followed   bool
+	Language   string
+	Sort       string
+	Descending bool
+	Limit      int64
+	Offset     int64
+}
+
+type ListFeedsRow struct {
+	Feed          Feed
+	FollowerCount int64
+	LastPostAt    sql.NullTime
+}
+
+func (q *Queries) ListFeeds(ctx context.Context, arg ListFeedsParams) ([]ListFeedsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFeeds,
+		arg.Q,
+		arg.UserID,
+		arg.Mine,
+		arg.OwnerID,
+		arg.Followed,
+		arg.Language,
+		arg.Sort,
+		arg.Descending,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Feed
+	var items []ListFeedsRow
 	for rows.Next() {
-		var i Feed
+		var i ListFeedsRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.Name,
-			&i.Url,
-			&i.UserID,
-			&i.LastFetch,
+			&i.Feed.ID,
+			&i.Feed.CreatedAt,
+			&i.Feed.UpdatedAt,
+			&i.Feed.Name,
+			&i.Feed.Url,
+			&i.Feed.UserID,
+			&i.Feed.LastFetch,
+			&i.Feed.SiteUrl,
+			&i.Feed.Description,
+			&i.Feed.Language,
+			&i.Feed.ImageUrl,
+			&i.Feed.Ttl,
+			pq.Array(&i.Feed.SkipHours),
+			&i.Feed.ClaimedBy,
+			&i.Feed.ClaimExpiresAt,
+			&i.Feed.FetchInterval,
+			&i.Feed.FetchIntervalOverride,
+			&i.Feed.NextFetchAt,
+			&i.Feed.Visibility,
+			&i.Feed.DeletedAt,
+			&i.FollowerCount,
+			&i.LastPostAt,
 		); err != nil {
 			return nil, err
 		}
@@ -150,14 +474,146 @@ func (q *Queries) GetNextFeedsToFetch(ctx context.Context, limit int64) ([]Feed,
 }
 
 const markFeedAsFetched = `-- name: MarkFeedAsFetched :one
-UPDATE feeds 
-SET last_fetch = NOW(), updated_at = NOW() 
+UPDATE feeds
+SET last_fetch = NOW(), updated_at = NOW(), next_fetch_at = NOW() + make_interval(secs => $1::int),
+    claimed_by = CASE WHEN $2::text IS NULL THEN claimed_by END,
+    claim_expires_at = CASE WHEN $2::text IS NULL THEN claim_expires_at END
+WHERE id = $3 AND ($2::text IS NULL OR claimed_by = $2)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetch, site_url, description, language, image_url, ttl, skip_hours, claimed_by, claim_expires_at, fetch_interval, fetch_interval_override, next_fetch_at, visibility, deleted_at
+`
+
+type MarkFeedAsFetchedParams struct {
+	FetchDelaySeconds int32
+	WorkerID          sql.NullString
+	ID                uuid.UUID
+}
+
+func (q *Queries) MarkFeedAsFetched(ctx context.Context, arg MarkFeedAsFetchedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, markFeedAsFetched, arg.FetchDelaySeconds, arg.WorkerID, arg.ID)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetch,
+		&i.SiteUrl,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
+		&i.Ttl,
+		pq.Array(&i.SkipHours),
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.FetchInterval,
+		&i.FetchIntervalOverride,
+		&i.NextFetchAt,
+		&i.Visibility,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const purgeDeletedFeeds = `-- name: PurgeDeletedFeeds :execrows
+DELETE FROM feeds
+WHERE deleted_at < NOW() - make_interval(secs => $1::int)
+`
+
+func (q *Queries) PurgeDeletedFeeds(ctx context.Context, graceSeconds int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedFeeds, graceSeconds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreFeed = `-- name: RestoreFeed :one
+UPDATE feeds SET deleted_at = NULL, updated_at = NOW() WHERE id = $1 RETURNING id, created_at, updated_at, name, url, user_id, last_fetch, site_url, description, language, image_url, ttl, skip_hours, claimed_by, claim_expires_at, fetch_interval, fetch_interval_override, next_fetch_at, visibility, deleted_at
+`
+
+func (q *Queries) RestoreFeed(ctx context.Context, id uuid.UUID) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, restoreFeed, id)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetch,
+		&i.SiteUrl,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
+		&i.Ttl,
+		pq.Array(&i.SkipHours),
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.FetchInterval,
+		&i.FetchIntervalOverride,
+		&i.NextFetchAt,
+		&i.Visibility,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const setFeedFetchIntervalOverride = `-- name: SetFeedFetchIntervalOverride :one
+UPDATE feeds
+SET fetch_interval_override = $2,
+    next_fetch_at = COALESCE(last_fetch, NOW()) + make_interval(secs => COALESCE($2, fetch_interval)),
+    updated_at = NOW()
 WHERE id = $1
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetch
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetch, site_url, description, language, image_url, ttl, skip_hours, claimed_by, claim_expires_at, fetch_interval, fetch_interval_override, next_fetch_at, visibility, deleted_at
+`
+
+type SetFeedFetchIntervalOverrideParams struct {
+	ID                    uuid.UUID
+	FetchIntervalOverride sql.NullInt32
+}
+
+func (q *Queries) SetFeedFetchIntervalOverride(ctx context.Context, arg SetFeedFetchIntervalOverrideParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, setFeedFetchIntervalOverride, arg.ID, arg.FetchIntervalOverride)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetch,
+		&i.SiteUrl,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
+		&i.Ttl,
+		pq.Array(&i.SkipHours),
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.FetchInterval,
+		&i.FetchIntervalOverride,
+		&i.NextFetchAt,
+		&i.Visibility,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const setFeedVisibility = `-- name: SetFeedVisibility :one
+UPDATE feeds SET visibility = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL RETURNING id, created_at, updated_at, name, url, user_id, last_fetch, site_url, description, language, image_url, ttl, skip_hours, claimed_by, claim_expires_at, fetch_interval, fetch_interval_override, next_fetch_at, visibility, deleted_at
 `
 
-func (q *Queries) MarkFeedAsFetched(ctx context.Context, id uuid.UUID) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, markFeedAsFetched, id)
+type SetFeedVisibilityParams struct {
+	ID         uuid.UUID
+	Visibility string
+}
+
+func (q *Queries) SetFeedVisibility(ctx context.Context, arg SetFeedVisibilityParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, setFeedVisibility, arg.ID, arg.Visibility)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -167,12 +623,25 @@ func (q *Queries) MarkFeedAsFetched(ctx context.Context, id uuid.UUID) (Feed, er
 		&i.Url,
 		&i.UserID,
 		&i.LastFetch,
+		&i.SiteUrl,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
+		&i.Ttl,
+		pq.Array(&i.SkipHours),
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.FetchInterval,
+		&i.FetchIntervalOverride,
+		&i.NextFetchAt,
+		&i.Visibility,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const updateFeed = `-- name: UpdateFeed :one
-UPDATE feeds SET name = $2, url = $3 WHERE user_id = $1 AND id = $4 RETURNING id, created_at, updated_at, name, url, user_id, last_fetch
+UPDATE feeds SET name = $2, url = $3 WHERE user_id = $1 AND id = $4 AND deleted_at IS NULL RETURNING id, created_at, updated_at, name, url, user_id, last_fetch, site_url, description, language, image_url, ttl, skip_hours, claimed_by, claim_expires_at, fetch_interval, fetch_interval_override, next_fetch_at, visibility, deleted_at
 `
 
 type UpdateFeedParams struct {
@@ -198,6 +667,86 @@ func (q *Queries) UpdateFeed(ctx context.Context, arg UpdateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetch,
+		&i.SiteUrl,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
+		&i.Ttl,
+		pq.Array(&i.SkipHours),
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.FetchInterval,
+		&i.FetchIntervalOverride,
+		&i.NextFetchAt,
+		&i.Visibility,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const updateFeedFetchInterval = `-- name: UpdateFeedFetchInterval :exec
+UPDATE feeds SET fetch_interval = $2 WHERE id = $1
+`
+
+type UpdateFeedFetchIntervalParams struct {
+	ID            uuid.UUID
+	FetchInterval int32
+}
+
+func (q *Queries) UpdateFeedFetchInterval(ctx context.Context, arg UpdateFeedFetchIntervalParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedFetchInterval, arg.ID, arg.FetchInterval)
+	return err
+}
+
+const updateFeedMetadata = `-- name: UpdateFeedMetadata :one
+UPDATE feeds
+SET site_url = $2, description = $3, language = $4, image_url = $5, ttl = $6, skip_hours = $7, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetch, site_url, description, language, image_url, ttl, skip_hours, claimed_by, claim_expires_at, fetch_interval, fetch_interval_override, next_fetch_at, visibility, deleted_at
+`
+
+type UpdateFeedMetadataParams struct {
+	ID          uuid.UUID
+	SiteUrl     sql.NullString
+	Description sql.NullString
+	Language    sql.NullString
+	ImageUrl    sql.NullString
+	Ttl         sql.NullInt32
+	SkipHours   []int32
+}
+
+func (q *Queries) UpdateFeedMetadata(ctx context.Context, arg UpdateFeedMetadataParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, updateFeedMetadata,
+		arg.ID,
+		arg.SiteUrl,
+		arg.Description,
+		arg.Language,
+		arg.ImageUrl,
+		arg.Ttl,
+		pq.Array(arg.SkipHours),
+	)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetch,
+		&i.SiteUrl,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
+		&i.Ttl,
+		pq.Array(&i.SkipHours),
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.FetchInterval,
+		&i.FetchIntervalOverride,
+		&i.NextFetchAt,
+		&i.Visibility,
+		&i.DeletedAt,
 	)
 	return i, err
 }
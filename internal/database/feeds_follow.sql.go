@@ -7,24 +7,37 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createFollow = `-- name: CreateFollow :one
-INSERT INTO feed_follow (id, user_id, feed_id) 
-VALUES ($1, $2, $3) 
-RETURNING id, created_at, updated_at, user_id, feed_id
+INSERT INTO feed_follow (id, user_id, feed_id, folder_id) 
+VALUES ($1, $2, $3, $4) 
+ON CONFLICT (user_id, feed_id) DO UPDATE
+SET id = EXCLUDED.id, folder_id = EXCLUDED.folder_id, title = NULL, muted = FALSE, priority = 0,
+    created_at = NOW(), updated_at = NOW(), deleted_at = NULL
+WHERE feed_follow.deleted_at IS NOT NULL
+RETURNING id, created_at, updated_at, user_id, feed_id, folder_id, title, muted, priority, deleted_at
 `
 
 type CreateFollowParams struct {
-	ID     uuid.UUID
-	UserID uuid.UUID
-	FeedID uuid.UUID
+	ID       uuid.UUID
+	UserID   uuid.UUID
+	FeedID   uuid.UUID
+	FolderID uuid.NullUUID
 }
 
 func (q *Queries) CreateFollow(ctx context.Context, arg CreateFollowParams) (FeedFollow, error) {
-	row := q.db.QueryRowContext(ctx, createFollow, arg.ID, arg.UserID, arg.FeedID)
+	row := q.db.QueryRowContext(ctx, createFollow,
+		arg.ID,
+		arg.UserID,
+		arg.FeedID,
+		arg.FolderID,
+	)
 	var i FeedFollow
 	err := row.Scan(
 		&i.ID,
@@ -32,29 +45,183 @@ func (q *Queries) CreateFollow(ctx context.Context, arg CreateFollowParams) (Fee
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.FolderID,
+		&i.Title,
+		&i.Muted,
+		&i.Priority,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
+const getDeletedFollow = `-- name: GetDeletedFollow :one
+SELECT id, created_at, updated_at, user_id, feed_id, folder_id, title, muted, priority, deleted_at FROM feed_follow
+WHERE user_id = $1 AND feed_id = $2
+    AND deleted_at > NOW() - make_interval(secs => $3::int)
+`
+
+type GetDeletedFollowParams struct {
+	UserID       uuid.UUID
+	FeedID       uuid.UUID
+	GraceSeconds int32
+}
+
+func (q *Queries) GetDeletedFollow(ctx context.Context, arg GetDeletedFollowParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, getDeletedFollow, arg.UserID, arg.FeedID, arg.GraceSeconds)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+		&i.FolderID,
+		&i.Title,
+		&i.Muted,
+		&i.Priority,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getFollowedFeeds = `-- name: GetFollowedFeeds :many
+SELECT feeds.id, feeds.created_at, feeds.updated_at, feeds.name, feeds.url, feeds.user_id, feeds.last_fetch, feeds.site_url, feeds.description, feeds.language, feeds.image_url, feeds.ttl, feeds.skip_hours, feeds.claimed_by, feeds.claim_expires_at, feeds.fetch_interval, feeds.fetch_interval_override, feeds.next_fetch_at, feeds.visibility, feeds.deleted_at, folders.name AS folder_name FROM feeds
+JOIN feed_follow ON feed_follow.feed_id = feeds.id
+LEFT JOIN folders ON folders.id = feed_follow.folder_id
+WHERE feed_follow.user_id = $1 AND feed_follow.deleted_at IS NULL AND feeds.deleted_at IS NULL
+    AND feed_readable(feeds.id, feed_follow.user_id)
+ORDER BY folders.position NULLS FIRST, folders.name, feeds.name
+`
+
+type GetFollowedFeedsRow struct {
+	ID                    uuid.UUID
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+	Name                  string
+	Url                   string
+	UserID                uuid.UUID
+	LastFetch             sql.NullTime
+	SiteUrl               sql.NullString
+	Description           sql.NullString
+	Language              sql.NullString
+	ImageUrl              sql.NullString
+	Ttl                   sql.NullInt32
+	SkipHours             []int32
+	ClaimedBy             sql.NullString
+	ClaimExpiresAt        sql.NullTime
+	FetchInterval         int32
+	FetchIntervalOverride sql.NullInt32
+	NextFetchAt           sql.NullTime
+	Visibility            string
+	DeletedAt             sql.NullTime
+	FolderName            sql.NullString
+}
+
+func (q *Queries) GetFollowedFeeds(ctx context.Context, userID uuid.UUID) ([]GetFollowedFeedsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowedFeeds, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFollowedFeedsRow
+	for rows.Next() {
+		var i GetFollowedFeedsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetch,
+			&i.SiteUrl,
+			&i.Description,
+			&i.Language,
+			&i.ImageUrl,
+			&i.Ttl,
+			pq.Array(&i.SkipHours),
+			&i.ClaimedBy,
+			&i.ClaimExpiresAt,
+			&i.FetchInterval,
+			&i.FetchIntervalOverride,
+			&i.NextFetchAt,
+			&i.Visibility,
+			&i.DeletedAt,
+			&i.FolderName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getFollows = `-- name: GetFollows :many
-SELECT id, created_at, updated_at, user_id, feed_id FROM feed_follow WHERE user_id = $1
+SELECT feed_follow.id, feed_follow.created_at, feed_follow.updated_at, feed_follow.user_id, feed_follow.feed_id, feed_follow.folder_id, feed_follow.title, feed_follow.muted, feed_follow.priority, feed_follow.deleted_at, feeds.name AS feed_name, feeds.url AS feed_url,
+    feeds.user_id AS owner_id, users.name AS owner_name,
+    (SELECT COUNT(*) FROM posts
+        WHERE posts.feed_id = feeds.id AND (NOT posts.manual OR posts.published_at <= NOW()))::bigint AS post_count,
+    (SELECT MAX(posts.published_at) FROM posts
+        WHERE posts.feed_id = feeds.id AND (NOT posts.manual OR posts.published_at <= NOW())) AS last_post_at
+FROM feed_follow
+JOIN feeds ON feeds.id = feed_follow.feed_id
+JOIN users ON users.id = feeds.user_id
+WHERE feed_follow.user_id = $1 AND feed_follow.deleted_at IS NULL AND feeds.deleted_at IS NULL
+    AND feed_readable(feeds.id, feed_follow.user_id)
+ORDER BY feed_follow.priority DESC, feed_follow.created_at
 `
 
-func (q *Queries) GetFollows(ctx context.Context, userID uuid.UUID) ([]FeedFollow, error) {
+type GetFollowsRow struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uuid.UUID
+	FeedID     uuid.UUID
+	FolderID   uuid.NullUUID
+	Title      sql.NullString
+	Muted      bool
+	Priority   int32
+	DeletedAt  sql.NullTime
+	FeedName   string
+	FeedUrl    string
+	OwnerID    uuid.UUID
+	OwnerName  string
+	PostCount  int64
+	LastPostAt sql.NullTime
+}
+
+func (q *Queries) GetFollows(ctx context.Context, userID uuid.UUID) ([]GetFollowsRow, error) {
 	rows, err := q.db.QueryContext(ctx, getFollows, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []FeedFollow
+	var items []GetFollowsRow
 	for rows.Next() {
-		var i FeedFollow
+		var i GetFollowsRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.FeedID,
+			&i.FolderID,
+			&i.Title,
+			&i.Muted,
+			&i.Priority,
+			&i.DeletedAt,
+			&i.FeedName,
+			&i.FeedUrl,
+			&i.OwnerID,
+			&i.OwnerName,
+			&i.PostCount,
+			&i.LastPostAt,
 		); err != nil {
 			return nil, err
 		}
@@ -70,7 +237,7 @@ func (q *Queries) GetFollows(ctx context.Context, userID uuid.UUID) ([]FeedFollo
 }
 
 const getFollowsByFeedID = `-- name: GetFollowsByFeedID :one
-SELECT id, created_at, updated_at, user_id, feed_id FROM feed_follow WHERE feed_id = $1 AND user_id = $2
+SELECT id, created_at, updated_at, user_id, feed_id, folder_id, title, muted, priority, deleted_at FROM feed_follow WHERE feed_id = $1 AND user_id = $2 AND deleted_at IS NULL
 `
 
 type GetFollowsByFeedIDParams struct {
@@ -87,12 +254,126 @@ func (q *Queries) GetFollowsByFeedID(ctx context.Context, arg GetFollowsByFeedID
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.FolderID,
+		&i.Title,
+		&i.Muted,
+		&i.Priority,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getTimelineUpdatedAt = `-- name: GetTimelineUpdatedAt :one
+SELECT GREATEST(users.updated_at,
+    (SELECT MAX(GREATEST(feed_follow.updated_at, feed_follow.deleted_at)) FROM feed_follow WHERE feed_follow.user_id = users.id),
+    (SELECT MAX(feeds.updated_at) FROM feeds JOIN feed_follow ON feed_follow.feed_id = feeds.id
+        WHERE feed_follow.user_id = users.id AND feed_follow.deleted_at IS NULL))::timestamp AS updated_at
+FROM users
+WHERE users.id = $1
+`
+
+func (q *Queries) GetTimelineUpdatedAt(ctx context.Context, id uuid.UUID) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, getTimelineUpdatedAt, id)
+	var updatedAt time.Time
+	err := row.Scan(&updatedAt)
+	return updatedAt, err
+}
+
+const purgeDeletedFollows = `-- name: PurgeDeletedFollows :execrows
+DELETE FROM feed_follow
+WHERE deleted_at < NOW() - make_interval(secs => $1::int)
+`
+
+func (q *Queries) PurgeDeletedFollows(ctx context.Context, graceSeconds int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedFollows, graceSeconds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreFollow = `-- name: RestoreFollow :one
+UPDATE feed_follow SET deleted_at = NULL, updated_at = NOW() WHERE id = $1 RETURNING id, created_at, updated_at, user_id, feed_id, folder_id, title, muted, priority, deleted_at
+`
+
+func (q *Queries) RestoreFollow(ctx context.Context, id uuid.UUID) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, restoreFollow, id)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+		&i.FolderID,
+		&i.Title,
+		&i.Muted,
+		&i.Priority,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const restoreUserFollows = `-- name: RestoreUserFollows :exec
+UPDATE feed_follow SET deleted_at = NULL WHERE user_id = $1 AND deleted_at = $2
+`
+
+type RestoreUserFollowsParams struct {
+	UserID    uuid.UUID
+	DeletedAt sql.NullTime
+}
+
+func (q *Queries) RestoreUserFollows(ctx context.Context, arg RestoreUserFollowsParams) error {
+	_, err := q.db.ExecContext(ctx, restoreUserFollows, arg.UserID, arg.DeletedAt)
+	return err
+}
+
+const setFollowFolder = `-- name: SetFollowFolder :one
+UPDATE feed_follow SET folder_id = $3, updated_at = NOW()
+WHERE user_id = $1 AND feed_id = $2 AND deleted_at IS NULL
+RETURNING id, created_at, updated_at, user_id, feed_id, folder_id, title, muted, priority, deleted_at
+`
+
+type SetFollowFolderParams struct {
+	UserID   uuid.UUID
+	FeedID   uuid.UUID
+	FolderID uuid.NullUUID
+}
+
+func (q *Queries) SetFollowFolder(ctx context.Context, arg SetFollowFolderParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, setFollowFolder, arg.UserID, arg.FeedID, arg.FolderID)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+		&i.FolderID,
+		&i.Title,
+		&i.Muted,
+		&i.Priority,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
+const softDeleteUserFollows = `-- name: SoftDeleteUserFollows :exec
+UPDATE feed_follow SET deleted_at = $2 WHERE user_id = $1 AND deleted_at IS NULL
+`
+
+type SoftDeleteUserFollowsParams struct {
+	UserID    uuid.UUID
+	DeletedAt sql.NullTime
+}
+
+func (q *Queries) SoftDeleteUserFollows(ctx context.Context, arg SoftDeleteUserFollowsParams) error {
+	_, err := q.db.ExecContext(ctx, softDeleteUserFollows, arg.UserID, arg.DeletedAt)
+	return err
+}
+
 const unfollow = `-- name: Unfollow :exec
-DELETE FROM feed_follow WHERE user_id = $1 AND feed_id = $2
+UPDATE feed_follow SET deleted_at = NOW() WHERE user_id = $1 AND feed_id = $2 AND deleted_at IS NULL
 `
 
 type UnfollowParams struct {
@@ -104,3 +385,45 @@ func (q *Queries) Unfollow(ctx context.Context, arg UnfollowParams) error {
 	_, err := q.db.ExecContext(ctx, unfollow, arg.UserID, arg.FeedID)
 	return err
 }
+
+const updateFollowSettings = `-- name: UpdateFollowSettings :one
+UPDATE feed_follow
+SET title = NULLIF(COALESCE($1::text, title), ''),
+    muted = COALESCE($2::bool, muted),
+    priority = COALESCE($3::int, priority),
+    updated_at = NOW()
+WHERE user_id = $4 AND feed_id = $5 AND deleted_at IS NULL
+RETURNING id, created_at, updated_at, user_id, feed_id, folder_id, title, muted, priority, deleted_at
+`
+
+type UpdateFollowSettingsParams struct {
+	Title    sql.NullString
+	Muted    sql.NullBool
+	Priority sql.NullInt32
+	UserID   uuid.UUID
+	FeedID   uuid.UUID
+}
+
+func (q *Queries) UpdateFollowSettings(ctx context.Context, arg UpdateFollowSettingsParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, updateFollowSettings,
+		arg.Title,
+		arg.Muted,
+		arg.Priority,
+		arg.UserID,
+		arg.FeedID,
+	)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+		&i.FolderID,
+		&i.Title,
+		&i.Muted,
+		&i.Priority,
+		&i.DeletedAt,
+	)
+	return i, err
+}
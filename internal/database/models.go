@@ -6,27 +6,130 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type Feed struct {
+	ID                    uuid.UUID
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+	Name                  string
+	Url                   string
+	UserID                uuid.UUID
+	LastFetch             sql.NullTime
+	SiteUrl               sql.NullString
+	Description           sql.NullString
+	Language              sql.NullString
+	ImageUrl              sql.NullString
+	Ttl                   sql.NullInt32
+	SkipHours             []int32
+	ClaimedBy             sql.NullString
+	ClaimExpiresAt        sql.NullTime
+	FetchInterval         int32
+	FetchIntervalOverride sql.NullInt32
+	NextFetchAt           sql.NullTime
+	Visibility            string
+	DeletedAt             sql.NullTime
+}
+
+type FeedAccess struct {
+	FeedID    uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type FeedFetchRun struct {
+	ID              uuid.UUID
+	FeedID          uuid.UUID
+	Source          string
+	StartedAt       time.Time
+	FinishedAt      time.Time
+	HttpStatus      sql.NullInt32
+	Bytes           int64
+	ItemsSeen       int32
+	NewPosts        int32
+	UpdatedPosts    int32
+	Error           sql.NullString
+	CompressedBytes int64
+}
+
+type FeedFollow struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Name      string
-	Url       string
 	UserID    uuid.UUID
-	LastFetch sql.NullTime
+	FeedID    uuid.UUID
+	FolderID  uuid.NullUUID
+	Title     sql.NullString
+	Muted     bool
+	Priority  int32
+	DeletedAt sql.NullTime
 }
 
-type FeedFollow struct {
+type FeedInvite struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	FeedID    uuid.UUID
+	CreatedBy uuid.NullUUID
+	TokenHash string
+	ExpiresAt sql.NullTime
+	MaxUses   sql.NullInt32
+	Uses      int32
+}
+
+type FeedMaintainer struct {
+	FeedID    uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type FeedOwnershipTransfer struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	FeedID     uuid.UUID
+	FromUserID uuid.UUID
+	ToUserID   uuid.UUID
+	Status     string
+}
+
+type FeedRefreshJob struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	FeedID       uuid.UUID
+	UserID       uuid.UUID
+	Status       string
+	StartedAt    sql.NullTime
+	FinishedAt   sql.NullTime
+	Error        sql.NullString
+	NewPosts     int32
+	UpdatedPosts int32
+}
+
+type Folder struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	UserID    uuid.UUID
-	FeedID    uuid.UUID
+	Name      string
+	Position  int32
+}
+
+type OpmlImportJob struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uuid.UUID
+	Status     string
+	Entries    json.RawMessage
+	Results    json.RawMessage
+	StartedAt  sql.NullTime
+	FinishedAt sql.NullTime
+	Error      sql.NullString
 }
 
 type Post struct {
@@ -38,13 +141,53 @@ type Post struct {
 	PublishedAt time.Time
 	Url         string
 	FeedID      uuid.UUID
+	Guid        sql.NullString
+	Author      sql.NullString
+	Categories  []string
+	Content     sql.NullString
+	ItemKey     string
+	ContentHash string
+	Revision    int32
+	Summary     sql.NullString
+	Manual      bool
+	Body        sql.NullString
+	AuthorID    uuid.NullUUID
+}
+
+type PostEnclosure struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	PostID    uuid.UUID
+	Url       string
+	Type      sql.NullString
+	Length    sql.NullInt64
 }
 
 type User struct {
+	ID                uuid.UUID
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	Name              string
+	Email             string
+	Password          string
+	TimelineTokenHash sql.NullString
+	DeletedAt         sql.NullTime
+}
+
+type UserFollow struct {
+	FollowerID uuid.UUID
+	FollowedID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type WebsubSubscription struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Name      string
-	Email     string
-	Password  string
+	FeedID    uuid.UUID
+	Hub       string
+	Topic     string
+	Secret    string
+	State     string
+	ExpiresAt sql.NullTime
 }
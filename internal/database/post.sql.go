@@ -11,31 +11,58 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
-const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, title, description, published_at, url, feed_id)
-VALUES ($1, $2, $3, $4, $5, $6) 
-RETURNING id, created_at, updated_at, title, description, published_at, url, feed_id
+const countManualPosts = `-- name: CountManualPosts :one
+SELECT COUNT(*) FROM posts WHERE feed_id = $1 AND manual
 `
 
-type CreatePostParams struct {
+func (q *Queries) CountManualPosts(ctx context.Context, feedID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countManualPosts, feedID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createManualPost = `-- name: CreateManualPost :one
+WITH created AS (
+    INSERT INTO posts (id, title, description, published_at, url, feed_id, item_key, content_hash, summary, manual, body, author_id)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, TRUE, $10, $11)
+    RETURNING id, created_at, updated_at, title, description, published_at, url, feed_id, guid, author, categories, content, item_key, content_hash, revision, summary, manual, body, author_id
+), touched AS (
+    UPDATE feeds SET updated_at = NOW() WHERE id = $6
+)
+SELECT id, created_at, updated_at, title, description, published_at, url, feed_id, guid, author, categories, content, item_key, content_hash, revision, summary, manual, body, author_id FROM created
+`
+
+type CreateManualPostParams struct {
 	ID          uuid.UUID
 	Title       string
 	Description sql.NullString
 	PublishedAt time.Time
 	Url         string
 	FeedID      uuid.UUID
+	ItemKey     string
+	ContentHash string
+	Summary     sql.NullString
+	Body        sql.NullString
+	AuthorID    uuid.NullUUID
 }
 
-func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
-	row := q.db.QueryRowContext(ctx, createPost,
+func (q *Queries) CreateManualPost(ctx context.Context, arg CreateManualPostParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, createManualPost,
 		arg.ID,
 		arg.Title,
 		arg.Description,
 		arg.PublishedAt,
 		arg.Url,
 		arg.FeedID,
+		arg.ItemKey,
+		arg.ContentHash,
+		arg.Summary,
+		arg.Body,
+		arg.AuthorID,
 	)
 	var i Post
 	err := row.Scan(
@@ -47,14 +74,402 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.PublishedAt,
 		&i.Url,
 		&i.FeedID,
+		&i.Guid,
+		&i.Author,
+		pq.Array(&i.Categories),
+		&i.Content,
+		&i.ItemKey,
+		&i.ContentHash,
+		&i.Revision,
+		&i.Summary,
+		&i.Manual,
+		&i.Body,
+		&i.AuthorID,
 	)
 	return i, err
 }
 
+const createPostEnclosures = `-- name: CreatePostEnclosures :exec
+INSERT INTO post_enclosures (id, post_id, url, type, length)
+SELECT enclosure.id, enclosure.post_id, enclosure.url, NULLIF(enclosure.type, ''), NULLIF(enclosure.length, 0)
+FROM unnest(
+    $1::uuid[],
+    $2::uuid[],
+    $3::text[],
+    $4::text[],
+    $5::bigint[]
+) AS enclosure(id, post_id, url, type, length)
+ON CONFLICT (post_id, url) DO NOTHING
+`
+
+type CreatePostEnclosuresParams struct {
+	Ids     []uuid.UUID
+	PostIds []uuid.UUID
+	Urls    []string
+	Types   []string
+	Lengths []int64
+}
+
+func (q *Queries) CreatePostEnclosures(ctx context.Context, arg CreatePostEnclosuresParams) error {
+	_, err := q.db.ExecContext(ctx, createPostEnclosures,
+		pq.Array(arg.Ids),
+		pq.Array(arg.PostIds),
+		pq.Array(arg.Urls),
+		pq.Array(arg.Types),
+		pq.Array(arg.Lengths),
+	)
+	return err
+}
+
+const deleteManualPost = `-- name: DeleteManualPost :execrows
+WITH deleted AS (
+    DELETE FROM posts WHERE id = $1 AND feed_id = $2 AND manual RETURNING feed_id
+)
+UPDATE feeds SET updated_at = NOW() WHERE id IN (SELECT feed_id FROM deleted)
+`
+
+type DeleteManualPostParams struct {
+	ID     uuid.UUID
+	FeedID uuid.UUID
+}
+
+func (q *Queries) DeleteManualPost(ctx context.Context, arg DeleteManualPostParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteManualPost, arg.ID, arg.FeedID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteStalePostEnclosures = `-- name: DeleteStalePostEnclosures :exec
+DELETE FROM post_enclosures
+WHERE post_id = ANY($1::uuid[])
+    AND NOT EXISTS (
+        SELECT 1 FROM unnest($2::uuid[], $3::text[]) AS kept(post_id, url)
+        WHERE kept.post_id = post_enclosures.post_id AND kept.url = post_enclosures.url
+    )
+`
+
+type DeleteStalePostEnclosuresParams struct {
+	PostIds     []uuid.UUID
+	KeptPostIds []uuid.UUID
+	KeptUrls    []string
+}
+
+func (q *Queries) DeleteStalePostEnclosures(ctx context.Context, arg DeleteStalePostEnclosuresParams) error {
+	_, err := q.db.ExecContext(ctx, deleteStalePostEnclosures, pq.Array(arg.PostIds), pq.Array(arg.KeptPostIds), pq.Array(arg.KeptUrls))
+	return err
+}
+
+const getFeedPost = `-- name: GetFeedPost :one
+SELECT id, created_at, updated_at, title, description, published_at, url, feed_id, guid, author, categories, content, item_key, content_hash, revision, summary, manual, body, author_id FROM posts WHERE id = $1 AND feed_id = $2
+`
+
+type GetFeedPostParams struct {
+	ID     uuid.UUID
+	FeedID uuid.UUID
+}
+
+func (q *Queries) GetFeedPost(ctx context.Context, arg GetFeedPostParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getFeedPost, arg.ID, arg.FeedID)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Description,
+		&i.PublishedAt,
+		&i.Url,
+		&i.FeedID,
+		&i.Guid,
+		&i.Author,
+		pq.Array(&i.Categories),
+		&i.Content,
+		&i.ItemKey,
+		&i.ContentHash,
+		&i.Revision,
+		&i.Summary,
+		&i.Manual,
+		&i.Body,
+		&i.AuthorID,
+	)
+	return i, err
+}
+
+const getFeedPublishedPosts = `-- name: GetFeedPublishedPosts :many
+SELECT id, created_at, updated_at, title, description, published_at, url, feed_id, guid, author, categories, content, item_key, content_hash, revision, summary, manual, body, author_id FROM posts
+WHERE feed_id = $1 AND (NOT manual OR published_at <= NOW())
+ORDER BY published_at DESC
+LIMIT $2
+`
+
+type GetFeedPublishedPostsParams struct {
+	FeedID uuid.UUID
+	Limit  int64
+}
+
+func (q *Queries) GetFeedPublishedPosts(ctx context.Context, arg GetFeedPublishedPostsParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedPublishedPosts, arg.FeedID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Description,
+			&i.PublishedAt,
+			&i.Url,
+			&i.FeedID,
+			&i.Guid,
+			&i.Author,
+			pq.Array(&i.Categories),
+			&i.Content,
+			&i.ItemKey,
+			&i.ContentHash,
+			&i.Revision,
+			&i.Summary,
+			&i.Manual,
+			&i.Body,
+			&i.AuthorID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFolderPosts = `-- name: GetFolderPosts :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.description, posts.published_at, posts.url, posts.feed_id, posts.guid, posts.author, posts.categories, posts.content, posts.item_key, posts.content_hash, posts.revision, posts.summary, posts.manual, posts.body, posts.author_id FROM posts
+JOIN feed_follow ON posts.feed_id = feed_follow.feed_id
+WHERE feed_follow.user_id = $1 AND feed_follow.folder_id = $2 AND feed_follow.deleted_at IS NULL
+    AND (NOT posts.manual OR posts.published_at <= NOW())
+    AND feed_readable(posts.feed_id, $1)
+ORDER BY posts.published_at DESC
+LIMIT $3
+`
+
+type GetFolderPostsParams struct {
+	UserID   uuid.UUID
+	FolderID uuid.NullUUID
+	Limit    int64
+}
+
+func (q *Queries) GetFolderPosts(ctx context.Context, arg GetFolderPostsParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getFolderPosts, arg.UserID, arg.FolderID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Description,
+			&i.PublishedAt,
+			&i.Url,
+			&i.FeedID,
+			&i.Guid,
+			&i.Author,
+			pq.Array(&i.Categories),
+			&i.Content,
+			&i.ItemKey,
+			&i.ContentHash,
+			&i.Revision,
+			&i.Summary,
+			&i.Manual,
+			&i.Body,
+			&i.AuthorID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getManualPosts = `-- name: GetManualPosts :many
+SELECT id, created_at, updated_at, title, description, published_at, url, feed_id, guid, author, categories, content, item_key, content_hash, revision, summary, manual, body, author_id FROM posts
+WHERE feed_id = $1 AND manual
+ORDER BY published_at DESC
+LIMIT $2 OFFSET $3
+`
+
+type GetManualPostsParams struct {
+	FeedID uuid.UUID
+	Limit  int64
+	Offset int64
+}
+
+func (q *Queries) GetManualPosts(ctx context.Context, arg GetManualPostsParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getManualPosts, arg.FeedID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Description,
+			&i.PublishedAt,
+			&i.Url,
+			&i.FeedID,
+			&i.Guid,
+			&i.Author,
+			pq.Array(&i.Categories),
+			&i.Content,
+			&i.ItemKey,
+			&i.ContentHash,
+			&i.Revision,
+			&i.Summary,
+			&i.Manual,
+			&i.Body,
+			&i.AuthorID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNetworkPosts = `-- name: GetNetworkPosts :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.description, posts.published_at, posts.url, posts.feed_id, posts.guid, posts.author, posts.categories, posts.content, posts.item_key, posts.content_hash, posts.revision, posts.summary, posts.manual, posts.body, posts.author_id FROM posts
+JOIN feeds ON feeds.id = posts.feed_id
+LEFT JOIN feed_follow ON feed_follow.feed_id = posts.feed_id AND feed_follow.user_id = $1 AND feed_follow.deleted_at IS NULL
+WHERE (feed_follow.id IS NOT NULL
+        OR (feeds.visibility = 'public' AND feeds.user_id IN (SELECT followed_id FROM user_follows WHERE follower_id = $1)))
+    AND NOT COALESCE(feed_follow.muted, FALSE)
+    AND (NOT posts.manual OR posts.published_at <= NOW())
+    AND feed_readable(posts.feed_id, $1)
+ORDER BY posts.published_at DESC
+LIMIT $2
+`
+
+type GetNetworkPostsParams struct {
+	UserID uuid.UUID
+	Limit  int64
+}
+
+func (q *Queries) GetNetworkPosts(ctx context.Context, arg GetNetworkPostsParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getNetworkPosts, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Description,
+			&i.PublishedAt,
+			&i.Url,
+			&i.FeedID,
+			&i.Guid,
+			&i.Author,
+			pq.Array(&i.Categories),
+			&i.Content,
+			&i.ItemKey,
+			&i.ContentHash,
+			&i.Revision,
+			&i.Summary,
+			&i.Manual,
+			&i.Body,
+			&i.AuthorID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostEnclosures = `-- name: GetPostEnclosures :many
+SELECT id, created_at, post_id, url, type, length FROM post_enclosures
+WHERE post_id = ANY($1::uuid[])
+ORDER BY created_at
+`
+
+func (q *Queries) GetPostEnclosures(ctx context.Context, postIds []uuid.UUID) ([]PostEnclosure, error) {
+	rows, err := q.db.QueryContext(ctx, getPostEnclosures, pq.Array(postIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PostEnclosure
+	for rows.Next() {
+		var i PostEnclosure
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.PostID,
+			&i.Url,
+			&i.Type,
+			&i.Length,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPosts = `-- name: GetPosts :many
-SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.description, posts.published_at, posts.url, posts.feed_id FROM posts
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.description, posts.published_at, posts.url, posts.feed_id, posts.guid, posts.author, posts.categories, posts.content, posts.item_key, posts.content_hash, posts.revision, posts.summary, posts.manual, posts.body, posts.author_id FROM posts
 JOIN feed_follow ON posts.feed_id = feed_follow.feed_id
-WHERE feed_follow.user_id = $1
+WHERE feed_follow.user_id = $1 AND feed_follow.deleted_at IS NULL AND NOT feed_follow.muted
+    AND (NOT posts.manual OR posts.published_at <= NOW())
+    AND feed_readable(posts.feed_id, $1)
 ORDER BY posts.published_at DESC
 LIMIT $2
 `
@@ -82,6 +497,17 @@ func (q *Queries) GetPosts(ctx context.Context, arg GetPostsParams) ([]Post, err
 			&i.PublishedAt,
 			&i.Url,
 			&i.FeedID,
+			&i.Guid,
+			&i.Author,
+			pq.Array(&i.Categories),
+			&i.Content,
+			&i.ItemKey,
+			&i.ContentHash,
+			&i.Revision,
+			&i.Summary,
+			&i.Manual,
+			&i.Body,
+			&i.AuthorID,
 		); err != nil {
 			return nil, err
 		}
@@ -95,3 +521,158 @@ func (q *Queries) GetPosts(ctx context.Context, arg GetPostsParams) ([]Post, err
 	}
 	return items, nil
 }
+
+const updateManualPost = `-- name: UpdateManualPost :one
+WITH updated AS (
+    UPDATE posts
+    SET title = $3, description = $4, published_at = $5, url = $6, content_hash = $7, summary = $8, body = $9,
+        revision = revision + 1, updated_at = NOW()
+    WHERE id = $1 AND feed_id = $2 AND manual
+    RETURNING id, created_at, updated_at, title, description, published_at, url, feed_id, guid, author, categories, content, item_key, content_hash, revision, summary, manual, body, author_id
+), touched AS (
+    UPDATE feeds SET updated_at = NOW() WHERE id IN (SELECT feed_id FROM updated)
+)
+SELECT id, created_at, updated_at, title, description, published_at, url, feed_id, guid, author, categories, content, item_key, content_hash, revision, summary, manual, body, author_id FROM updated
+`
+
+type UpdateManualPostParams struct {
+	ID          uuid.UUID
+	FeedID      uuid.UUID
+	Title       string
+	Description sql.NullString
+	PublishedAt time.Time
+	Url         string
+	ContentHash string
+	Summary     sql.NullString
+	Body        sql.NullString
+}
+
+func (q *Queries) UpdateManualPost(ctx context.Context, arg UpdateManualPostParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, updateManualPost,
+		arg.ID,
+		arg.FeedID,
+		arg.Title,
+		arg.Description,
+		arg.PublishedAt,
+		arg.Url,
+		arg.ContentHash,
+		arg.Summary,
+		arg.Body,
+	)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Description,
+		&i.PublishedAt,
+		&i.Url,
+		&i.FeedID,
+		&i.Guid,
+		&i.Author,
+		pq.Array(&i.Categories),
+		&i.Content,
+		&i.ItemKey,
+		&i.ContentHash,
+		&i.Revision,
+		&i.Summary,
+		&i.Manual,
+		&i.Body,
+		&i.AuthorID,
+	)
+	return i, err
+}
+
+const upsertPosts = `-- name: UpsertPosts :many
+INSERT INTO posts (id, title, description, published_at, url, feed_id, guid, author, categories, content, item_key, content_hash, summary)
+SELECT item.id, item.title, NULLIF(item.description, ''), item.published_at, item.url, $1::uuid,
+    NULLIF(item.guid, ''), NULLIF(item.author, ''), ARRAY(SELECT json_array_elements_text(item.categories::json)),
+    NULLIF(item.content, ''), item.item_key, item.content_hash, NULLIF(item.summary, '')
+FROM unnest(
+    $2::uuid[],
+    $3::text[],
+    $4::text[],
+    $5::timestamp[],
+    $6::text[],
+    $7::text[],
+    $8::text[],
+    $9::text[],
+    $10::text[],
+    $11::text[],
+    $12::text[],
+    $13::text[]
+) AS item(id, title, description, published_at, url, guid, author, categories, content, item_key, content_hash, summary)
+ON CONFLICT (feed_id, item_key) DO UPDATE
+SET title = EXCLUDED.title,
+    description = EXCLUDED.description,
+    url = EXCLUDED.url,
+    author = EXCLUDED.author,
+    categories = EXCLUDED.categories,
+    content = EXCLUDED.content,
+    content_hash = EXCLUDED.content_hash,
+    summary = EXCLUDED.summary,
+    revision = posts.revision + 1,
+    updated_at = NOW()
+WHERE posts.content_hash <> EXCLUDED.content_hash
+RETURNING id, item_key, revision
+`
+
+type UpsertPostsParams struct {
+	FeedID        uuid.UUID
+	Ids           []uuid.UUID
+	Titles        []string
+	Descriptions  []string
+	PublishedAt   []time.Time
+	Urls          []string
+	Guids         []string
+	Authors       []string
+	Categories    []string
+	Contents      []string
+	ItemKeys      []string
+	ContentHashes []string
+	Summaries     []string
+}
+
+type UpsertPostsRow struct {
+	ID       uuid.UUID
+	ItemKey  string
+	Revision int32
+}
+
+func (q *Queries) UpsertPosts(ctx context.Context, arg UpsertPostsParams) ([]UpsertPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, upsertPosts,
+		arg.FeedID,
+		pq.Array(arg.Ids),
+		pq.Array(arg.Titles),
+		pq.Array(arg.Descriptions),
+		pq.Array(arg.PublishedAt),
+		pq.Array(arg.Urls),
+		pq.Array(arg.Guids),
+		pq.Array(arg.Authors),
+		pq.Array(arg.Categories),
+		pq.Array(arg.Contents),
+		pq.Array(arg.ItemKeys),
+		pq.Array(arg.ContentHashes),
+		pq.Array(arg.Summaries),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UpsertPostsRow
+	for rows.Next() {
+		var i UpsertPostsRow
+		if err := rows.Scan(&i.ID, &i.ItemKey, &i.Revision); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
@@ -14,7 +15,7 @@ import (
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (id, name, email, password) 
 VALUES ($1, $2, $3, $4) 
-RETURNING id, created_at, updated_at, name, email, password
+RETURNING id, created_at, updated_at, name, email, password, timeline_token_hash, deleted_at
 `
 
 type CreateUserParams struct {
@@ -39,6 +40,8 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Name,
 		&i.Email,
 		&i.Password,
+		&i.TimelineTokenHash,
+		&i.DeletedAt,
 	)
 	return i, err
 }
@@ -52,8 +55,65 @@ func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const getDeletedUser = `-- name: GetDeletedUser :one
+SELECT id, created_at, updated_at, name, email, password, timeline_token_hash, deleted_at FROM users
+WHERE email = $1
+    AND deleted_at > NOW() - make_interval(secs => $2::int)
+ORDER BY deleted_at DESC
+LIMIT 1
+`
+
+type GetDeletedUserParams struct {
+	Email        string
+	GraceSeconds int32
+}
+
+func (q *Queries) GetDeletedUser(ctx context.Context, arg GetDeletedUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, getDeletedUser, arg.Email, arg.GraceSeconds)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Email,
+		&i.Password,
+		&i.TimelineTokenHash,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getPurgeableUsers = `-- name: GetPurgeableUsers :many
+SELECT id FROM users
+WHERE deleted_at < NOW() - make_interval(secs => $1::int)
+`
+
+func (q *Queries) GetPurgeableUsers(ctx context.Context, graceSeconds int32) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getPurgeableUsers, graceSeconds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUser = `-- name: GetUser :one
-SELECT id, created_at, updated_at, name, email, password FROM users WHERE email = $1
+SELECT id, created_at, updated_at, name, email, password, timeline_token_hash, deleted_at FROM users WHERE email = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetUser(ctx context.Context, email string) (User, error) {
@@ -66,12 +126,14 @@ func (q *Queries) GetUser(ctx context.Context, email string) (User, error) {
 		&i.Name,
 		&i.Email,
 		&i.Password,
+		&i.TimelineTokenHash,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, created_at, updated_at, name, email, password FROM users WHERE id = $1
+SELECT id, created_at, updated_at, name, email, password, timeline_token_hash, deleted_at FROM users WHERE id = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
@@ -84,12 +146,88 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Name,
 		&i.Email,
 		&i.Password,
+		&i.TimelineTokenHash,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getUserByTimelineToken = `-- name: GetUserByTimelineToken :one
+SELECT id, created_at, updated_at, name, email, password, timeline_token_hash, deleted_at FROM users WHERE timeline_token_hash = $1 AND deleted_at IS NULL
+`
+
+func (q *Queries) GetUserByTimelineToken(ctx context.Context, timelineTokenHash sql.NullString) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByTimelineToken, timelineTokenHash)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Email,
+		&i.Password,
+		&i.TimelineTokenHash,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const restoreUser = `-- name: RestoreUser :one
+UPDATE users SET deleted_at = NULL WHERE id = $1 RETURNING id, created_at, updated_at, name, email, password, timeline_token_hash, deleted_at
+`
+
+func (q *Queries) RestoreUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, restoreUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Email,
+		&i.Password,
+		&i.TimelineTokenHash,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const setTimelineToken = `-- name: SetTimelineToken :exec
+UPDATE users SET timeline_token_hash = $2 WHERE id = $1
+`
+
+type SetTimelineTokenParams struct {
+	ID                uuid.UUID
+	TimelineTokenHash sql.NullString
+}
+
+func (q *Queries) SetTimelineToken(ctx context.Context, arg SetTimelineTokenParams) error {
+	_, err := q.db.ExecContext(ctx, setTimelineToken, arg.ID, arg.TimelineTokenHash)
+	return err
+}
+
+const softDeleteUser = `-- name: SoftDeleteUser :one
+UPDATE users SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL RETURNING id, created_at, updated_at, name, email, password, timeline_token_hash, deleted_at
+`
+
+func (q *Queries) SoftDeleteUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, softDeleteUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Email,
+		&i.Password,
+		&i.TimelineTokenHash,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const updateUser = `-- name: UpdateUser :one
-UPDATE users SET name = $2, email = $3 WHERE id = $1 RETURNING id, created_at, updated_at, name, email, password
+UPDATE users SET name = $2, email = $3, updated_at = NOW() WHERE id = $1 RETURNING id, created_at, updated_at, name, email, password, timeline_token_hash, deleted_at
 `
 
 type UpdateUserParams struct {
@@ -108,6 +246,8 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Name,
 		&i.Email,
 		&i.Password,
+		&i.TimelineTokenHash,
+		&i.DeletedAt,
 	)
 	return i, err
 }
@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"os"
 	"project_1/internal/database"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/cors"
@@ -20,7 +22,8 @@ import (
 )
 
 type apiConfig struct {
-	DB *database.Queries
+	DB   *database.Queries
+	Conn *sql.DB // For the queries that run in a transaction
 }
 
 // @title           Swagger Example API
@@ -42,6 +45,65 @@ func main() {
 		log.Fatal("DB_URL is not set")
 	}
 
+	if length := os.Getenv("SUMMARY_LENGTH"); length != "" {
+		value, err := strconv.Atoi(length)
+		if err != nil {
+			log.Fatal("SUMMARY_LENGTH is not a number")
+		}
+		summaryLength = value
+	}
+
+	limiter, err := hostLimiterFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	hostPolicy = limiter
+
+	err = fetchIntervalsFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = refreshLimitsFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = compressionFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = fetchHistoryRetentionFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = deletionGracePeriodFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = scraperIntervalFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = allowPrivateFeedsFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	webSubCallbackURL = os.Getenv("WEBSUB_CALLBACK_URL")
+	if webSubCallbackURL != "" && !isValidURL(webSubCallbackURL) {
+		log.Fatal("WEBSUB_CALLBACK_URL is not a valid URL")
+	}
+
+	publicBaseURL = strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/")
+	if publicBaseURL != "" && !isValidURL(publicBaseURL) {
+		log.Fatal("PUBLIC_BASE_URL is not a valid URL")
+	}
+
 	conn, err := sql.Open("postgres", db_url)
 	if err != nil {
 		log.Fatal("Cannot connect to database")
@@ -50,11 +112,25 @@ func main() {
 	db := database.New(conn)
 	// Create a new instance of the API
 	apiCfg := apiConfig{
-		DB: db,
+		DB:   db,
+		Conn: conn,
 	}
 	// Go routine that runs separately from the main thread
 	// This is a good place to put background tasks
-	// go startScraping(db, 10, time.Minute)
+	if scraperInterval > 0 {
+		go startScraping(conn, scraperConcurrency, scraperInterval)
+	}
+
+	// Runs the refreshes asked for through /v2/feeds/{feed_id}/refresh
+	go startRefreshWorker(conn, 2)
+	// Runs the OPML imports uploaded to /v3/follow/opml
+	go apiCfg.startOPMLImportWorker(1)
+	// Keeps WebSub subscriptions alive, it does nothing without WEBSUB_CALLBACK_URL
+	go startWebSubRenewer(conn)
+	// Deletes the fetch history past FETCH_HISTORY_RETENTION
+	go startFetchHistoryPruner(conn)
+	// Deletes for good what was deleted before DELETION_GRACE_PERIOD
+	go startDeletionPurger(conn)
 
 	// Create a new router
 	router := chi.NewRouter()
@@ -62,19 +138,30 @@ func main() {
 	// Set up CORS middleware
 	router.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "X-Total-Count", "ETag"},
 		AllowCredentials: false,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	}))
 
+	// gzip or br depending on Accept-Encoding, for responses of COMPRESSION_MIN_SIZE bytes or more
+	router.Use(middlewareCompress)
+
 	// Add Swagger UI route to the main router
 	router.Get("/swagger/*", httpSwagger.WrapHandler)
 
 	// Test if the server is running
 	router.Get("/ready", handlerReadiness)
 
+	// WebSub callbacks, called by hubs without credentials
+	router.Get("/websub/{subscription_id}", apiCfg.handlerWebSubVerify)
+	router.Post("/websub/{subscription_id}", apiCfg.handlerWebSubPush)
+
+	// Feeds served back out, the timeline is addressed by its secret token
+	router.Get("/feeds/{feed_id}.{format}", apiCfg.handlerGetFeedDocument)
+	router.Get("/timelines/{token}.{format}", apiCfg.handlerGetTimelineDocument)
+
 	// Create V1 router
 	v1 := chi.NewRouter()
 	v1.Get("/err", handlerErr)
@@ -83,17 +170,59 @@ func main() {
 	v1.Get("/user", apiCfg.middlewareAuth(apiCfg.handlerGetUser))
 	v1.Delete("/user", apiCfg.middlewareAuth(apiCfg.handlerDeleteUser))
 	v1.Put("/user", apiCfg.middlewareAuth(apiCfg.handlerUpdateUser))
+	v1.Post("/user/restore", apiCfg.handlerRestoreUser)
+	v1.Post("/user/timeline-token", apiCfg.middlewareAuth(apiCfg.handlerCreateTimelineToken))
+	v1.Delete("/user/timeline-token", apiCfg.middlewareAuth(apiCfg.handlerDeleteTimelineToken))
+	v1.Get("/users/{user_id}", apiCfg.middlewareAuth(apiCfg.handlerGetUserProfile))
+	v1.Get("/users/{user_id}/feeds", apiCfg.middlewareAuth(apiCfg.handlerGetUserFeeds))
+	v1.Get("/users/{user_id}/followers", apiCfg.middlewareAuth(apiCfg.handlerGetUserFollowers))
+	v1.Get("/users/{user_id}/following", apiCfg.middlewareAuth(apiCfg.handlerGetFollowedUsers))
+	v1.Post("/users/{user_id}/follow", apiCfg.middlewareAuth(apiCfg.handlerFollowUser))
+	v1.Delete("/users/{user_id}/follow", apiCfg.middlewareAuth(apiCfg.handlerUnfollowUser))
 
 	v2 := chi.NewRouter()
 	v2.Post("/feeds", apiCfg.middlewareAuth(apiCfg.handlerCreateFeed))
 	v2.Get("/feeds", apiCfg.middlewareAuth(apiCfg.handlerGetFeeds))
+	v2.Get("/feeds/{feed_id}", apiCfg.middlewareAuth(apiCfg.handlerGetFeed))
 	v2.Put("/feeds/{feed_id}", apiCfg.middlewareAuth(apiCfg.handlerUpdateFeed))
 	v2.Delete("/feeds/{feed_id}", apiCfg.middlewareAuth(apiCfg.handlerDeleteFeed))
+	v2.Post("/feeds/{feed_id}/restore", apiCfg.middlewareAuth(apiCfg.handlerRestoreFeed))
+	v2.Post("/feeds/{feed_id}/refresh", apiCfg.middlewareAuth(apiCfg.handlerRefreshFeed))
+	v2.Get("/feeds/{feed_id}/refresh/{job_id}", apiCfg.middlewareAuth(apiCfg.handlerGetRefreshJob))
+	v2.Get("/feeds/{feed_id}/fetches", apiCfg.middlewareAuth(apiCfg.handlerGetFeedFetches))
+	v2.Post("/feeds/{feed_id}/posts", apiCfg.middlewareAuth(apiCfg.handlerCreatePost))
+	v2.Get("/feeds/{feed_id}/posts", apiCfg.middlewareAuth(apiCfg.handlerGetFeedPosts))
+	v2.Put("/feeds/{feed_id}/posts/{post_id}", apiCfg.middlewareAuth(apiCfg.handlerUpdatePost))
+	v2.Delete("/feeds/{feed_id}/posts/{post_id}", apiCfg.middlewareAuth(apiCfg.handlerDeletePost))
+	v2.Get("/feeds/{feed_id}/maintainers", apiCfg.middlewareAuth(apiCfg.handlerGetFeedMaintainers))
+	v2.Post("/feeds/{feed_id}/maintainers", apiCfg.middlewareAuth(apiCfg.handlerAddFeedMaintainer))
+	v2.Delete("/feeds/{feed_id}/maintainers/{user_id}", apiCfg.middlewareAuth(apiCfg.handlerRemoveFeedMaintainer))
+	v2.Get("/feeds/{feed_id}/access", apiCfg.middlewareAuth(apiCfg.handlerGetFeedAccess))
+	v2.Post("/feeds/{feed_id}/access", apiCfg.middlewareAuth(apiCfg.handlerAddFeedAccess))
+	v2.Delete("/feeds/{feed_id}/access/{user_id}", apiCfg.middlewareAuth(apiCfg.handlerRemoveFeedAccess))
+	v2.Post("/feeds/{feed_id}/invites", apiCfg.middlewareAuth(apiCfg.handlerCreateFeedInvite))
+	v2.Get("/feeds/{feed_id}/invites", apiCfg.middlewareAuth(apiCfg.handlerGetFeedInvites))
+	v2.Delete("/feeds/{feed_id}/invites/{invite_id}", apiCfg.middlewareAuth(apiCfg.handlerDeleteFeedInvite))
+	v2.Post("/invites/{token}/accept", apiCfg.middlewareAuth(apiCfg.handlerAcceptFeedInvite))
+	v2.Post("/feeds/{feed_id}/transfers", apiCfg.middlewareAuth(apiCfg.handlerCreateOwnershipTransfer))
+	v2.Get("/transfers", apiCfg.middlewareAuth(apiCfg.handlerGetOwnershipTransfers))
+	v2.Post("/transfers/{transfer_id}/accept", apiCfg.middlewareAuth(apiCfg.handlerAcceptOwnershipTransfer))
+	v2.Post("/transfers/{transfer_id}/decline", apiCfg.middlewareAuth(apiCfg.handlerDeclineOwnershipTransfer))
 
 	v3 := chi.NewRouter()
 	v3.Post("/follow", apiCfg.middlewareAuth(apiCfg.handlerFollowFeed))
 	v3.Get("/follow", apiCfg.middlewareAuth(apiCfg.handlerGetFollows))
+	v3.Post("/follow/opml", apiCfg.middlewareAuth(apiCfg.handlerImportOPML))
+	v3.Get("/follow/opml", apiCfg.middlewareAuth(apiCfg.handlerExportOPML))
+	v3.Get("/follow/opml/{job_id}", apiCfg.middlewareAuth(apiCfg.handlerGetOPMLImportJob))
+	v3.Patch("/follow/{feed_id}", apiCfg.middlewareAuth(apiCfg.handlerUpdateFollow))
 	v3.Delete("/follow/{feed_id}", apiCfg.middlewareAuth(apiCfg.handlerUnfollow))
+	v3.Post("/follow/{feed_id}/restore", apiCfg.middlewareAuth(apiCfg.handlerRestoreFollow))
+	v3.Put("/follow/{feed_id}/folder", apiCfg.middlewareAuth(apiCfg.handlerSetFollowFolder))
+	v3.Post("/folders", apiCfg.middlewareAuth(apiCfg.handlerCreateFolder))
+	v3.Get("/folders", apiCfg.middlewareAuth(apiCfg.handlerGetFolders))
+	v3.Put("/folders/{folder_id}", apiCfg.middlewareAuth(apiCfg.handlerUpdateFolder))
+	v3.Delete("/folders/{folder_id}", apiCfg.middlewareAuth(apiCfg.handlerDeleteFolder))
 
 	v4 := chi.NewRouter()
 	v4.Get("/posts", apiCfg.middlewareAuth(apiCfg.handlerGetPosts))
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// GitHub flavoured Markdown, raw HTML in the source is left out of the output
+// rather than passed through
+var markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// renderMarkdown turns the Markdown body of a post into sanitized HTML
+func renderMarkdown(source string) (string, error) {
+	var buf bytes.Buffer
+	err := markdown.Convert([]byte(source), &buf)
+	if err != nil {
+		return "", err
+	}
+	return sanitizeHTML(buf.String(), ""), nil
+}
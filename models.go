@@ -1,12 +1,42 @@
 package main
 
 import (
+	"database/sql"
+	"encoding/json"
 	"project_1/internal/database"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+func nullStringToPtr(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
+
+func nullInt32ToPtr(value sql.NullInt32) *int32 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Int32
+}
+
+func nullTimeToPtr(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Time
+}
+
+func nullUUIDToPtr(value uuid.NullUUID) *uuid.UUID {
+	if !value.Valid {
+		return nil
+	}
+	return &value.UUID
+}
+
 // @name User
 // @description A registered user of the application.
 type User struct {
@@ -35,28 +65,159 @@ func databaseUsertoUser(dbUser database.User) User {
 	}
 }
 
+// @name UserProfile
+// @description A user as other users see them, with the feeds they own.
+type UserProfile struct {
+	ID             uuid.UUID      `json:"id"`              // User ID
+	Name           string         `json:"name"`            // Full name
+	CreatedAt      time.Time      `json:"created_at"`      // Account creation timestamp
+	FeedCount      int64          `json:"feed_count"`      // Feeds owned by the user
+	FollowerCount  int64          `json:"follower_count"`  // Users following the user
+	FollowingCount int64          `json:"following_count"` // Users the user follows
+	Followed       bool           `json:"followed"`        // Whether the authenticated user follows the user
+	Feeds          []FeedListItem `json:"feeds"`           // First page of the owned feeds, by name
+}
+
+func databaseUserProfiletoUserProfile(row database.GetUserProfileRow, feeds []FeedListItem) UserProfile {
+	return UserProfile{
+		ID:             row.ID,
+		Name:           row.Name,
+		CreatedAt:      row.CreatedAt,
+		FeedCount:      row.FeedCount,
+		FollowerCount:  row.FollowerCount,
+		FollowingCount: row.FollowingCount,
+		Followed:       row.Followed,
+		Feeds:          feeds,
+	}
+}
+
+// @name UserFollow
+// @description A user in a followers or following list.
+type UserFollow struct {
+	ID         uuid.UUID `json:"id"`          // User ID
+	Name       string    `json:"name"`        // Full name
+	FollowedAt time.Time `json:"followed_at"` // When the follow started
+}
+
+func databaseFollowerstoUserFollows(rows []database.GetUserFollowersRow) []UserFollow {
+	users := []UserFollow{}
+	for _, row := range rows {
+		users = append(users, UserFollow{ID: row.ID, Name: row.Name, FollowedAt: row.FollowedAt})
+	}
+	return users
+}
+
+func databaseFollowedUserstoUserFollows(rows []database.GetFollowedUsersRow) []UserFollow {
+	users := []UserFollow{}
+	for _, row := range rows {
+		users = append(users, UserFollow{ID: row.ID, Name: row.Name, FollowedAt: row.FollowedAt})
+	}
+	return users
+}
+
+// @name TimelineToken
+// @description Secret token addressing a user's timeline as a feed, with the URLs of its documents.
+type TimelineToken struct {
+	Token   string `json:"token"`    // Secret token, only shown once
+	RSSURL  string `json:"rss_url"`  // RSS 2.0 document
+	AtomURL string `json:"atom_url"` // Atom 1.0 document
+	JSONURL string `json:"json_url"` // JSON Feed 1.1 document
+}
+
 // @name Feed
 // @description Represents an RSS feed followed or owned by a user.
 type Feed struct {
-	ID     uuid.UUID `json:"id"`      // Feed ID
-	Name   string    `json:"name"`    // Feed name
-	UserID uuid.UUID `json:"user_id"` // Owner's user ID
-	URL    string    `json:"url"`     // Feed URL
+	ID          uuid.UUID `json:"id"`          // Feed ID
+	Name        string    `json:"name"`        // Feed name
+	UserID      uuid.UUID `json:"user_id"`     // Owner's user ID
+	URL         string    `json:"url"`         // Feed URL
+	SiteURL     *string   `json:"site_url"`    // Website the feed belongs to
+	Description *string   `json:"description"` // Channel description
+	Language    *string   `json:"language"`    // Channel language
+	ImageURL    *string   `json:"image_url"`   // Channel image or icon
+	TTL         *int32    `json:"ttl"`         // Minutes the publisher asks to cache the feed
+	SkipHours   []int32   `json:"skip_hours"`  // Hours (UTC) the publisher asks not to be fetched
+	Visibility  string    `json:"visibility"`  // public, unlisted or private
+
+	FetchInterval         int32      `json:"fetch_interval"`          // Seconds between two fetches
+	FetchIntervalOverride *int32     `json:"fetch_interval_override"` // Interval set by the owner, null when computed
+	NextFetchAt           *time.Time `json:"next_fetch_at"`           // When the feed is fetched next
+}
+
+// @name FeedListItem
+// @description A feed of the listing with its follower count and latest post.
+type FeedListItem struct {
+	Feed
+	FollowerCount int64      `json:"follower_count"` // Users following the feed
+	LastPostAt    *time.Time `json:"last_post_at"`   // Publication time of the latest post, null without posts
+}
+
+func databaseFeedRowstoFeedListItems(rows []database.ListFeedsRow) []FeedListItem {
+	feeds := []FeedListItem{}
+	for _, row := range rows {
+		feeds = append(feeds, FeedListItem{
+			Feed:          databaseFeedtoFeed(row.Feed),
+			FollowerCount: row.FollowerCount,
+			LastPostAt:    nullTimeToPtr(row.LastPostAt),
+		})
+	}
+	return feeds
+}
+
+// @name FeedDetail
+// @description A feed with its owner and activity.
+type FeedDetail struct {
+	Feed
+	OwnerName     string     `json:"owner_name"`     // Owner's name
+	FollowerCount int64      `json:"follower_count"` // Users following the feed
+	Followed      bool       `json:"followed"`       // Whether the authenticated user follows the feed
+	PostCount     int64      `json:"post_count"`     // Posts saved for the feed
+	LastPostAt    *time.Time `json:"last_post_at"`   // Publication time of the latest post, null without posts
+	LastFetch     *time.Time `json:"last_fetch"`     // Last time the feed was fetched
+}
+
+func databaseFeedDetailtoFeedDetail(row database.GetFeedDetailRow) FeedDetail {
+	return FeedDetail{
+		Feed:          databaseFeedtoFeed(row.Feed),
+		OwnerName:     row.OwnerName,
+		FollowerCount: row.FollowerCount,
+		Followed:      row.Followed,
+		PostCount:     row.PostCount,
+		LastPostAt:    nullTimeToPtr(row.LastPostAt),
+		LastFetch:     nullTimeToPtr(row.Feed.LastFetch),
+	}
 }
 
 // @name FeedInput
 // @description Input model for creating or updating a feed.
 type FeedInput struct {
-	Name string `json:"name"` // Feed name
-	URL  string `json:"url"`  // Feed URL
+	Name          string  `json:"name"`           // Feed name
+	URL           string  `json:"url"`            // Feed URL
+	FetchInterval *int32  `json:"fetch_interval"` // Optional polling interval in seconds, 0 goes back to the computed one
+	Visibility    *string `json:"visibility"`     // Optional public, unlisted or private, public by default
 }
 
 func databaseFeedtoFeed(dbFeed database.Feed) Feed {
+	skipHours := dbFeed.SkipHours
+	if skipHours == nil {
+		skipHours = []int32{}
+	}
 	return Feed{
-		ID:     dbFeed.ID,
-		Name:   dbFeed.Name,
-		UserID: dbFeed.UserID,
-		URL:    dbFeed.Url,
+		ID:          dbFeed.ID,
+		Name:        dbFeed.Name,
+		UserID:      dbFeed.UserID,
+		URL:         dbFeed.Url,
+		SiteURL:     nullStringToPtr(dbFeed.SiteUrl),
+		Description: nullStringToPtr(dbFeed.Description),
+		Language:    nullStringToPtr(dbFeed.Language),
+		ImageURL:    nullStringToPtr(dbFeed.ImageUrl),
+		TTL:         nullInt32ToPtr(dbFeed.Ttl),
+		SkipHours:   skipHours,
+		Visibility:  dbFeed.Visibility,
+
+		FetchInterval:         int32(effectiveFetchInterval(dbFeed) / time.Second),
+		FetchIntervalOverride: nullInt32ToPtr(dbFeed.FetchIntervalOverride),
+		NextFetchAt:           nullTimeToPtr(dbFeed.NextFetchAt),
 	}
 }
 
@@ -68,37 +229,287 @@ func databaseFeedstoFeeds(dbFeed []database.Feed) []Feed {
 	return feeds
 }
 
+// @name Maintainer
+// @description A user who manages a feed besides its owner.
+type Maintainer struct {
+	FeedID    uuid.UUID `json:"feed_id"`    // Feed ID
+	UserID    uuid.UUID `json:"user_id"`    // Maintainer's user ID
+	UserName  string    `json:"user_name"`  // Maintainer's name
+	CreatedAt time.Time `json:"created_at"` // When the user became a maintainer
+}
+
+func databaseMaintainerstoMaintainers(rows []database.GetFeedMaintainersRow) []Maintainer {
+	maintainers := []Maintainer{}
+	for _, row := range rows {
+		maintainers = append(maintainers, Maintainer{
+			FeedID:    row.FeedID,
+			UserID:    row.UserID,
+			UserName:  row.UserName,
+			CreatedAt: row.CreatedAt,
+		})
+	}
+	return maintainers
+}
+
+// @name FeedAccess
+// @description A user allowed to read a private feed.
+type FeedAccess struct {
+	FeedID    uuid.UUID `json:"feed_id"`    // Feed ID
+	UserID    uuid.UUID `json:"user_id"`    // Allowed user's ID
+	UserName  string    `json:"user_name"`  // Allowed user's name
+	CreatedAt time.Time `json:"created_at"` // When the user was given access
+}
+
+func databaseFeedAccesstoFeedAccess(rows []database.GetFeedAccessRow) []FeedAccess {
+	access := []FeedAccess{}
+	for _, row := range rows {
+		access = append(access, FeedAccess{
+			FeedID:    row.FeedID,
+			UserID:    row.UserID,
+			UserName:  row.UserName,
+			CreatedAt: row.CreatedAt,
+		})
+	}
+	return access
+}
+
+// @name FeedInviteInput
+// @description Input model for inviting users to a feed.
+type FeedInviteInput struct {
+	ExpiresAt *time.Time `json:"expires_at"` // Optional time the invite stops working
+	MaxUses   *int32     `json:"max_uses"`   // Optional number of users who can accept the invite
+}
+
+// @name FeedInvite
+// @description An invite link giving access to a feed.
+type FeedInvite struct {
+	ID        uuid.UUID  `json:"id"`         // Invite ID
+	FeedID    uuid.UUID  `json:"feed_id"`    // Feed the invite gives access to
+	Token     *string    `json:"token"`      // Secret to accept the invite with, only returned on creation
+	CreatedAt time.Time  `json:"created_at"` // When the invite was created
+	ExpiresAt *time.Time `json:"expires_at"` // When the invite stops working, never when null
+	MaxUses   *int32     `json:"max_uses"`   // Users who can accept the invite, unlimited when null
+	Uses      int32      `json:"uses"`       // Users who accepted the invite
+}
+
+func databaseFeedInvitetoFeedInvite(dbInvite database.FeedInvite) FeedInvite {
+	var expiresAt *time.Time
+	if dbInvite.ExpiresAt.Valid {
+		expiresAt = &dbInvite.ExpiresAt.Time
+	}
+	var maxUses *int32
+	if dbInvite.MaxUses.Valid {
+		maxUses = &dbInvite.MaxUses.Int32
+	}
+	return FeedInvite{
+		ID:        dbInvite.ID,
+		FeedID:    dbInvite.FeedID,
+		CreatedAt: dbInvite.CreatedAt,
+		ExpiresAt: expiresAt,
+		MaxUses:   maxUses,
+		Uses:      dbInvite.Uses,
+	}
+}
+
+func databaseFeedInvitestoFeedInvites(dbInvites []database.FeedInvite) []FeedInvite {
+	invites := []FeedInvite{}
+	for _, dbInvite := range dbInvites {
+		invites = append(invites, databaseFeedInvitetoFeedInvite(dbInvite))
+	}
+	return invites
+}
+
+// @name OwnershipTransfer
+// @description An owner offering a feed to another user.
+type OwnershipTransfer struct {
+	ID         uuid.UUID `json:"id"`           // Transfer ID
+	FeedID     uuid.UUID `json:"feed_id"`      // Feed changing owner
+	FromUserID uuid.UUID `json:"from_user_id"` // Owner offering the feed
+	ToUserID   uuid.UUID `json:"to_user_id"`   // User the feed is offered to
+	Status     string    `json:"status"`       // pending, accepted, declined or cancelled
+	CreatedAt  time.Time `json:"created_at"`   // When the transfer was offered
+	UpdatedAt  time.Time `json:"updated_at"`   // When the status last changed
+}
+
+func databaseTransfertoTransfer(dbTransfer database.FeedOwnershipTransfer) OwnershipTransfer {
+	return OwnershipTransfer{
+		ID:         dbTransfer.ID,
+		FeedID:     dbTransfer.FeedID,
+		FromUserID: dbTransfer.FromUserID,
+		ToUserID:   dbTransfer.ToUserID,
+		Status:     dbTransfer.Status,
+		CreatedAt:  dbTransfer.CreatedAt,
+		UpdatedAt:  dbTransfer.UpdatedAt,
+	}
+}
+
+func databaseTransferstoTransfers(dbTransfers []database.FeedOwnershipTransfer) []OwnershipTransfer {
+	transfers := []OwnershipTransfer{}
+	for _, dbTransfer := range dbTransfers {
+		transfers = append(transfers, databaseTransfertoTransfer(dbTransfer))
+	}
+	return transfers
+}
+
 // @name Follow
 // @description A follow relationship between a user and a feed.
 type Follow struct {
-	UserID uuid.UUID `json:"user_id"` // ID of the user
-	FeedID uuid.UUID `json:"feed_id"` // ID of the followed feed
+	UserID   uuid.UUID  `json:"user_id"`   // ID of the user
+	FeedID   uuid.UUID  `json:"feed_id"`   // ID of the followed feed
+	FolderID *uuid.UUID `json:"folder_id"` // Folder of the follow, null when it is in none
+	Title    *string    `json:"title"`     // Display title replacing the feed name for this user
+	Muted    bool       `json:"muted"`     // Muted feeds are left out of the default timeline
+	Priority int32      `json:"priority"`  // Follows are listed by priority, highest first
+}
+
+// @name FollowSettingsInput
+// @description Input model for changing the settings of a follow, missing fields are kept.
+type FollowSettingsInput struct {
+	Title    *string `json:"title"`    // Display title, blank to use the feed name again
+	Muted    *bool   `json:"muted"`    // Leave the feed out of the default timeline
+	Priority *int32  `json:"priority"` // Priority weight
 }
 
 func databaseFollowtoFollow(dbFeed database.FeedFollow) Follow {
 	return Follow{
-		UserID: dbFeed.UserID,
-		FeedID: dbFeed.FeedID,
+		UserID:   dbFeed.UserID,
+		FeedID:   dbFeed.FeedID,
+		FolderID: nullUUIDToPtr(dbFeed.FolderID),
+		Title:    nullStringToPtr(dbFeed.Title),
+		Muted:    dbFeed.Muted,
+		Priority: dbFeed.Priority,
 	}
 }
 
-func databaseFollowstoFollows(dbFeed []database.FeedFollow) []Follow {
-	follows := []Follow{}
-	for _, dbFedbFeed := range dbFeed {
-		follows = append(follows, databaseFollowtoFollow(dbFedbFeed))
+// @name FollowedFeed
+// @description The feed of a follow and its owner.
+type FollowedFeed struct {
+	ID        uuid.UUID `json:"id"`         // Feed ID
+	Name      string    `json:"name"`       // Feed name
+	URL       string    `json:"url"`        // Feed URL
+	OwnerID   uuid.UUID `json:"owner_id"`   // Owner's user ID
+	OwnerName string    `json:"owner_name"` // Owner's name
+}
+
+// @name FollowDetail
+// @description A follow with its feed and the feed's activity.
+type FollowDetail struct {
+	Follow
+	Feed       FollowedFeed `json:"feed"`         // Followed feed
+	PostCount  int64        `json:"post_count"`   // Posts saved for the feed
+	LastPostAt *time.Time   `json:"last_post_at"` // Publication time of the latest post, null without posts
+}
+
+func databaseFollowRowstoFollowDetails(rows []database.GetFollowsRow) []FollowDetail {
+	follows := []FollowDetail{}
+	for _, row := range rows {
+		follows = append(follows, FollowDetail{
+			Follow: databaseFollowtoFollow(database.FeedFollow{
+				ID:       row.ID,
+				UserID:   row.UserID,
+				FeedID:   row.FeedID,
+				FolderID: row.FolderID,
+				Title:    row.Title,
+				Muted:    row.Muted,
+				Priority: row.Priority,
+			}),
+			Feed: FollowedFeed{
+				ID:        row.FeedID,
+				Name:      row.FeedName,
+				URL:       row.FeedUrl,
+				OwnerID:   row.OwnerID,
+				OwnerName: row.OwnerName,
+			},
+			PostCount:  row.PostCount,
+			LastPostAt: nullTimeToPtr(row.LastPostAt),
+		})
 	}
 	return follows
 }
 
+// @name Folder
+// @description A folder the user sorts followed feeds into.
+type Folder struct {
+	ID        uuid.UUID `json:"id"`         // Folder ID
+	Name      string    `json:"name"`       // Folder name, unique per user
+	Position  int32     `json:"position"`   // Folders are listed by position, then name
+	CreatedAt time.Time `json:"created_at"` // Creation timestamp
+	UpdatedAt time.Time `json:"updated_at"` // Last update timestamp
+}
+
+// @name FolderInput
+// @description Input model for creating or updating a folder.
+type FolderInput struct {
+	Name     string `json:"name"`     // Folder name
+	Position *int32 `json:"position"` // Optional position, new folders go last
+}
+
+func databaseFoldertoFolder(dbFolder database.Folder) Folder {
+	return Folder{
+		ID:        dbFolder.ID,
+		Name:      dbFolder.Name,
+		Position:  dbFolder.Position,
+		CreatedAt: dbFolder.CreatedAt,
+		UpdatedAt: dbFolder.UpdatedAt,
+	}
+}
+
+func databaseFolderstoFolders(dbFolders []database.Folder) []Folder {
+	folders := []Folder{}
+	for _, dbFolder := range dbFolders {
+		folders = append(folders, databaseFoldertoFolder(dbFolder))
+	}
+	return folders
+}
+
 // @name Post
 // @description A post from an RSS feed.
 type Post struct {
-	ID          uuid.UUID `json:"id"`           // Post ID
-	Title       string    `json:"title"`        // Post title
-	Description *string   `json:"description"`  // Post description
-	PublishedAt time.Time `json:"published_at"` // Publication timestamp
-	Url         string    `json:"url"`          // Post URL
-	FeedID      uuid.UUID `json:"feed_id"`      // Associated feed ID
+	ID          uuid.UUID   `json:"id"`           // Post ID
+	Title       string      `json:"title"`        // Post title
+	Description *string     `json:"description"`  // Post description, sanitized HTML
+	Summary     *string     `json:"summary"`      // Plain text excerpt of the description
+	PublishedAt time.Time   `json:"published_at"` // Publication timestamp
+	Url         string      `json:"url"`          // Post URL
+	FeedID      uuid.UUID   `json:"feed_id"`      // Associated feed ID
+	GUID        *string     `json:"guid"`         // Publisher's unique ID for the item
+	Author      *string     `json:"author"`       // Author or dc:creator
+	Categories  []string    `json:"categories"`   // Category tags
+	Content     *string     `json:"content"`      // Full sanitized HTML from content:encoded
+	Enclosures  []Enclosure `json:"enclosures"`   // Attached media
+	UpdatedAt   time.Time   `json:"updated_at"`   // Last time the publisher changed the item
+	Revision    int32       `json:"revision"`     // Number of versions seen, starting at 1
+	Manual      bool        `json:"manual"`       // Written through the API rather than scraped
+	Body        *string     `json:"body"`         // Markdown source of a manual post
+}
+
+// @name PostInput
+// @description Input model for writing a post on a feed.
+type PostInput struct {
+	Title     string     `json:"title"`      // Post title
+	Body      string     `json:"body"`       // Markdown, rendered to sanitized HTML as the description
+	URL       string     `json:"url"`        // Optional link of the post
+	PublishAt *time.Time `json:"publish_at"` // Publication time, a future time schedules the post. Now when missing on creation, unchanged on update
+}
+
+// @name Enclosure
+// @description A media file attached to a post.
+type Enclosure struct {
+	URL    string  `json:"url"`    // Media URL
+	Type   *string `json:"type"`   // Media type
+	Length *int64  `json:"length"` // Size in bytes
+}
+
+func databaseEnclosuretoEnclosure(dbEnclosure database.PostEnclosure) Enclosure {
+	var length *int64
+	if dbEnclosure.Length.Valid {
+		length = &dbEnclosure.Length.Int64
+	}
+	return Enclosure{
+		URL:    dbEnclosure.Url,
+		Type:   nullStringToPtr(dbEnclosure.Type),
+		Length: length,
+	}
 }
 
 func databasePosttoPost(dbPost database.Post) Post {
@@ -106,25 +517,205 @@ func databasePosttoPost(dbPost database.Post) Post {
 	if dbPost.Description.Valid {
 		description = &dbPost.Description.String
 	}
+	categories := dbPost.Categories
+	if categories == nil {
+		categories = []string{}
+	}
 
 	return Post{
 		ID:          dbPost.ID,
 		Title:       dbPost.Title,
 		Description: description,
+		Summary:     nullStringToPtr(dbPost.Summary),
 		PublishedAt: dbPost.PublishedAt,
 		Url:         dbPost.Url,
 		FeedID:      dbPost.FeedID,
+		GUID:        nullStringToPtr(dbPost.Guid),
+		Author:      nullStringToPtr(dbPost.Author),
+		Categories:  categories,
+		Content:     nullStringToPtr(dbPost.Content),
+		Enclosures:  []Enclosure{},
+		UpdatedAt:   dbPost.UpdatedAt,
+		Revision:    dbPost.Revision,
+		Manual:      dbPost.Manual,
+		Body:        nullStringToPtr(dbPost.Body),
 	}
 }
 
-func databasePoststoPosts(dbPost []database.Post) []Post {
+// databasePoststoPosts converts posts and attaches their enclosures
+func databasePoststoPosts(dbPost []database.Post, dbEnclosures []database.PostEnclosure) []Post {
+	enclosures := map[uuid.UUID][]Enclosure{}
+	for _, dbEnclosure := range dbEnclosures {
+		enclosures[dbEnclosure.PostID] = append(enclosures[dbEnclosure.PostID], databaseEnclosuretoEnclosure(dbEnclosure))
+	}
 	posts := []Post{}
 	for _, dbPost := range dbPost {
-		posts = append(posts, databasePosttoPost(dbPost))
+		post := databasePosttoPost(dbPost)
+		if postEnclosures, ok := enclosures[dbPost.ID]; ok {
+			post.Enclosures = postEnclosures
+		}
+		posts = append(posts, post)
+	}
+	return posts
+}
+
+// postsAsText replaces the HTML description and content of posts with their text
+func postsAsText(posts []Post) []Post {
+	for i, post := range posts {
+		if post.Description != nil {
+			text := htmlToText(*post.Description)
+			posts[i].Description = &text
+		}
+		if post.Content != nil {
+			text := htmlToText(*post.Content)
+			posts[i].Content = &text
+		}
 	}
 	return posts
 }
 
+// @name RefreshJob
+// @description An on-demand fetch of a feed and its outcome.
+type RefreshJob struct {
+	ID           uuid.UUID  `json:"id"`            // Job ID
+	FeedID       uuid.UUID  `json:"feed_id"`       // ID of the refreshed feed
+	Status       string     `json:"status"`        // queued, running, succeeded or failed
+	Error        *string    `json:"error"`         // Why the fetch failed
+	NewPosts     int32      `json:"new_posts"`     // Posts added by the fetch
+	UpdatedPosts int32      `json:"updated_posts"` // Posts whose content changed
+	CreatedAt    time.Time  `json:"created_at"`    // When the refresh was asked for
+	StartedAt    *time.Time `json:"started_at"`    // When a worker picked the job up
+	FinishedAt   *time.Time `json:"finished_at"`   // When the job ended
+}
+
+func databaseRefreshJobtoRefreshJob(dbJob database.FeedRefreshJob) RefreshJob {
+	return RefreshJob{
+		ID:           dbJob.ID,
+		FeedID:       dbJob.FeedID,
+		Status:       dbJob.Status,
+		Error:        nullStringToPtr(dbJob.Error),
+		NewPosts:     dbJob.NewPosts,
+		UpdatedPosts: dbJob.UpdatedPosts,
+		CreatedAt:    dbJob.CreatedAt,
+		StartedAt:    nullTimeToPtr(dbJob.StartedAt),
+		FinishedAt:   nullTimeToPtr(dbJob.FinishedAt),
+	}
+}
+
+// @name FetchRun
+// @description One fetch of a feed in its history.
+type FetchRun struct {
+	ID              uuid.UUID `json:"id"`               // Run ID
+	Source          string    `json:"source"`           // scheduled, refresh or push
+	StartedAt       time.Time `json:"started_at"`       // When the fetch started
+	FinishedAt      time.Time `json:"finished_at"`      // When the fetch ended
+	HTTPStatus      *int32    `json:"http_status"`      // Status answered by the publisher, null for pushes and network errors
+	Bytes           int64     `json:"bytes"`            // Size of the decoded document
+	CompressedBytes int64     `json:"compressed_bytes"` // Bytes transferred, smaller than bytes when the publisher compressed it
+	ItemsSeen       int32     `json:"items_seen"`       // Items in the document
+	NewPosts        int32     `json:"new_posts"`        // Posts added
+	UpdatedPosts    int32     `json:"updated_posts"`    // Posts whose content changed
+	Error           *string   `json:"error"`            // Why the fetch failed
+}
+
+func databaseFetchRunstoFetchRuns(dbRuns []database.FeedFetchRun) []FetchRun {
+	runs := []FetchRun{}
+	for _, dbRun := range dbRuns {
+		runs = append(runs, FetchRun{
+			ID:              dbRun.ID,
+			Source:          dbRun.Source,
+			StartedAt:       dbRun.StartedAt,
+			FinishedAt:      dbRun.FinishedAt,
+			HTTPStatus:      nullInt32ToPtr(dbRun.HttpStatus),
+			Bytes:           dbRun.Bytes,
+			CompressedBytes: dbRun.CompressedBytes,
+			ItemsSeen:       dbRun.ItemsSeen,
+			NewPosts:        dbRun.NewPosts,
+			UpdatedPosts:    dbRun.UpdatedPosts,
+			Error:           nullStringToPtr(dbRun.Error),
+		})
+	}
+	return runs
+}
+
+// @name OPMLImportEntry
+// @description What happened to one feed of an imported OPML file.
+type OPMLImportEntry struct {
+	URL    string     `json:"url"`             // xmlUrl of the outline
+	Title  string     `json:"title"`           // Title of the outline
+	Folder string     `json:"folder"`          // Folder the outline was nested in
+	Status string     `json:"status"`          // created, followed, already_followed, duplicate or failed
+	FeedID *uuid.UUID `json:"feed_id"`         // Feed the entry resolved to
+	Error  string     `json:"error,omitempty"` // Why the entry failed
+}
+
+func newOPMLImportEntry(entry opmlEntry, status string) OPMLImportEntry {
+	return OPMLImportEntry{
+		URL:    entry.URL,
+		Title:  entry.Title,
+		Folder: entry.Folder,
+		Status: status,
+	}
+}
+
+// @name OPMLImportReport
+// @description Outcome of an OPML import.
+type OPMLImportReport struct {
+	Created         int               `json:"created"`          // Feeds created and followed
+	Followed        int               `json:"followed"`         // Existing feeds followed
+	AlreadyFollowed int               `json:"already_followed"` // Feeds that were followed already
+	Failed          int               `json:"failed"`           // Entries that could not be imported
+	Entries         []OPMLImportEntry `json:"entries"`          // One entry per outline, in file order
+}
+
+func newOPMLImportReport(entries []OPMLImportEntry) OPMLImportReport {
+	report := OPMLImportReport{Entries: entries}
+	for _, entry := range entries {
+		switch entry.Status {
+		case opmlCreated:
+			report.Created++
+		case opmlFollowed:
+			report.Followed++
+		case opmlAlreadyFollowed:
+			report.AlreadyFollowed++
+		case opmlFailed:
+			report.Failed++
+		}
+	}
+	return report
+}
+
+// @name OPMLImportJob
+// @description An OPML import running in the background and its outcome.
+type OPMLImportJob struct {
+	ID         uuid.UUID         `json:"id"`          // Job ID
+	Status     string            `json:"status"`      // queued, running, succeeded or failed
+	Error      *string           `json:"error"`       // Why the import failed as a whole
+	Report     *OPMLImportReport `json:"report"`      // What happened to each feed, null until the import succeeded
+	CreatedAt  time.Time         `json:"created_at"`  // When the file was uploaded
+	StartedAt  *time.Time        `json:"started_at"`  // When a worker picked the import up
+	FinishedAt *time.Time        `json:"finished_at"` // When the import ended
+}
+
+func databaseOPMLImportJobtoOPMLImportJob(dbJob database.OpmlImportJob) OPMLImportJob {
+	job := OPMLImportJob{
+		ID:         dbJob.ID,
+		Status:     dbJob.Status,
+		Error:      nullStringToPtr(dbJob.Error),
+		CreatedAt:  dbJob.CreatedAt,
+		StartedAt:  nullTimeToPtr(dbJob.StartedAt),
+		FinishedAt: nullTimeToPtr(dbJob.FinishedAt),
+	}
+	if dbJob.Status == opmlImportSucceeded {
+		entries := []OPMLImportEntry{}
+		if err := json.Unmarshal(dbJob.Results, &entries); err == nil {
+			report := newOPMLImportReport(entries)
+			job.Report = &report
+		}
+	}
+	return job
+}
+
 // @name LoginResponse
 // @description Token response after successful login.
 type LoginResponse struct {
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Biggest OPML file accepted on import
+const maxOPMLSize = 5 << 20
+
+// Feeds imported from one file at most
+const maxOPMLEntries = 500
+
+// Feeds looked up at the same time while importing
+const opmlImportConcurrency = 5
+
+var errNotOPML = errors.New("document is not an OPML file")
+
+type OPML struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    struct {
+		Title       string `xml:"title,omitempty"`
+		DateCreated string `xml:"dateCreated,omitempty"`
+	} `xml:"head"`
+	Body struct {
+		Outlines []OPMLOutline `xml:"outline"`
+	} `xml:"body"`
+}
+
+// OPMLOutline is either a feed, when it has an xmlUrl, or a folder holding
+// other outlines
+type OPMLOutline struct {
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr,omitempty"`
+	Type     string        `xml:"type,attr,omitempty"`
+	XMLURL   string        `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string        `xml:"htmlUrl,attr,omitempty"`
+	Outlines []OPMLOutline `xml:"outline"`
+}
+
+// opmlEntry is one feed found in an OPML file
+type opmlEntry struct {
+	URL    string `json:"url"`
+	Title  string `json:"title"`
+	Folder string `json:"folder"`
+}
+
+// parseOPML returns the feeds of an OPML file in document order, each with the
+// folder it was nested in. Nested folders are joined with "/".
+func parseOPML(data []byte) ([]opmlEntry, error) {
+	data = toUTF8(data, "")
+	if rootElement(data) != "opml" {
+		return nil, errNotOPML
+	}
+	doc := OPML{}
+	err := newXMLDecoder(data).Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+	entries := []opmlEntry{}
+	var walk func(outlines []OPMLOutline, folder string)
+	walk = func(outlines []OPMLOutline, folder string) {
+		for _, outline := range outlines {
+			title := strings.TrimSpace(outline.Title)
+			if title == "" {
+				title = strings.TrimSpace(outline.Text)
+			}
+			if url := strings.TrimSpace(outline.XMLURL); url != "" {
+				entries = append(entries, opmlEntry{URL: url, Title: title, Folder: folder})
+				continue
+			}
+			child := title
+			if folder != "" && title != "" {
+				child = folder + "/" + title
+			} else if title == "" {
+				child = folder
+			}
+			walk(outline.Outlines, child)
+		}
+	}
+	walk(doc.Body.Outlines, "")
+	return entries, nil
+}
+
+// opmlFeed is one followed feed written to an export
+type opmlFeed struct {
+	Title   string
+	URL     string
+	SiteURL string
+	Folder  string
+}
+
+// encodeOPML writes an OPML 2.0 document, feeds with a folder are nested in
+// an outline named after it, folders appear in the order they are first seen
+func encodeOPML(title string, feeds []opmlFeed) ([]byte, error) {
+	doc := OPML{Version: "2.0"}
+	doc.Head.Title = title
+	doc.Head.DateCreated = time.Now().UTC().Format(time.RFC1123Z)
+
+	folders := map[string]int{}
+	for _, feed := range feeds {
+		outline := OPMLOutline{
+			Text:    feed.Title,
+			Title:   feed.Title,
+			Type:    "rss",
+			XMLURL:  feed.URL,
+			HTMLURL: feed.SiteURL,
+		}
+		if feed.Folder == "" {
+			doc.Body.Outlines = append(doc.Body.Outlines, outline)
+			continue
+		}
+		i, ok := folders[feed.Folder]
+		if !ok {
+			i = len(doc.Body.Outlines)
+			folders[feed.Folder] = i
+			doc.Body.Outlines = append(doc.Body.Outlines, OPMLOutline{Text: feed.Folder, Title: feed.Folder})
+		}
+		doc.Body.Outlines[i].Outlines = append(doc.Body.Outlines[i].Outlines, outline)
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(bytes.TrimSpace(data), '\n')...), nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"project_1/internal/database"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status of a finished import job, queued and running are set by the queries
+const (
+	opmlImportSucceeded = "succeeded"
+	opmlImportFailed    = "failed"
+)
+
+// An import running longer than this is given up and picked up again by a
+// worker. Every entry may need a feed to be discovered, which takes a few seconds
+const opmlImportJobTimeout = 15 * time.Minute
+
+// Queued imports are looked for this often even when nobody wakes the worker,
+// so imports queued by other instances are run as well
+const opmlImportPollInterval = 5 * time.Second
+
+// Wakes the import worker up as soon as an import is queued
+var opmlImportQueue = make(chan struct{}, 1)
+
+// wakeOPMLImportWorker tells the worker an import is waiting, it never blocks
+func wakeOPMLImportWorker() {
+	select {
+	case opmlImportQueue <- struct{}{}:
+	default:
+	}
+}
+
+// startOPMLImportWorker runs queued OPML imports, at most concurrency at a time
+func (apiCfg *apiConfig) startOPMLImportWorker(concurrency int) {
+	slots := make(chan struct{}, concurrency)
+	ticker := time.NewTicker(opmlImportPollInterval)
+	for {
+		for {
+			slots <- struct{}{}
+			job, err := apiCfg.DB.ClaimNextOPMLImportJob(context.Background(), int32(opmlImportJobTimeout/time.Second))
+			if err != nil {
+				<-slots
+				if !errors.Is(err, sql.ErrNoRows) {
+					log.Printf("Error claiming OPML import: %v", err)
+				}
+				break
+			}
+			go func() {
+				defer func() { <-slots }()
+				apiCfg.runOPMLImportJob(job)
+			}()
+		}
+		select {
+		case <-opmlImportQueue:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runOPMLImportJob imports the entries of a job and records what happened to each
+func (apiCfg *apiConfig) runOPMLImportJob(job database.OpmlImportJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), opmlImportJobTimeout)
+	defer cancel()
+
+	params := database.FinishOPMLImportJobParams{ID: job.ID, Status: opmlImportSucceeded}
+	results, err := apiCfg.importOPMLJobEntries(ctx, job)
+	if err == nil {
+		params.Results, err = json.Marshal(results)
+	}
+	if err != nil {
+		log.Printf("Error importing OPML %v: %v", job.ID, err)
+		params.Status = opmlImportFailed
+		params.Error = sql.NullString{String: err.Error(), Valid: true}
+		params.Results = json.RawMessage("[]")
+	}
+	// The job context may be over, the outcome is still saved
+	_, err = apiCfg.DB.FinishOPMLImportJob(context.Background(), params)
+	if err != nil {
+		log.Printf("Error finishing OPML import %v: %v", job.ID, err)
+	}
+}
+
+// importOPMLJobEntries imports the entries saved with a job for its user
+func (apiCfg *apiConfig) importOPMLJobEntries(ctx context.Context, job database.OpmlImportJob) ([]OPMLImportEntry, error) {
+	entries := []opmlEntry{}
+	err := json.Unmarshal(job.Entries, &entries)
+	if err != nil {
+		return nil, err
+	}
+	user, err := apiCfg.DB.GetUserByID(ctx, job.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return apiCfg.importOPML(ctx, user, entries)
+}
+
+// importOPML follows every entry, creating the feeds and folders missing. The
+// result has one entry per entry of the file
+func (apiCfg *apiConfig) importOPML(ctx context.Context, user database.User, entries []opmlEntry) ([]OPMLImportEntry, error) {
+	// Folders are created up front so entries sharing one don't race for it
+	folders := map[string]uuid.NullUUID{}
+	for _, entry := range entries {
+		if _, ok := folders[entry.Folder]; ok || entry.Folder == "" {
+			continue
+		}
+		folder, err := apiCfg.findOrCreateFolder(ctx, user, entry.Folder)
+		if err != nil {
+			return nil, err
+		}
+		folders[entry.Folder] = uuid.NullUUID{UUID: folder.ID, Valid: true}
+	}
+
+	results := make([]OPMLImportEntry, len(entries))
+	seen := map[string]bool{}
+	slots := make(chan struct{}, opmlImportConcurrency)
+	wg := &sync.WaitGroup{}
+	for i, entry := range entries {
+		if seen[entry.URL] {
+			results[i] = newOPMLImportEntry(entry, opmlDuplicate)
+			continue
+		}
+		seen[entry.URL] = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			results[i] = apiCfg.importOPMLEntry(ctx, user, entry, folders[entry.Folder])
+		}()
+	}
+	wg.Wait()
+	return results, nil
+}
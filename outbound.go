@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Feeds, pages and hubs on loopback, link-local and private addresses are
+// refused so users can't point the server at internal services. Set with
+// ALLOW_PRIVATE_FEEDS, for local setups and the tests' feed server
+var allowPrivateFeeds = false
+
+var errPrivateAddress = errors.New("address is not public")
+
+// Ranges that IsGlobalUnicast and IsPrivate let through but aren't reachable
+// on the internet: "this network", which Linux routes to the host, and the
+// carrier-grade NAT space used inside clouds
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// outboundTransport is the transport of every request to a URL that came
+// from a user or a feed. Addresses are checked when the connection is made,
+// after DNS resolution, so redirects and hostnames resolving to private
+// addresses are caught as well. No proxy is used, it would dial for us
+var outboundTransport = &http.Transport{
+	DialContext: (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   publicAddressControl,
+	}).DialContext,
+	ForceAttemptHTTP2:     true,
+	MaxIdleConns:          100,
+	IdleConnTimeout:       90 * time.Second,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ExpectContinueTimeout: time.Second,
+}
+
+// allowPrivateFeedsFromEnv reads ALLOW_PRIVATE_FEEDS
+func allowPrivateFeedsFromEnv() error {
+	if value := os.Getenv("ALLOW_PRIVATE_FEEDS"); value != "" {
+		allow, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid ALLOW_PRIVATE_FEEDS value: %v", value)
+		}
+		allowPrivateFeeds = allow
+	}
+	return nil
+}
+
+// publicAddressControl refuses to connect to an address that isn't public
+func publicAddressControl(network string, address string, _ syscall.RawConn) error {
+	if allowPrivateFeeds {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !isPublicAddress(ip) {
+		return fmt.Errorf("%w: %v", errPrivateAddress, ip)
+	}
+	return nil
+}
+
+func isPublicAddress(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestIsPublicAddress(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:93.184.216.34", true},
+	}
+	for _, test := range tests {
+		if got := isPublicAddress(netip.MustParseAddr(test.ip)); got != test.want {
+			t.Errorf("isPublicAddress(%v) = %v, want %v", test.ip, got, test.want)
+		}
+	}
+}
+
+func TestOutboundTransportRefusesLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal"))
+	}))
+	defer server.Close()
+
+	_, _, _, err := fetchDocument(context.Background(), server.URL)
+	if !errors.Is(err, errPrivateAddress) {
+		t.Fatalf("fetched %v, err %v, want %v", server.URL, err, errPrivateAddress)
+	}
+
+	allowPrivateFeeds = true
+	t.Cleanup(func() { allowPrivateFeeds = false })
+	data, _, _, err := fetchDocument(context.Background(), server.URL)
+	if err != nil || string(data) != "internal" {
+		t.Fatalf("fetched %q, err %v with ALLOW_PRIVATE_FEEDS", data, err)
+	}
+}
+
+func TestOutboundTransportRefusesRedirectToLoopback(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal"))
+	}))
+	defer internal.Close()
+	// The first hop is allowed, the redirect it answers with must not be
+	allowPrivateFeeds = true
+	t.Cleanup(func() { allowPrivateFeeds = false })
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowPrivateFeeds = false
+		http.Redirect(w, r, internal.URL, http.StatusFound)
+	}))
+	defer redirect.Close()
+
+	_, _, _, err := fetchDocument(context.Background(), redirect.URL)
+	if !errors.Is(err, errPrivateAddress) {
+		t.Fatalf("followed the redirect to %v, err %v", internal.URL, err)
+	}
+}
+
+func TestDiscoverFeedRefusesPrivateFeedLink(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(`<rss version="2.0"><channel><title>internal</title></channel></rss>`))
+	}))
+	defer internal.Close()
+	// The page is allowed, the feed it links to must not be
+	allowPrivateFeeds = true
+	t.Cleanup(func() { allowPrivateFeeds = false })
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowPrivateFeeds = false
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><link rel="alternate" type="application/rss+xml" href="` + internal.URL + `"></head></html>`))
+	}))
+	defer page.Close()
+
+	_, _, err := discoverFeed(context.Background(), page.URL)
+	if !errors.Is(err, errPrivateAddress) {
+		t.Fatalf("discovered %v, err %v, want %v", internal.URL, err, errPrivateAddress)
+	}
+}
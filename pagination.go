@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Page size of the paginated listings when the client doesn't ask for one
+const defaultPageSize = 20
+
+// Biggest page a client can ask for
+const maxPageSize = 100
+
+// page is the slice of a listing asked for through ?page= and ?limit=
+type page struct {
+	Number int // 1-based
+	Size   int
+}
+
+func (p page) offset() int64 {
+	return int64((p.Number - 1) * p.Size)
+}
+
+// pageFromRequest reads ?page= and ?limit=, it returns the error message to
+// answer with when either is invalid
+func pageFromRequest(r *http.Request) (page, string) {
+	p := page{Number: 1, Size: defaultPageSize}
+	if value := r.URL.Query().Get("page"); value != "" {
+		number, err := strconv.Atoi(value)
+		if err != nil || number < 1 {
+			return page{}, "Invalid page"
+		}
+		p.Number = number
+	}
+	if value := r.URL.Query().Get("limit"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 1 || size > maxPageSize {
+			return page{}, "Invalid limit"
+		}
+		p.Size = size
+	}
+	return p, ""
+}
+
+// setPageHeaders sets X-Total-Count and a Link header pointing at the
+// previous and next pages, built from the request's own query
+func setPageHeaders(w http.ResponseWriter, r *http.Request, p page, total int64) {
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	links := []string{}
+	link := func(number int, rel string) {
+		query := r.URL.Query()
+		query.Set("page", strconv.Itoa(number))
+		query.Set("limit", strconv.Itoa(p.Size))
+		links = append(links, fmt.Sprintf(`<%v?%v>; rel="%v"`, r.URL.Path, query.Encode(), rel))
+	}
+	if p.Number > 1 {
+		link(p.Number-1, "prev")
+	}
+	if int64(p.Number*p.Size) < total {
+		link(p.Number+1, "next")
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
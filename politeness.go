@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// User agent sent by the scraper, also the name looked up in robots.txt
+const userAgent = "GO-Book-Project/1.0 (+https://github.com/Darkred69/GO-Book-Project)"
+
+const robotsAgent = "go-book-project"
+
+// robots.txt files are fetched again after this long
+const robotsTTL = 24 * time.Hour
+
+// A fetch waiting longer than this for its host is skipped until the next round
+const maxHostWait = 30 * time.Second
+
+var errDisallowedByRobots = errors.New("fetching is disallowed by robots.txt")
+
+var errHostBackingOff = errors.New("host asked to retry later")
+
+// How long a host answering 429 or 503 without a Retry-After header is left alone
+const defaultHostBackoff = time.Minute
+
+// Politeness settings of the scraper, see SCRAPER_* in the README
+var hostPolicy = newHostLimiter(2, time.Second, true)
+
+// hostLimiterFromEnv builds the politeness settings from SCRAPER_MAX_PER_HOST,
+// SCRAPER_HOST_DELAY and SCRAPER_RESPECT_ROBOTS
+func hostLimiterFromEnv() (*hostLimiter, error) {
+	maxPerHost, minDelay, respectRobots := 2, time.Second, true
+	var err error
+	if value := os.Getenv("SCRAPER_MAX_PER_HOST"); value != "" {
+		maxPerHost, err = strconv.Atoi(value)
+		if err != nil || maxPerHost < 1 {
+			return nil, fmt.Errorf("invalid SCRAPER_MAX_PER_HOST value: %v", value)
+		}
+	}
+	if value := os.Getenv("SCRAPER_HOST_DELAY"); value != "" {
+		minDelay, err = time.ParseDuration(value)
+		if err != nil || minDelay < 0 {
+			return nil, fmt.Errorf("invalid SCRAPER_HOST_DELAY value: %v", value)
+		}
+	}
+	if value := os.Getenv("SCRAPER_RESPECT_ROBOTS"); value != "" {
+		respectRobots, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SCRAPER_RESPECT_ROBOTS value: %v", value)
+		}
+	}
+	return newHostLimiter(maxPerHost, minDelay, respectRobots), nil
+}
+
+// robotsRules are the Allow/Disallow lines of the robots.txt group that
+// applies to us
+type robotsRules struct {
+	allow      []string
+	disallow   []string
+	crawlDelay time.Duration
+}
+
+// allowed applies the longest matching rule, Allow wins ties
+func (rules *robotsRules) allowed(path string) bool {
+	if rules == nil {
+		return true
+	}
+	allowLen, disallowLen := -1, -1
+	for _, prefix := range rules.allow {
+		if strings.HasPrefix(path, prefix) && len(prefix) > allowLen {
+			allowLen = len(prefix)
+		}
+	}
+	for _, prefix := range rules.disallow {
+		if strings.HasPrefix(path, prefix) && len(prefix) > disallowLen {
+			disallowLen = len(prefix)
+		}
+	}
+	return disallowLen < 0 || allowLen >= disallowLen
+}
+
+// robotsProductToken is the name a User-agent line gives, compared without
+// case and without the version some sites add ("Go-Book-Project/1.0")
+func robotsProductToken(value string) string {
+	token, _, _ := strings.Cut(strings.TrimSpace(value), "/")
+	return strings.ToLower(strings.TrimSpace(token))
+}
+
+// parseRobots reads the group naming our product token, or the * group when
+// there is none, as robots.txt (RFC 9309) asks. Groups naming the same agent
+// are merged. Wildcards inside paths are not supported and are matched literally.
+func parseRobots(r io.Reader) *robotsRules {
+	groups := map[string]*robotsRules{}
+	var current []*robotsRules
+	inAgents := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if key == "user-agent" {
+			if !inAgents {
+				current = nil
+			}
+			inAgents = true
+			agent := robotsProductToken(value)
+			if groups[agent] == nil {
+				groups[agent] = &robotsRules{}
+			}
+			current = append(current, groups[agent])
+			continue
+		}
+		inAgents = false
+		for _, rules := range current {
+			switch key {
+			case "allow":
+				if value != "" {
+					rules.allow = append(rules.allow, value)
+				}
+			case "disallow":
+				if value != "" {
+					rules.disallow = append(rules.disallow, value)
+				}
+			case "crawl-delay":
+				if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+					rules.crawlDelay = time.Duration(seconds * float64(time.Second))
+				}
+			}
+		}
+	}
+	if rules, ok := groups[robotsAgent]; ok {
+		return rules
+	}
+	return groups["*"]
+}
+
+// hostState is what the limiter knows about one host
+type hostState struct {
+	slots         chan struct{}
+	next          time.Time
+	robots        *robotsRules
+	robotsFetched time.Time
+}
+
+// hostLimiter spaces and caps the requests the scraper sends to each host
+type hostLimiter struct {
+	mu            sync.Mutex
+	hosts         map[string]*hostState
+	maxPerHost    int
+	minDelay      time.Duration
+	respectRobots bool
+}
+
+func newHostLimiter(maxPerHost int, minDelay time.Duration, respectRobots bool) *hostLimiter {
+	return &hostLimiter{
+		hosts:         map[string]*hostState{},
+		maxPerHost:    max(maxPerHost, 1),
+		minDelay:      minDelay,
+		respectRobots: respectRobots,
+	}
+}
+
+func (l *hostLimiter) host(host string) *hostState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	state, ok := l.hosts[host]
+	if !ok {
+		state = &hostState{slots: make(chan struct{}, l.maxPerHost)}
+		l.hosts[host] = state
+	}
+	return state
+}
+
+// acquire waits for a free slot on the host and for its minimum delay to
+// pass, the returned function releases the slot
+func (l *hostLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	state := l.host(host)
+	select {
+	case state.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release := func() { <-state.slots }
+
+	l.mu.Lock()
+	now := time.Now()
+	wait := state.next.Sub(now)
+	delay := l.minDelay
+	if state.robots != nil && state.robots.crawlDelay > delay {
+		delay = state.robots.crawlDelay
+	}
+	if wait > maxHostWait {
+		l.mu.Unlock()
+		release()
+		return nil, errHostBackingOff
+	}
+	state.next = now.Add(max(wait, 0) + delay)
+	l.mu.Unlock()
+
+	if wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
+
+// backoff keeps requests away from a host until the given time
+func (l *hostLimiter) backoff(host string, until time.Time) {
+	state := l.host(host)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until.After(state.next) {
+		state.next = until
+	}
+}
+
+// allowed checks the URL against the host's robots.txt, fetching it when it
+// is unknown or stale. A missing or unreadable robots.txt allows everything.
+func (l *hostLimiter) allowed(ctx context.Context, client *http.Client, target *url.URL) bool {
+	if !l.respectRobots {
+		return true
+	}
+	state := l.host(target.Host)
+	l.mu.Lock()
+	fresh := time.Since(state.robotsFetched) < robotsTTL
+	rules := state.robots
+	l.mu.Unlock()
+
+	if !fresh {
+		rules = fetchRobots(ctx, client, target)
+		l.mu.Lock()
+		state.robots = rules
+		state.robotsFetched = time.Now()
+		l.mu.Unlock()
+	}
+	path := target.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if target.RawQuery != "" {
+		path += "?" + target.RawQuery
+	}
+	return rules.allowed(path)
+}
+
+func fetchRobots(ctx context.Context, client *http.Client, target *url.URL) *robotsRules {
+	robotsURL := url.URL{Scheme: target.Scheme, Host: target.Host, Path: "/robots.txt"}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL.String(), nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	return parseRobots(io.LimitReader(resp.Body, 512<<10))
+}
+
+// retryAfter reads a Retry-After header given in seconds or as an HTTP date
+func retryAfter(header string, now time.Time) (time.Time, bool) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return time.Time{}, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return now.Add(time.Duration(seconds) * time.Second), true
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return date, true
+	}
+	return time.Time{}, false
+}
+
+// releasingBody gives the host slot back when the body is closed, so the
+// download counts against the host and not only the headers
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// politeGet sends a GET through the host limiter, honouring robots.txt and
+// backing off from hosts that answer 429 or 503, for as long as their
+// Retry-After header asks or defaultHostBackoff. The host slot is held until
+// the body is closed. The body may be compressed and has to be read with readBody.
+func politeGet(ctx context.Context, client *http.Client, rawURL string) (*http.Response, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	release, err := hostPolicy.acquire(ctx, target.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hostPolicy.allowed(ctx, client, target) {
+		release()
+		return nil, errDisallowedByRobots
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	// Asked for explicitly, so the transport leaves the body encoded for readBody
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	resp, err := client.Do(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		now := time.Now()
+		until, ok := retryAfter(resp.Header.Get("Retry-After"), now)
+		if !ok {
+			until = now.Add(defaultHostBackoff)
+		}
+		hostPolicy.backoff(target.Host, until)
+		resp.Body.Close()
+		release()
+		return nil, fmt.Errorf("%w: %v", errHostBackingOff, resp.Status)
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRobots(t *testing.T) {
+	tests := []struct {
+		name    string
+		robots  string
+		path    string
+		allowed bool
+	}{
+		{"empty file", ``, "/feed", true},
+		{"star group", "User-agent: *\nDisallow: /private", "/private/feed", false},
+		{"star group other path", "User-agent: *\nDisallow: /private", "/feed", true},
+		{"our group", "User-agent: go-book-project\nDisallow: /", "/feed", false},
+		{"our group ignores case", "User-agent: GO-Book-Project\nDisallow: /", "/feed", false},
+		{"our group with a version", "User-agent: Go-Book-Project/1.0\nDisallow: /", "/feed", false},
+		{"our group wins over star", "User-agent: *\nDisallow: /\n\nUser-agent: go-book-project\nAllow: /", "/feed", true},
+		{"our group wins when listed first", "User-agent: go-book-project\nAllow: /\n\nUser-agent: *\nDisallow: /", "/feed", true},
+		{"substring of our token", "User-agent: Bot\nDisallow: /", "/feed", true},
+		{"shorter token", "User-agent: go-book\nDisallow: /", "/feed", true},
+		{"longer token", "User-agent: go-book-project-staging\nDisallow: /", "/feed", true},
+		{"other agent falls back to star", "User-agent: otherbot\nAllow: /\n\nUser-agent: *\nDisallow: /", "/feed", false},
+		{"shared group", "User-agent: otherbot\nUser-agent: go-book-project\nDisallow: /feed", "/feed", false},
+		{"groups merged", "User-agent: go-book-project\nDisallow: /a\n\nUser-agent: go-book-project\nDisallow: /b", "/b/feed", false},
+		{"longest rule wins", "User-agent: *\nDisallow: /feeds\nAllow: /feeds/public", "/feeds/public/1", true},
+		{"allow wins ties", "User-agent: *\nDisallow: /feed\nAllow: /feed", "/feed", true},
+		{"empty disallow", "User-agent: *\nDisallow:", "/feed", true},
+		{"comments", "# rules\nUser-agent: * # everybody\nDisallow: /private # secret", "/private", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rules := parseRobots(strings.NewReader(test.robots))
+			if got := rules.allowed(test.path); got != test.allowed {
+				t.Errorf("allowed(%q) = %v, want %v", test.path, got, test.allowed)
+			}
+		})
+	}
+}
+
+func TestParseRobotsCrawlDelay(t *testing.T) {
+	tests := []struct {
+		name   string
+		robots string
+		want   time.Duration
+	}{
+		{"none", "User-agent: *\nDisallow: /private", 0},
+		{"star group", "User-agent: *\nCrawl-delay: 2", 2 * time.Second},
+		{"fraction", "User-agent: *\nCrawl-delay: 0.5", 500 * time.Millisecond},
+		{"our group", "User-agent: *\nCrawl-delay: 10\n\nUser-agent: go-book-project\nCrawl-delay: 1", time.Second},
+		{"invalid", "User-agent: *\nCrawl-delay: soon", 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rules := parseRobots(strings.NewReader(test.robots))
+			if rules == nil {
+				t.Fatal("no rules")
+			}
+			if rules.crawlDelay != test.want {
+				t.Errorf("crawl delay %v, want %v", rules.crawlDelay, test.want)
+			}
+		})
+	}
+}
+
+func TestPoliteGetHoldsSlotUntilClose(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("feed"))
+	}))
+	defer server.Close()
+	previous := hostPolicy
+	hostPolicy = newHostLimiter(1, 0, false)
+	t.Cleanup(func() { hostPolicy = previous })
+	target, _ := url.Parse(server.URL)
+
+	resp, err := politeGet(context.Background(), server.Client(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(hostPolicy.host(target.Host).slots); n != 1 {
+		t.Errorf("%v slots taken while the body is open, want 1", n)
+	}
+	resp.Body.Close()
+	resp.Body.Close()
+	if n := len(hostPolicy.host(target.Host).slots); n != 0 {
+		t.Errorf("%v slots taken after the body is closed, want 0", n)
+	}
+}
+
+func TestPoliteGetDefaultBackoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	previous := hostPolicy
+	hostPolicy = newHostLimiter(1, 0, false)
+	t.Cleanup(func() { hostPolicy = previous })
+	target, _ := url.Parse(server.URL)
+
+	start := time.Now()
+	_, err := politeGet(context.Background(), server.Client(), server.URL)
+	if !errors.Is(err, errHostBackingOff) {
+		t.Fatalf("err %v, want %v", err, errHostBackingOff)
+	}
+	state := hostPolicy.host(target.Host)
+	if state.next.Before(start.Add(defaultHostBackoff)) {
+		t.Errorf("host left alone until %v, want at least %v", state.next, start.Add(defaultHostBackoff))
+	}
+	if n := len(state.slots); n != 0 {
+		t.Errorf("%v slots taken after the error, want 0", n)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"project_1/internal/database"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Bounds of every feed's polling interval, set with FEED_MIN_INTERVAL and
+// FEED_MAX_INTERVAL
+var minFetchInterval = 15 * time.Minute
+var maxFetchInterval = 24 * time.Hour
+
+// Interval used when a feed gives nothing to go on
+const defaultFetchInterval = time.Hour
+
+// Number of recent items used to estimate how often a feed publishes
+const publishSampleSize = 10
+
+// fetchIntervalsFromEnv reads FEED_MIN_INTERVAL and FEED_MAX_INTERVAL
+func fetchIntervalsFromEnv() error {
+	if value := os.Getenv("FEED_MIN_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval <= 0 {
+			return fmt.Errorf("invalid FEED_MIN_INTERVAL value: %v", value)
+		}
+		minFetchInterval = interval
+	}
+	if value := os.Getenv("FEED_MAX_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval <= 0 {
+			return fmt.Errorf("invalid FEED_MAX_INTERVAL value: %v", value)
+		}
+		maxFetchInterval = interval
+	}
+	if minFetchInterval > maxFetchInterval {
+		return fmt.Errorf("FEED_MIN_INTERVAL is bigger than FEED_MAX_INTERVAL")
+	}
+	return nil
+}
+
+func clampFetchInterval(interval time.Duration) time.Duration {
+	return min(max(interval, minFetchInterval), maxFetchInterval)
+}
+
+// syndicationPeriod converts sy:updatePeriod and sy:updateFrequency to the
+// delay between two updates announced by the publisher
+func syndicationPeriod(period string, frequency string) (time.Duration, bool) {
+	periods := map[string]time.Duration{
+		"hourly":  time.Hour,
+		"daily":   24 * time.Hour,
+		"weekly":  7 * 24 * time.Hour,
+		"monthly": 30 * 24 * time.Hour,
+		"yearly":  365 * 24 * time.Hour,
+	}
+	length, ok := periods[strings.ToLower(strings.TrimSpace(period))]
+	if !ok {
+		return 0, false
+	}
+	times := 1
+	if value, err := strconv.Atoi(strings.TrimSpace(frequency)); err == nil && value > 0 {
+		times = value
+	}
+	return length / time.Duration(times), true
+}
+
+// observedPublishGap is the average time between the most recent items of a
+// feed, it needs at least two dated items
+func observedPublishGap(items []RSSItem) (time.Duration, bool) {
+	dates := []time.Time{}
+	for _, item := range items {
+		if date, err := parsePubDate(item.PubDate); err == nil {
+			dates = append(dates, date)
+		}
+	}
+	if len(dates) < 2 {
+		return 0, false
+	}
+	slices.SortFunc(dates, func(a, b time.Time) int { return b.Compare(a) })
+	dates = dates[:min(len(dates), publishSampleSize)]
+	gap := dates[0].Sub(dates[len(dates)-1]) / time.Duration(len(dates)-1)
+	return gap, gap > 0
+}
+
+// computeFetchInterval polls at half the observed publishing gap, never more
+// often than the publisher's ttl or syndication period allow, clamped to the
+// configured bounds
+func computeFetchInterval(rssFeed RSSFeed) time.Duration {
+	interval := defaultFetchInterval
+	if gap, ok := observedPublishGap(rssFeed.Channel.Item); ok {
+		interval = gap / 2
+	}
+	if ttl, err := strconv.Atoi(strings.TrimSpace(rssFeed.Channel.TTL)); err == nil && ttl > 0 {
+		interval = max(interval, time.Duration(ttl)*time.Minute)
+	}
+	if period, ok := syndicationPeriod(rssFeed.Channel.UpdatePeriod, rssFeed.Channel.UpdateFrequency); ok {
+		interval = max(interval, period)
+	}
+	return clampFetchInterval(interval)
+}
+
+// effectiveFetchInterval is the owner's override when there is one
+func effectiveFetchInterval(feed database.Feed) time.Duration {
+	if feed.FetchIntervalOverride.Valid {
+		return time.Duration(feed.FetchIntervalOverride.Int32) * time.Second
+	}
+	return time.Duration(feed.FetchInterval) * time.Second
+}
+
+// nextFetchTime schedules the next poll of a feed, moved past the hours
+// listed in the publisher's skipHours
+func nextFetchTime(now time.Time, interval time.Duration, skipHours []int32) time.Time {
+	next := now.Add(interval).UTC()
+	for i := 0; i < 24 && slices.Contains(skipHours, int32(next.Hour())); i++ {
+		next = next.Truncate(time.Hour).Add(time.Hour)
+	}
+	return next
+}
+
+// feedFetchedParams schedules the next fetch of a feed after this one and
+// releases the claim the feed was fetched under, refreshes have none. Only the
+// delay is computed here, the database adds it to its own clock
+func feedFetchedParams(feed database.Feed) database.MarkFeedAsFetchedParams {
+	now := time.Now()
+	next := nextFetchTime(now, effectiveFetchInterval(feed), feed.SkipHours)
+	return database.MarkFeedAsFetchedParams{
+		ID:                feed.ID,
+		FetchDelaySeconds: int32(next.Sub(now) / time.Second),
+		WorkerID:          feed.ClaimedBy,
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"project_1/internal/database"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status of a finished refresh job, queued and running are set by the queries
+const (
+	refreshSucceeded = "succeeded"
+	refreshFailed    = "failed"
+)
+
+// A feed can be refreshed once per cooldown, set with REFRESH_FEED_COOLDOWN
+var refreshFeedCooldown = time.Minute
+
+// Refreshes a user can ask for per hour, set with REFRESH_USER_LIMIT
+var refreshUserLimit = 10
+
+const refreshUserWindow = time.Hour
+
+// A job running longer than this is given up and picked up again by a worker
+const refreshJobTimeout = 2 * time.Minute
+
+// Queued jobs are looked for this often even when nobody wakes the worker,
+// so jobs queued by other instances are run as well
+const refreshPollInterval = 5 * time.Second
+
+// Wakes the refresh worker up as soon as a job is queued
+var refreshQueue = make(chan struct{}, 1)
+
+// refreshLimitsFromEnv reads REFRESH_FEED_COOLDOWN and REFRESH_USER_LIMIT
+func refreshLimitsFromEnv() error {
+	if value := os.Getenv("REFRESH_FEED_COOLDOWN"); value != "" {
+		cooldown, err := time.ParseDuration(value)
+		if err != nil || cooldown < time.Second {
+			return fmt.Errorf("invalid REFRESH_FEED_COOLDOWN value: %v", value)
+		}
+		refreshFeedCooldown = cooldown
+	}
+	if value := os.Getenv("REFRESH_USER_LIMIT"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return fmt.Errorf("invalid REFRESH_USER_LIMIT value: %v", value)
+		}
+		refreshUserLimit = limit
+	}
+	return nil
+}
+
+// wakeRefreshWorker tells the worker a job is waiting, it never blocks
+func wakeRefreshWorker() {
+	select {
+	case refreshQueue <- struct{}{}:
+	default:
+	}
+}
+
+// startRefreshWorker runs queued refresh jobs, at most concurrency at a time
+func startRefreshWorker(conn *sql.DB, concurrency int) {
+	db := database.New(conn)
+	slots := make(chan struct{}, concurrency)
+	ticker := time.NewTicker(refreshPollInterval)
+	for {
+		for {
+			slots <- struct{}{}
+			job, err := db.ClaimNextRefreshJob(context.Background(), int32(refreshJobTimeout/time.Second))
+			if err != nil {
+				<-slots
+				if !errors.Is(err, sql.ErrNoRows) {
+					log.Printf("Error claiming refresh job: %v", err)
+				}
+				break
+			}
+			go func() {
+				defer func() { <-slots }()
+				runRefreshJob(conn, job)
+			}()
+		}
+		select {
+		case <-refreshQueue:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runRefreshJob fetches the feed of a job and records the outcome
+func runRefreshJob(conn *sql.DB, job database.FeedRefreshJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), refreshJobTimeout)
+	defer cancel()
+	db := database.New(conn)
+
+	params := database.FinishRefreshJobParams{ID: job.ID, Status: refreshSucceeded}
+	result, err := refreshFeed(ctx, conn, job.FeedID)
+	if err != nil {
+		log.Printf("Error refreshing feed %v: %v", job.FeedID, err)
+		params.Status = refreshFailed
+		params.Error = sql.NullString{String: err.Error(), Valid: true}
+	} else {
+		params.NewPosts = int32(result.NewPosts)
+		params.UpdatedPosts = int32(result.UpdatedPosts)
+	}
+	// The job context may be over, the outcome is still saved
+	_, err = db.FinishRefreshJob(context.Background(), params)
+	if err != nil {
+		log.Printf("Error finishing refresh job %v: %v", job.ID, err)
+	}
+}
+
+func refreshFeed(ctx context.Context, conn *sql.DB, feedID uuid.UUID) (ingestResult, error) {
+	feed, err := database.New(conn).GetFeed(ctx, feedID)
+	if err != nil {
+		return ingestResult{}, err
+	}
+	// A scraper may hold the feed, the refresh must leave its claim alone
+	feed.ClaimedBy = sql.NullString{}
+	return fetchFeed(ctx, conn, feed, fetchRefresh)
+}
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/xml"
-	"io"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"project_1/internal/database"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -17,48 +21,327 @@ import (
 
 type RSSFeed struct {
 	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
-		Description string    `xml:"description"`
-		Language    string    `xml:"language"`
-		Item        []RSSItem `xml:"item"`
+		// atom:link comes before link, the first field matching an element wins
+		AtomLinks   []AtomLink `xml:"http://www.w3.org/2005/Atom link"`
+		Title       string     `xml:"title"`
+		Link        string     `xml:"link"`
+		Description string     `xml:"description"`
+		Language    string     `xml:"language"`
+		Image       struct {
+			URL string `xml:"url"`
+		} `xml:"image"`
+		TTL       string `xml:"ttl"`
+		SkipHours struct {
+			Hours []string `xml:"hour"`
+		} `xml:"skipHours"`
+		UpdatePeriod    string    `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+		UpdateFrequency string    `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+		Item            []RSSItem `xml:"item"`
 	} `xml:"channel"`
 }
 
 type RSSItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+	Title       string         `xml:"title"`
+	Link        string         `xml:"link"`
+	Description string         `xml:"description"`
+	PubDate     string         `xml:"pubDate"`
+	GUID        string         `xml:"guid"`
+	Author      string         `xml:"author"`
+	Creator     string         `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories  []string       `xml:"category"`
+	Content     string         `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Enclosures  []RSSEnclosure `xml:"enclosure"`
 }
 
-func urlToFeed(url string) (RSSFeed, error) {
+type RSSEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// AtomFeed is the subset of an Atom document we read, it is converted to an
+// RSSFeed so the rest of the app only deals with one shape
+type AtomFeed struct {
+	XMLName  xml.Name    `xml:"feed"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle"`
+	Icon     string      `xml:"icon"`
+	Logo     string      `xml:"logo"`
+	Links    []AtomLink  `xml:"link"`
+	Entries  []AtomEntry `xml:"entry"`
+}
+
+type AtomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+type AtomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Links      []AtomLink     `xml:"link"`
+	Summary    string         `xml:"summary"`
+	Content    string         `xml:"content"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Authors    []AtomAuthor   `xml:"author"`
+	Categories []AtomCategory `xml:"category"`
+}
+
+type AtomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type AtomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// Layouts seen in the wild for pubDate / published, tried in order
+var pubDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+func parsePubDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range pubDateLayouts {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown date format %q", value)
+}
+
+// alternateLink returns the rel="alternate" link of an Atom element, or the
+// first link when none is marked as alternate
+func alternateLink(links []AtomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+	if len(links) > 0 {
+		return links[0].Href
+	}
+	return ""
+}
+
+// author returns the post author, RSS <author> holds an email address so
+// <dc:creator> is preferred when both are present
+func (item RSSItem) author() string {
+	if strings.TrimSpace(item.Creator) != "" {
+		return item.Creator
+	}
+	return item.Author
+}
+
+func atomToRSS(atom AtomFeed) RSSFeed {
+	rssFeed := RSSFeed{}
+	rssFeed.Channel.Title = atom.Title
+	rssFeed.Channel.Link = alternateLink(atom.Links)
+	rssFeed.Channel.AtomLinks = atom.Links
+	rssFeed.Channel.Description = atom.Subtitle
+	rssFeed.Channel.Image.URL = atom.Logo
+	if rssFeed.Channel.Image.URL == "" {
+		rssFeed.Channel.Image.URL = atom.Icon
+	}
+	for _, entry := range atom.Entries {
+		description := entry.Summary
+		if description == "" {
+			description = entry.Content
+		}
+		pubDate := entry.Published
+		if pubDate == "" {
+			pubDate = entry.Updated
+		}
+		item := RSSItem{
+			Title:       entry.Title,
+			Link:        alternateLink(entry.Links),
+			Description: description,
+			PubDate:     pubDate,
+			GUID:        entry.ID,
+			Content:     entry.Content,
+		}
+		if len(entry.Authors) > 0 {
+			item.Author = entry.Authors[0].Name
+		}
+		for _, category := range entry.Categories {
+			item.Categories = append(item.Categories, category.Term)
+		}
+		for _, link := range entry.Links {
+			if link.Rel == "enclosure" {
+				item.Enclosures = append(item.Enclosures, RSSEnclosure{URL: link.Href, Type: link.Type, Length: link.Length})
+			}
+		}
+		rssFeed.Channel.Item = append(rssFeed.Channel.Item, item)
+	}
+	return rssFeed
+}
+
+// newXMLDecoder reads a document that went through toUTF8
+func newXMLDecoder(data []byte) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = charsetReader
+	return decoder
+}
+
+// rootElement returns the local name of the first element in an XML document
+func rootElement(data []byte) string {
+	decoder := newXMLDecoder(data)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local
+		}
+	}
+}
+
+// parseFeed decodes an RSS or Atom document, contentType is the response
+// header the document came with and may name its charset
+func parseFeed(data []byte, contentType string) (RSSFeed, error) {
+	data = toUTF8(data, contentType)
+	switch rootElement(data) {
+	case "rss":
+		rssFeed := RSSFeed{}
+		err := newXMLDecoder(data).Decode(&rssFeed)
+		if err != nil {
+			return RSSFeed{}, err
+		}
+		return rssFeed, nil
+	case "feed":
+		atom := AtomFeed{}
+		err := newXMLDecoder(data).Decode(&atom)
+		if err != nil {
+			return RSSFeed{}, err
+		}
+		return atomToRSS(atom), nil
+	}
+	return RSSFeed{}, errNotAFeed
+}
+
+// feedMetadataParams collects the channel level information stored on a feed
+func feedMetadataParams(feedID uuid.UUID, rssFeed RSSFeed) database.UpdateFeedMetadataParams {
+	params := database.UpdateFeedMetadataParams{
+		ID:          feedID,
+		SiteUrl:     nullString(rssFeed.Channel.Link),
+		Description: nullString(rssFeed.Channel.Description),
+		Language:    nullString(rssFeed.Channel.Language),
+		ImageUrl:    nullString(rssFeed.Channel.Image.URL),
+		SkipHours:   []int32{},
+	}
+	if ttl, err := strconv.Atoi(strings.TrimSpace(rssFeed.Channel.TTL)); err == nil && ttl > 0 {
+		params.Ttl = sql.NullInt32{Int32: int32(ttl), Valid: true}
+	}
+	for _, hour := range rssFeed.Channel.SkipHours.Hours {
+		h, err := strconv.Atoi(strings.TrimSpace(hour))
+		if err != nil || h < 0 || h > 23 {
+			continue
+		}
+		params.SkipHours = append(params.SkipHours, int32(h))
+	}
+	return params
+}
+
+// fetchStats is what the HTTP side of a fetch is recorded with in the history
+type fetchStats struct {
+	StatusCode      int
+	Bytes           int64 // Size of the decoded document
+	CompressedBytes int64 // Bytes received on the wire
+}
+
+// urlToFeed fetches and parses the feed at url, giving up when ctx is done
+func urlToFeed(ctx context.Context, url string) (RSSFeed, fetchStats, error) {
+	stats := fetchStats{}
 	httpClient := http.Client{
-		Timeout: time.Second * 2, // Maximum of 2 secs
+		Transport: outboundTransport,
+		Timeout:   time.Second * 2, // Maximum of 2 secs
 	}
-	resp, err := httpClient.Get(url)
+	resp, err := politeGet(ctx, &httpClient, url)
 	if err != nil {
-		return RSSFeed{}, err
+		return RSSFeed{}, stats, err
 	}
 	defer resp.Body.Close()
-	data, err := io.ReadAll(resp.Body)
+	stats.StatusCode = resp.StatusCode
+	if resp.StatusCode != http.StatusOK {
+		return RSSFeed{}, stats, fmt.Errorf("unexpected status %v", resp.Status)
+	}
+	data, compressed, err := readBody(resp, maxFeedSize)
+	stats.Bytes, stats.CompressedBytes = int64(len(data)), compressed
 	if err != nil {
-		return RSSFeed{}, err
+		return RSSFeed{}, stats, err
 	}
-	rssFeed := RSSFeed{}
-	err = xml.Unmarshal(data, &rssFeed)
+	rssFeed, err := parseFeed(data, resp.Header.Get("Content-Type"))
 	if err != nil {
-		return RSSFeed{}, err
+		return RSSFeed{}, stats, err
 	}
-	return rssFeed, nil
+	// WebSub hubs can also be advertised in Link headers
+	rssFeed.Channel.AtomLinks = append(rssFeed.Channel.AtomLinks, linkHeaderLinks(resp.Header.Values("Link"))...)
+	return rssFeed, stats, nil
+}
+
+// How long a worker owns the feeds it claimed, a worker that crashed loses
+// its feeds to the other instances once the lease expires
+const feedLeaseDuration = 5 * time.Minute
+
+// Feeds fetched at once by the scraper
+const scraperConcurrency = 10
 
+// Time between two rounds of the scraper, set with SCRAPER_INTERVAL. 0 turns
+// the scraper off, feeds are then only fetched when refreshed or pushed
+var scraperInterval = time.Minute
+
+// errFeedClaimLost is returned when a worker's lease on a feed ran out and
+// another worker claimed the feed before the fetch was saved
+var errFeedClaimLost = errors.New("feed claimed by another worker")
+
+// scraperIntervalFromEnv reads SCRAPER_INTERVAL
+func scraperIntervalFromEnv() error {
+	if value := os.Getenv("SCRAPER_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval < 0 || (interval > 0 && interval < time.Second) {
+			return fmt.Errorf("invalid SCRAPER_INTERVAL value: %v", value)
+		}
+		scraperInterval = interval
+	}
+	return nil
+}
+
+// scraperWorkerID names this scraper instance in feed leases, it can be set
+// with SCRAPER_WORKER_ID and defaults to the host name and process ID
+func scraperWorkerID() string {
+	if workerID := os.Getenv("SCRAPER_WORKER_ID"); workerID != "" {
+		return workerID
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "scraper"
+	}
+	return fmt.Sprintf("%v-%v", hostname, os.Getpid())
 }
 
-func startScraping(db *database.Queries, concurrency int, timebetweenrequest time.Duration) {
-	log.Printf("Starting scraping on %v gorountines every %s seconds", concurrency, timebetweenrequest)
+func startScraping(conn *sql.DB, concurrency int, timebetweenrequest time.Duration) {
+	workerID := scraperWorkerID()
+	log.Printf("Starting scraping on %v gorountines every %s seconds as %v", concurrency, timebetweenrequest, workerID)
+	db := database.New(conn)
 	ticker := time.NewTicker(timebetweenrequest)
 	for ; ; <-ticker.C {
-		feeds, err := db.GetNextFeedsToFetch(context.Background(), int64(concurrency))
+		feeds, err := db.ClaimNextFeedsToFetch(context.Background(), database.ClaimNextFeedsToFetchParams{
+			WorkerID:     workerID,
+			LeaseSeconds: int32(feedLeaseDuration / time.Second),
+			MaxFeeds:     int32(concurrency),
+		})
 
 		if err != nil {
 			log.Printf("Error fetching feeds: %v", err)
@@ -67,53 +350,34 @@ func startScraping(db *database.Queries, concurrency int, timebetweenrequest tim
 		wg := &sync.WaitGroup{}
 		for _, feed := range feeds {
 			wg.Add(1)
-			go ScrapeFeed(wg, db, feed)
+			// A fetch can't outlive the lease on its feed
+			ctx, cancel := context.WithTimeout(context.Background(), feedLeaseDuration)
+			go func() {
+				defer cancel()
+				ScrapeFeed(ctx, wg, conn, feed)
+			}()
 		}
 		wg.Wait()
 	}
 }
 
-func ScrapeFeed(wg *sync.WaitGroup, db *database.Queries, feed database.Feed) {
+func ScrapeFeed(ctx context.Context, wg *sync.WaitGroup, conn *sql.DB, feed database.Feed) {
 	defer wg.Done()
-	_, err := db.MarkFeedAsFetched(context.Background(), feed.ID)
-	if err != nil {
-		log.Printf("Error marking feed as fetched: %v", err)
+	result, err := fetchFeed(ctx, conn, feed, fetchScheduled)
+	if errors.Is(err, errFetchFailed) {
+		log.Printf("Error fetching feed: %v", err)
+		// Still wait a full interval before trying again
+		_, err = database.New(conn).MarkFeedAsFetched(context.Background(), feedFetchedParams(feed))
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Feed %v was claimed by another worker", feed.Name)
+		} else if err != nil {
+			log.Printf("Error marking feed as fetched: %v", err)
+		}
 		return
 	}
-	rssFeed, err := urlToFeed(feed.Url)
 	if err != nil {
-		log.Printf("Error fetching feed: %v", err)
+		log.Printf("Error saving feed %v: %v", feed.Name, err)
 		return
 	}
-	for _, item := range rssFeed.Channel.Item {
-		// Check if the description is empty
-		description := sql.NullString{}
-		if item.Description != "" {
-			description.String = item.Description
-			description.Valid = true
-		}
-		// Parse the date of feed it is a string
-		time, err := time.Parse(time.RFC1123Z, item.PubDate)
-		if err != nil {
-			log.Printf("Error parsing date: %v", err)
-			continue
-		}
-
-		_, err = db.CreatePost(context.Background(), database.CreatePostParams{
-			ID:          uuid.New(),
-			Title:       item.Title,
-			Url:         item.Link,
-			FeedID:      feed.ID,
-			Description: description,
-			PublishedAt: time,
-		})
-		if err != nil {
-			if strings.Contains(err.Error(), "duplicate key") {
-				continue
-			}
-			log.Printf("Error creating post: %v", err)
-			continue
-		}
-	}
-	log.Printf("Feed fetched %v, %v posts found", feed.Name, len(rssFeed.Channel.Item))
+	log.Printf("Feed fetched %v, %v posts found, %v new, %v updated", feed.Name, result.ItemsSeen, result.NewPosts, result.UpdatedPosts)
 }
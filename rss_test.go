@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"project_1/internal/database"
+	"testing"
+)
+
+// claimTestQueries runs the test in a rolled back transaction with one feed.
+// Other feeds are pushed out of the way so the claims only see the test's feed
+func claimTestQueries(t *testing.T) (*database.Queries, database.Feed) {
+	t.Helper()
+	tx, qtx := testTx(t)
+	_, err := tx.ExecContext(context.Background(), "UPDATE feeds SET next_fetch_at = NOW() + interval '1 day'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return qtx, createTestFeed(t, qtx, "Claims")
+}
+
+// claim leases the due feeds to a worker, a negative lease is already expired
+func claim(t *testing.T, qtx *database.Queries, workerID string, leaseSeconds int32) []database.Feed {
+	t.Helper()
+	feeds, err := qtx.ClaimNextFeedsToFetch(context.Background(), database.ClaimNextFeedsToFetchParams{
+		WorkerID:     workerID,
+		LeaseSeconds: leaseSeconds,
+		MaxFeeds:     10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return feeds
+}
+
+func TestClaimSkipsLeasedFeeds(t *testing.T) {
+	qtx, feed := claimTestQueries(t)
+
+	claimed := claim(t, qtx, "worker-a", 300)
+	if len(claimed) != 1 || claimed[0].ID != feed.ID || claimed[0].ClaimedBy.String != "worker-a" {
+		t.Fatalf("worker-a claimed %+v, want the test feed", claimed)
+	}
+	if claimed := claim(t, qtx, "worker-b", 300); len(claimed) != 0 {
+		t.Fatalf("worker-b claimed %v feeds under a live lease", len(claimed))
+	}
+}
+
+func TestMarkFeedAsFetchedReleasesClaim(t *testing.T) {
+	qtx, _ := claimTestQueries(t)
+
+	claimed := claim(t, qtx, "worker-a", 300)
+	if len(claimed) != 1 {
+		t.Fatalf("claimed %v feeds, want 1", len(claimed))
+	}
+	feed, err := qtx.MarkFeedAsFetched(context.Background(), feedFetchedParams(claimed[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.ClaimedBy.Valid || feed.ClaimExpiresAt.Valid {
+		t.Errorf("claim kept after the fetch: %v until %v", feed.ClaimedBy.String, feed.ClaimExpiresAt.Time)
+	}
+	if !feed.NextFetchAt.Valid || !feed.LastFetch.Valid {
+		t.Fatalf("fetch not scheduled: next %v, last %v", feed.NextFetchAt, feed.LastFetch)
+	}
+	// Both come from the database clock
+	if got, want := feed.NextFetchAt.Time.Sub(feed.LastFetch.Time), effectiveFetchInterval(feed); got < want {
+		t.Errorf("next fetch %v after the last one, want at least %v", got, want)
+	}
+}
+
+func TestMarkFeedAsFetchedKeepsNewerClaim(t *testing.T) {
+	qtx, _ := claimTestQueries(t)
+
+	expired := claim(t, qtx, "worker-a", -1)
+	if len(expired) != 1 {
+		t.Fatalf("worker-a claimed %v feeds, want 1", len(expired))
+	}
+	current := claim(t, qtx, "worker-b", 300)
+	if len(current) != 1 {
+		t.Fatalf("worker-b claimed %v feeds after the lease ran out, want 1", len(current))
+	}
+
+	_, err := qtx.MarkFeedAsFetched(context.Background(), feedFetchedParams(expired[0]))
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("worker-a marked a feed claimed by worker-b, err %v", err)
+	}
+	feed, err := qtx.GetFeed(context.Background(), current[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.ClaimedBy.String != "worker-b" {
+		t.Errorf("claimed by %q, want worker-b", feed.ClaimedBy.String)
+	}
+}
+
+func TestMarkFeedAsFetchedWithoutClaim(t *testing.T) {
+	qtx, _ := claimTestQueries(t)
+
+	claimed := claim(t, qtx, "worker-a", 300)
+	if len(claimed) != 1 {
+		t.Fatalf("claimed %v feeds, want 1", len(claimed))
+	}
+	// A refresh of the feed while the scraper holds it
+	refreshed := claimed[0]
+	refreshed.ClaimedBy = sql.NullString{}
+	feed, err := qtx.MarkFeedAsFetched(context.Background(), feedFetchedParams(refreshed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.ClaimedBy.String != "worker-a" {
+		t.Errorf("claimed by %q after a refresh, want worker-a", feed.ClaimedBy.String)
+	}
+}
+
+func TestScraperIntervalFromEnv(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{"", false},
+		{"0", false},
+		{"30s", false},
+		{"-1m", true},
+		{"10ms", true},
+		{"often", true},
+	}
+	saved := scraperInterval
+	t.Cleanup(func() { scraperInterval = saved })
+	for _, test := range tests {
+		t.Setenv("SCRAPER_INTERVAL", test.value)
+		err := scraperIntervalFromEnv()
+		if (err != nil) != test.wantErr {
+			t.Errorf("SCRAPER_INTERVAL=%q: err %v, want error %v", test.value, err, test.wantErr)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Length of the plain text summary stored with each post, set with SUMMARY_LENGTH
+var summaryLength = 280
+
+// Tags kept in sanitized HTML with the attributes allowed on each of them,
+// any other tag is removed but its text is kept
+var allowedTags = map[string][]string{
+	"a":          {"href", "title"},
+	"abbr":       {"title"},
+	"b":          nil,
+	"blockquote": {"cite"},
+	"br":         nil,
+	"code":       nil,
+	"dd":         nil,
+	"del":        nil,
+	"div":        nil,
+	"dl":         nil,
+	"dt":         nil,
+	"em":         nil,
+	"figcaption": nil,
+	"figure":     nil,
+	"h1":         nil,
+	"h2":         nil,
+	"h3":         nil,
+	"h4":         nil,
+	"h5":         nil,
+	"h6":         nil,
+	"hr":         nil,
+	"i":          nil,
+	"img":        {"src", "alt", "title", "width", "height"},
+	"li":         nil,
+	"ol":         nil,
+	"p":          nil,
+	"pre":        nil,
+	"q":          {"cite"},
+	"s":          nil,
+	"span":       nil,
+	"strong":     nil,
+	"sub":        nil,
+	"sup":        nil,
+	"table":      nil,
+	"tbody":      nil,
+	"td":         {"colspan", "rowspan"},
+	"th":         {"colspan", "rowspan"},
+	"thead":      nil,
+	"tr":         nil,
+	"u":          nil,
+	"ul":         nil,
+}
+
+// Tags removed together with everything inside them
+var droppedTags = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"iframe":   true,
+	"object":   true,
+	"embed":    true,
+	"noscript": true,
+	"template": true,
+	"form":     true,
+	"head":     true,
+	"title":    true,
+	"svg":      true,
+	"math":     true,
+}
+
+// Attributes holding a URL, they are resolved and checked against urlSchemes
+var urlAttributes = map[string]bool{
+	"href": true,
+	"src":  true,
+	"cite": true,
+}
+
+var urlSchemes = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+}
+
+// Tags that start a new line when converted to plain text
+var blockTags = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "blockquote": true, "pre": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"tr": true, "dt": true, "dd": true, "figcaption": true, "hr": true,
+}
+
+func parseHTMLFragment(fragment string) []*html.Node {
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+	if err != nil {
+		return nil
+	}
+	return nodes
+}
+
+// resolveURL resolves a link found in a post against the post URL, only
+// absolute http(s) and mailto URLs are returned
+func resolveURL(base *url.URL, value string) (string, bool) {
+	ref, err := url.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return "", false
+	}
+	if base != nil {
+		ref = base.ResolveReference(ref)
+	}
+	if !urlSchemes[strings.ToLower(ref.Scheme)] {
+		return "", false
+	}
+	return ref.String(), true
+}
+
+// isTrackingPixel reports whether an image is 1x1 or smaller, which is how
+// publishers hide read tracking in feed content
+func isTrackingPixel(n *html.Node) bool {
+	size := map[string]int{}
+	for _, attr := range n.Attr {
+		if attr.Key == "width" || attr.Key == "height" {
+			value, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(attr.Val), "px"))
+			if err == nil {
+				size[attr.Key] = value
+			}
+		}
+	}
+	width, hasWidth := size["width"]
+	height, hasHeight := size["height"]
+	return hasWidth && hasHeight && width <= 1 && height <= 1
+}
+
+func writeSanitized(sb *strings.Builder, n *html.Node, base *url.URL) {
+	switch n.Type {
+	case html.TextNode:
+		sb.WriteString(html.EscapeString(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		// Comments and doctypes are dropped
+		return
+	}
+
+	tag := strings.ToLower(n.Data)
+	if droppedTags[tag] {
+		return
+	}
+	allowedAttrs, allowed := allowedTags[tag]
+	if !allowed {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			writeSanitized(sb, child, base)
+		}
+		return
+	}
+	if tag == "img" && isTrackingPixel(n) {
+		return
+	}
+
+	attrs := []html.Attribute{}
+	for _, attr := range n.Attr {
+		key := strings.ToLower(attr.Key)
+		if attr.Namespace != "" || !containsString(allowedAttrs, key) {
+			continue
+		}
+		value := attr.Val
+		if urlAttributes[key] {
+			resolved, ok := resolveURL(base, value)
+			if !ok {
+				continue
+			}
+			value = resolved
+		}
+		attrs = append(attrs, html.Attribute{Key: key, Val: value})
+	}
+	// An image without a usable source has nothing left to show
+	if tag == "img" && !hasAttribute(attrs, "src") {
+		return
+	}
+	if tag == "a" && hasAttribute(attrs, "href") {
+		attrs = append(attrs, html.Attribute{Key: "rel", Val: "nofollow noopener noreferrer"})
+	}
+
+	sb.WriteString("<" + tag)
+	for _, attr := range attrs {
+		sb.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+	}
+	sb.WriteString(">")
+	if tag == "br" || tag == "hr" || tag == "img" {
+		return
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		writeSanitized(sb, child, base)
+	}
+	sb.WriteString("</" + tag + ">")
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAttribute(attrs []html.Attribute, key string) bool {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeHTML keeps the allowlisted tags and attributes of a fragment and
+// resolves relative links against baseURL
+func sanitizeHTML(fragment string, baseURL string) string {
+	base, err := url.Parse(baseURL)
+	if err != nil || !base.IsAbs() {
+		base = nil
+	}
+	sb := strings.Builder{}
+	for _, n := range parseHTMLFragment(fragment) {
+		writeSanitized(&sb, n, base)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func writeText(sb *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		sb.WriteString(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+	tag := strings.ToLower(n.Data)
+	if droppedTags[tag] {
+		return
+	}
+	if blockTags[tag] {
+		sb.WriteString("\n")
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		writeText(sb, child)
+	}
+	if blockTags[tag] {
+		sb.WriteString("\n")
+	}
+}
+
+// htmlToText returns the text of a fragment, paragraphs are kept as single
+// line breaks and other whitespace is collapsed
+func htmlToText(fragment string) string {
+	sb := strings.Builder{}
+	for _, n := range parseHTMLFragment(fragment) {
+		writeText(&sb, n)
+	}
+	lines := []string{}
+	for _, line := range strings.Split(sb.String(), "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// summarize returns at most length runes of plain text, cut on a word
+// boundary when possible
+func summarize(fragment string, length int) string {
+	text := strings.Join(strings.Fields(htmlToText(fragment)), " ")
+	if length <= 0 || utf8.RuneCountInString(text) <= length {
+		return text
+	}
+	runes := []rune(text)
+	cut := string(runes[:length])
+	if i := strings.LastIndex(cut, " "); i > length/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:") + "…"
+}
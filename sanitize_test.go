@@ -0,0 +1,53 @@
+package main
+
+import "testing"
+
+func TestSanitizeHTML(t *testing.T) {
+	const base = "https://example.com/blog/post/1"
+	const rel = ` rel="nofollow noopener noreferrer"`
+	tests := []struct {
+		name     string
+		fragment string
+		want     string
+	}{
+		{"script", `<p>Hi<script>alert(1)</script></p>`, `<p>Hi</p>`},
+		{"style", `<style>p{color:red}</style><p>Text</p>`, `<p>Text</p>`},
+		{"iframe", `<iframe src="https://evil.example"></iframe><p>After</p>`, `<p>After</p>`},
+		{"script inside svg", `<svg><script>alert(1)</script></svg>`, ``},
+		{"comment", `<!-- comment --><p>x</p>`, `<p>x</p>`},
+		{"event handler", `<p onclick="alert(1)">Text</p>`, `<p>Text</p>`},
+		{"event handler on image", `<img src="https://example.com/a.png" onerror="alert(1)" alt="A">`, `<img src="https://example.com/a.png" alt="A">`},
+		{"attribute not allowed", `<p class="x" style="color:red">Text</p>`, `<p>Text</p>`},
+		{"unknown tag keeps its text", `<div><custom>kept text</custom></div>`, `<div>kept text</div>`},
+		{"attribute escaped", `<a title='"><script>' href="https://a.example/">t</a>`, `<a title="&#34;&gt;&lt;script&gt;" href="https://a.example/"` + rel + `>t</a>`},
+		{"javascript URL", `<a href="javascript:alert(1)">Click</a>`, `<a>Click</a>`},
+		{"javascript URL mixed case", `<a href="JaVaScRiPt:alert(1)">Click</a>`, `<a>Click</a>`},
+		{"javascript URL with spaces", `<a href=" javascript:alert(1)">Click</a>`, `<a>Click</a>`},
+		{"javascript URL with a tab", `<a href="java&#09;script:alert(1)">Click</a>`, `<a>Click</a>`},
+		{"data URL link", `<a href="data:text/html,<script>alert(1)</script>">x</a>`, `<a>x</a>`},
+		{"data URL image", `<img src="data:image/png;base64,AAAA">`, ``},
+		{"mailto kept", `<a href="mailto:me@example.com">Mail</a>`, `<a href="mailto:me@example.com"` + rel + `>Mail</a>`},
+		{"tracking pixel", `<img src="https://t.example/p.gif" width="1" height="1">`, ``},
+		{"root relative URL", `<a href="/post/2">Next</a>`, `<a href="https://example.com/post/2"` + rel + `>Next</a>`},
+		{"path relative URL", `<img src="img.png" alt="A">`, `<img src="https://example.com/blog/post/img.png" alt="A">`},
+		{"parent relative URL", `<a href="../up">Up</a>`, `<a href="https://example.com/blog/up"` + rel + `>Up</a>`},
+		{"scheme relative URL", `<a href="//cdn.example.org/x">CDN</a>`, `<a href="https://cdn.example.org/x"` + rel + `>CDN</a>`},
+		{"blockquote cite", `<blockquote cite="/source">Quote</blockquote>`, `<blockquote cite="https://example.com/source">Quote</blockquote>`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := sanitizeHTML(test.fragment, base); got != test.want {
+				t.Errorf("sanitizeHTML(%q)\n got %q\nwant %q", test.fragment, got, test.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeHTMLWithoutBase(t *testing.T) {
+	// Relative URLs can't be resolved and are dropped
+	for _, base := range []string{"", "not a url", "/relative/path"} {
+		if got := sanitizeHTML(`<a href="/x">x</a>`, base); got != `<a>x</a>` {
+			t.Errorf("sanitizeHTML with base %q = %q, want <a>x</a>", base, got)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+-- name: AddFeedAccess :one
+-- Returns no row when the user already has access
+INSERT INTO feed_access (feed_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT (feed_id, user_id) DO NOTHING
+RETURNING *;
+
+-- name: GetFeedAccess :many
+SELECT feed_access.*, users.name AS user_name FROM feed_access
+JOIN users ON users.id = feed_access.user_id
+WHERE feed_access.feed_id = $1 AND users.deleted_at IS NULL
+ORDER BY feed_access.created_at;
+
+-- name: RemoveFeedAccess :execrows
+DELETE FROM feed_access WHERE feed_id = $1 AND user_id = $2;
+
+-- name: CreateFeedInvite :one
+INSERT INTO feed_invites (id, feed_id, created_by, token_hash, expires_at, max_uses)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetFeedInvites :many
+SELECT * FROM feed_invites WHERE feed_id = $1 ORDER BY created_at DESC;
+
+-- name: DeleteFeedInvite :execrows
+DELETE FROM feed_invites WHERE id = $1 AND feed_id = $2;
+
+-- name: UseFeedInvite :one
+-- Counts one use of a live invite, no row when it is unknown, expired, used
+-- up or its feed was deleted
+UPDATE feed_invites SET uses = uses + 1
+WHERE token_hash = $1
+    AND feed_id IN (SELECT id FROM feeds WHERE deleted_at IS NULL)
+    AND (expires_at IS NULL OR expires_at > NOW())
+    AND (max_uses IS NULL OR uses < max_uses)
+RETURNING *;
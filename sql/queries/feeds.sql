@@ -1,27 +1,157 @@
 -- name: CreateFeed :one
-INSERT INTO feeds (id, name, url, user_id) 
-VALUES ($1, $2, $3, $4) 
+INSERT INTO feeds (id, name, url, user_id, visibility) 
+VALUES ($1, $2, $3, $4, $5) 
 RETURNING *;
 
--- name: GetAllFeeds :many
-SELECT * FROM feeds;
+-- name: ListFeeds :many
+-- One page of the feed listing. q matches name, URL or description, language
+-- matches its subtags too ("en" finds "en-us"). Only feeds the user may read
+-- are listed, and other users' unlisted feeds only when the user follows them.
+-- owner_id narrows the listing to the feeds of one owner. Deleted feeds are
+-- left out. CountFeeds takes the same filters. The latest post is looked up
+-- per feed rather than joined, so the posts of the feeds off the page are left alone
+SELECT sqlc.embed(feeds),
+    (SELECT COUNT(*) FROM feed_follow WHERE feed_follow.feed_id = feeds.id AND feed_follow.deleted_at IS NULL)::bigint AS follower_count,
+    (SELECT MAX(posts.published_at) FROM posts
+        WHERE posts.feed_id = feeds.id AND (NOT posts.manual OR posts.published_at <= NOW())) AS last_post_at
+FROM feeds
+WHERE feeds.deleted_at IS NULL
+    AND (sqlc.arg(q)::text = ''
+        OR feeds.name ILIKE '%' || sqlc.arg(q) || '%'
+        OR feeds.url ILIKE '%' || sqlc.arg(q) || '%'
+        OR feeds.description ILIKE '%' || sqlc.arg(q) || '%')
+    AND feed_readable(feeds.id, sqlc.arg(user_id))
+    AND (feeds.visibility <> 'unlisted' OR feeds.user_id = sqlc.arg(user_id)
+        OR EXISTS(SELECT 1 FROM feed_follow WHERE feed_follow.feed_id = feeds.id AND feed_follow.deleted_at IS NULL AND feed_follow.user_id = sqlc.arg(user_id)))
+    AND (NOT sqlc.arg(mine)::bool OR feeds.user_id = sqlc.arg(user_id))
+    AND (sqlc.narg(owner_id)::uuid IS NULL OR feeds.user_id = sqlc.narg(owner_id))
+    AND (NOT sqlc.arg(followed)::bool OR EXISTS(
+        SELECT 1 FROM feed_follow WHERE feed_follow.feed_id = feeds.id AND feed_follow.deleted_at IS NULL AND feed_follow.user_id = sqlc.arg(user_id)))
+    AND (sqlc.arg(language)::text = ''
+        OR lower(feeds.language) = lower(sqlc.arg(language))
+        OR lower(feeds.language) LIKE lower(sqlc.arg(language)) || '-%')
+ORDER BY
+    CASE WHEN sqlc.arg(sort)::text = 'name' AND NOT sqlc.arg(descending)::bool THEN lower(feeds.name) END ASC,
+    CASE WHEN sqlc.arg(sort) = 'name' AND sqlc.arg(descending) THEN lower(feeds.name) END DESC,
+    CASE WHEN sqlc.arg(sort) = 'created' AND NOT sqlc.arg(descending) THEN feeds.created_at END ASC,
+    CASE WHEN sqlc.arg(sort) = 'created' AND sqlc.arg(descending) THEN feeds.created_at END DESC,
+    CASE WHEN sqlc.arg(sort) = 'followers' AND NOT sqlc.arg(descending) THEN (SELECT COUNT(*) FROM feed_follow WHERE feed_follow.feed_id = feeds.id AND feed_follow.deleted_at IS NULL) END ASC,
+    CASE WHEN sqlc.arg(sort) = 'followers' AND sqlc.arg(descending) THEN (SELECT COUNT(*) FROM feed_follow WHERE feed_follow.feed_id = feeds.id AND feed_follow.deleted_at IS NULL) END DESC,
+    CASE WHEN sqlc.arg(sort) = 'activity' AND NOT sqlc.arg(descending) THEN (SELECT MAX(posts.published_at) FROM posts
+        WHERE posts.feed_id = feeds.id AND (NOT posts.manual OR posts.published_at <= NOW())) END ASC NULLS FIRST,
+    CASE WHEN sqlc.arg(sort) = 'activity' AND sqlc.arg(descending) THEN (SELECT MAX(posts.published_at) FROM posts
+        WHERE posts.feed_id = feeds.id AND (NOT posts.manual OR posts.published_at <= NOW())) END DESC NULLS LAST,
+    lower(feeds.name), feeds.id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
 
--- name: GetNextFeedsToFetch :many
-SELECT * FROM feeds 
-ORDER BY last_fetch ASC NULLS FIRST
-LIMIT $1;
+-- name: CountFeeds :one
+-- Number of feeds ListFeeds finds across all its pages
+SELECT COUNT(*) FROM feeds
+WHERE feeds.deleted_at IS NULL
+    AND (sqlc.arg(q)::text = ''
+        OR feeds.name ILIKE '%' || sqlc.arg(q) || '%'
+        OR feeds.url ILIKE '%' || sqlc.arg(q) || '%'
+        OR feeds.description ILIKE '%' || sqlc.arg(q) || '%')
+    AND feed_readable(feeds.id, sqlc.arg(user_id))
+    AND (feeds.visibility <> 'unlisted' OR feeds.user_id = sqlc.arg(user_id)
+        OR EXISTS(SELECT 1 FROM feed_follow WHERE feed_follow.feed_id = feeds.id AND feed_follow.deleted_at IS NULL AND feed_follow.user_id = sqlc.arg(user_id)))
+    AND (NOT sqlc.arg(mine)::bool OR feeds.user_id = sqlc.arg(user_id))
+    AND (sqlc.narg(owner_id)::uuid IS NULL OR feeds.user_id = sqlc.narg(owner_id))
+    AND (NOT sqlc.arg(followed)::bool OR EXISTS(
+        SELECT 1 FROM feed_follow WHERE feed_follow.feed_id = feeds.id AND feed_follow.deleted_at IS NULL AND feed_follow.user_id = sqlc.arg(user_id)))
+    AND (sqlc.arg(language)::text = ''
+        OR lower(feeds.language) = lower(sqlc.arg(language))
+        OR lower(feeds.language) LIKE lower(sqlc.arg(language)) || '-%');
+
+-- name: GetFeedDetail :one
+-- One feed with its owner, follower and post counts, and whether user_id
+-- follows it. No row when user_id may not read it or it was deleted
+SELECT sqlc.embed(feeds), users.name AS owner_name,
+    (SELECT COUNT(*) FROM feed_follow WHERE feed_follow.feed_id = feeds.id AND feed_follow.deleted_at IS NULL)::bigint AS follower_count,
+    EXISTS(SELECT 1 FROM feed_follow WHERE feed_follow.feed_id = feeds.id AND feed_follow.deleted_at IS NULL AND feed_follow.user_id = sqlc.arg(user_id)) AS followed,
+    COUNT(posts.id) AS post_count, MAX(posts.published_at) AS last_post_at
+FROM feeds
+JOIN users ON users.id = feeds.user_id
+LEFT JOIN posts ON posts.feed_id = feeds.id AND (NOT posts.manual OR posts.published_at <= NOW())
+WHERE feeds.id = sqlc.arg(id) AND feed_readable(feeds.id, sqlc.arg(user_id))
+GROUP BY feeds.id, users.id;
+
+-- name: ClaimNextFeedsToFetch :many
+-- Leases the feeds that are due, most overdue first, to one worker. Rows
+-- locked by another worker's claim are skipped, and so are feeds with a live lease
+UPDATE feeds
+SET claimed_by = sqlc.arg(worker_id)::text,
+    claim_expires_at = NOW() + make_interval(secs => sqlc.arg(lease_seconds)::int)
+WHERE id IN (
+    SELECT id FROM feeds
+    WHERE deleted_at IS NULL
+    AND (claim_expires_at IS NULL OR claim_expires_at < NOW())
+    AND (next_fetch_at IS NULL OR next_fetch_at <= NOW())
+    ORDER BY next_fetch_at ASC NULLS FIRST
+    LIMIT sqlc.arg(max_feeds)::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
 
 -- name: MarkFeedAsFetched :one
-UPDATE feeds 
-SET last_fetch = NOW(), updated_at = NOW() 
+-- Schedules the next fetch. A worker passes its ID to release its claim, no row
+-- comes back when its lease ran out and another worker claimed the feed since.
+-- Refreshes pass no worker and leave any claim alone. The next fetch is
+-- fetch_delay_seconds after the database's clock, which the claims compare with
+UPDATE feeds
+SET last_fetch = NOW(), updated_at = NOW(), next_fetch_at = NOW() + make_interval(secs => sqlc.arg(fetch_delay_seconds)::int),
+    claimed_by = CASE WHEN sqlc.narg(worker_id)::text IS NULL THEN claimed_by END,
+    claim_expires_at = CASE WHEN sqlc.narg(worker_id)::text IS NULL THEN claim_expires_at END
+WHERE id = sqlc.arg(id) AND (sqlc.narg(worker_id)::text IS NULL OR claimed_by = sqlc.narg(worker_id))
+RETURNING *;
+
+-- name: UpdateFeedFetchInterval :exec
+UPDATE feeds SET fetch_interval = $2 WHERE id = $1;
+
+-- name: SetFeedFetchIntervalOverride :one
+-- A NULL override goes back to the computed interval, the next fetch is
+-- moved so the new interval applies right away
+UPDATE feeds
+SET fetch_interval_override = $2,
+    next_fetch_at = COALESCE(last_fetch, NOW()) + make_interval(secs => COALESCE($2, fetch_interval)),
+    updated_at = NOW()
 WHERE id = $1
 RETURNING *;
 
 -- name: UpdateFeed :one
-UPDATE feeds SET name = $2, url = $3 WHERE user_id = $1 AND id = $4 RETURNING *;
+UPDATE feeds SET name = $2, url = $3 WHERE user_id = $1 AND id = $4 AND deleted_at IS NULL RETURNING *;
 
 -- name: DeleteFeed :exec
-DELETE FROM feeds WHERE id = $1 AND user_id = $2;
+UPDATE feeds SET deleted_at = NOW() WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL;
 
 -- name: GetFeed :one
-SELECT * FROM feeds WHERE id = $1;
\ No newline at end of file
+SELECT * FROM feeds WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: UpdateFeedMetadata :one
+UPDATE feeds
+SET site_url = $2, description = $3, language = $4, image_url = $5, ttl = $6, skip_hours = $7, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetFeedByURL :one
+SELECT * FROM feeds WHERE url = $1 AND deleted_at IS NULL;
+
+-- name: SetFeedVisibility :one
+UPDATE feeds SET visibility = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL RETURNING *;
+
+-- name: CanReadFeed :one
+SELECT COALESCE(feed_readable(sqlc.arg(feed_id)::uuid, sqlc.arg(user_id)::uuid), FALSE)::bool AS readable;
+
+-- name: GetDeletedFeed :one
+-- A feed deleted less than grace_seconds ago
+SELECT * FROM feeds
+WHERE id = sqlc.arg(id)
+    AND deleted_at > NOW() - make_interval(secs => sqlc.arg(grace_seconds)::int);
+
+-- name: RestoreFeed :one
+UPDATE feeds SET deleted_at = NULL, updated_at = NOW() WHERE id = $1 RETURNING *;
+
+-- name: PurgeDeletedFeeds :execrows
+-- Posts and everything else hanging off the feeds go with them
+DELETE FROM feeds
+WHERE deleted_at < NOW() - make_interval(secs => sqlc.arg(grace_seconds)::int);
@@ -1,13 +1,88 @@
 -- name: CreateFollow :one
-INSERT INTO feed_follow (id, user_id, feed_id) 
-VALUES ($1, $2, $3) 
+-- A deleted follow of the feed is replaced by the new one, no row comes back
+-- when the user already follows the feed
+INSERT INTO feed_follow (id, user_id, feed_id, folder_id) 
+VALUES ($1, $2, $3, $4) 
+ON CONFLICT (user_id, feed_id) DO UPDATE
+SET id = EXCLUDED.id, folder_id = EXCLUDED.folder_id, title = NULL, muted = FALSE, priority = 0,
+    created_at = NOW(), updated_at = NOW(), deleted_at = NULL
+WHERE feed_follow.deleted_at IS NOT NULL
 RETURNING *;
 
 -- name: GetFollows :many
-SELECT * FROM feed_follow WHERE user_id = $1;
+-- Each follow with its feed, the feed owner and the feed's post count and
+-- latest published post, looked up per feed. Follows of feeds the user may no
+-- longer read are left out
+SELECT feed_follow.*, feeds.name AS feed_name, feeds.url AS feed_url,
+    feeds.user_id AS owner_id, users.name AS owner_name,
+    (SELECT COUNT(*) FROM posts
+        WHERE posts.feed_id = feeds.id AND (NOT posts.manual OR posts.published_at <= NOW()))::bigint AS post_count,
+    (SELECT MAX(posts.published_at) FROM posts
+        WHERE posts.feed_id = feeds.id AND (NOT posts.manual OR posts.published_at <= NOW())) AS last_post_at
+FROM feed_follow
+JOIN feeds ON feeds.id = feed_follow.feed_id
+JOIN users ON users.id = feeds.user_id
+WHERE feed_follow.user_id = $1 AND feed_follow.deleted_at IS NULL AND feeds.deleted_at IS NULL
+    AND feed_readable(feeds.id, feed_follow.user_id)
+ORDER BY feed_follow.priority DESC, feed_follow.created_at;
 
 -- name: Unfollow :exec
-DELETE FROM feed_follow WHERE user_id = $1 AND feed_id = $2;
+UPDATE feed_follow SET deleted_at = NOW() WHERE user_id = $1 AND feed_id = $2 AND deleted_at IS NULL;
 
 -- name: GetFollowsByFeedID :one
-SELECT * FROM feed_follow WHERE feed_id = $1 AND user_id = $2;
\ No newline at end of file
+SELECT * FROM feed_follow WHERE feed_id = $1 AND user_id = $2 AND deleted_at IS NULL;
+
+-- name: GetFollowedFeeds :many
+-- Feeds the user follows and may still read
+SELECT feeds.*, folders.name AS folder_name FROM feeds
+JOIN feed_follow ON feed_follow.feed_id = feeds.id
+LEFT JOIN folders ON folders.id = feed_follow.folder_id
+WHERE feed_follow.user_id = $1 AND feed_follow.deleted_at IS NULL AND feeds.deleted_at IS NULL
+    AND feed_readable(feeds.id, feed_follow.user_id)
+ORDER BY folders.position NULLS FIRST, folders.name, feeds.name;
+
+-- name: SetFollowFolder :one
+UPDATE feed_follow SET folder_id = $3, updated_at = NOW()
+WHERE user_id = $1 AND feed_id = $2 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: UpdateFollowSettings :one
+-- NULL settings keep their value, a blank title goes back to the feed name.
+-- No row comes back when the user doesn't follow the feed
+UPDATE feed_follow
+SET title = NULLIF(COALESCE(sqlc.narg(title)::text, title), ''),
+    muted = COALESCE(sqlc.narg(muted)::bool, muted),
+    priority = COALESCE(sqlc.narg(priority)::int, priority),
+    updated_at = NOW()
+WHERE user_id = sqlc.arg(user_id) AND feed_id = sqlc.arg(feed_id) AND deleted_at IS NULL
+RETURNING *;
+
+-- name: GetDeletedFollow :one
+-- A follow deleted less than grace_seconds ago
+SELECT * FROM feed_follow
+WHERE user_id = sqlc.arg(user_id) AND feed_id = sqlc.arg(feed_id)
+    AND deleted_at > NOW() - make_interval(secs => sqlc.arg(grace_seconds)::int);
+
+-- name: RestoreFollow :one
+UPDATE feed_follow SET deleted_at = NULL, updated_at = NOW() WHERE id = $1 RETURNING *;
+
+-- name: SoftDeleteUserFollows :exec
+UPDATE feed_follow SET deleted_at = $2 WHERE user_id = $1 AND deleted_at IS NULL;
+
+-- name: RestoreUserFollows :exec
+-- Follows deleted along with the user, at the same time
+UPDATE feed_follow SET deleted_at = NULL WHERE user_id = $1 AND deleted_at = $2;
+
+-- name: PurgeDeletedFollows :execrows
+DELETE FROM feed_follow
+WHERE deleted_at < NOW() - make_interval(secs => sqlc.arg(grace_seconds)::int);
+
+-- name: GetTimelineUpdatedAt :one
+-- Latest change to a user's timeline besides its posts: the user, their follows
+-- and the feeds they follow
+SELECT GREATEST(users.updated_at,
+    (SELECT MAX(GREATEST(feed_follow.updated_at, feed_follow.deleted_at)) FROM feed_follow WHERE feed_follow.user_id = users.id),
+    (SELECT MAX(feeds.updated_at) FROM feeds JOIN feed_follow ON feed_follow.feed_id = feeds.id
+        WHERE feed_follow.user_id = users.id AND feed_follow.deleted_at IS NULL))::timestamp AS updated_at
+FROM users
+WHERE users.id = $1;
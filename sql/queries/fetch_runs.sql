@@ -0,0 +1,15 @@
+-- name: CreateFeedFetchRun :exec
+-- The run finishes now on the database clock, which the pruning compares with,
+-- and started duration_seconds earlier
+INSERT INTO feed_fetch_runs (id, feed_id, source, started_at, finished_at, http_status, bytes, compressed_bytes, items_seen, new_posts, updated_posts, error)
+VALUES ($1, $2, $3, NOW() - make_interval(secs => sqlc.arg(duration_seconds)::float8), NOW(), $4, $5, $6, $7, $8, $9, $10);
+
+-- name: GetFeedFetchRuns :many
+SELECT * FROM feed_fetch_runs
+WHERE feed_id = $1
+ORDER BY started_at DESC
+LIMIT $2;
+
+-- name: DeleteOldFeedFetchRuns :execrows
+DELETE FROM feed_fetch_runs
+WHERE started_at < NOW() - make_interval(secs => sqlc.arg(retention_seconds)::int);
@@ -0,0 +1,29 @@
+-- name: CreateFolder :one
+INSERT INTO folders (id, user_id, name, position)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: UpsertFolder :one
+-- Returns the user's folder with that name, creating it last when missing
+INSERT INTO folders (id, user_id, name, position)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, name) DO UPDATE SET name = EXCLUDED.name
+RETURNING *;
+
+-- name: GetNextFolderPosition :one
+SELECT COALESCE(MAX(position) + 1, 0)::int AS next_position FROM folders WHERE user_id = $1;
+
+-- name: GetFolders :many
+SELECT * FROM folders WHERE user_id = $1 ORDER BY position, name;
+
+-- name: GetFolder :one
+SELECT * FROM folders WHERE id = $1 AND user_id = $2;
+
+-- name: UpdateFolder :one
+UPDATE folders SET name = $3, position = $4, updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteFolder :exec
+-- The follows of the folder are kept, outside of any folder
+DELETE FROM folders WHERE id = $1 AND user_id = $2;
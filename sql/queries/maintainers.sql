@@ -0,0 +1,79 @@
+-- name: AddFeedMaintainer :one
+INSERT INTO feed_maintainers (feed_id, user_id)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: GetFeedMaintainers :many
+SELECT feed_maintainers.*, users.name AS user_name FROM feed_maintainers
+JOIN users ON users.id = feed_maintainers.user_id
+WHERE feed_maintainers.feed_id = $1 AND users.deleted_at IS NULL
+ORDER BY feed_maintainers.created_at;
+
+-- name: IsFeedMaintainer :one
+SELECT EXISTS(SELECT 1 FROM feed_maintainers WHERE feed_id = $1 AND user_id = $2) AS maintainer;
+
+-- name: RemoveFeedMaintainer :execrows
+DELETE FROM feed_maintainers WHERE feed_id = $1 AND user_id = $2;
+
+-- name: CreateOwnershipTransfer :one
+INSERT INTO feed_ownership_transfers (id, feed_id, from_user_id, to_user_id)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetOwnershipTransfer :one
+SELECT * FROM feed_ownership_transfers WHERE id = $1;
+
+-- name: GetUserOwnershipTransfers :many
+-- Pending transfers the user sent or received
+SELECT * FROM feed_ownership_transfers
+WHERE status = 'pending' AND (from_user_id = sqlc.arg(user_id) OR to_user_id = sqlc.arg(user_id))
+ORDER BY created_at DESC;
+
+-- name: SetOwnershipTransferStatus :one
+-- Only pending transfers change, no row means it was answered already
+UPDATE feed_ownership_transfers SET status = $2, updated_at = NOW()
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
+
+-- name: SetFeedOwner :one
+UPDATE feeds SET user_id = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: ReassignFeedsToMaintainers :exec
+-- Hands each feed of a user with maintainers to its longest standing maintainer
+-- who isn't deleted, and who stops being listed as one
+WITH heirs AS (
+    SELECT DISTINCT ON (feed_maintainers.feed_id) feed_maintainers.feed_id, feed_maintainers.user_id AS heir_id
+    FROM feed_maintainers
+    JOIN feeds ON feeds.id = feed_maintainers.feed_id
+    JOIN users ON users.id = feed_maintainers.user_id
+    WHERE feeds.user_id = sqlc.arg(user_id) AND feed_maintainers.user_id <> sqlc.arg(user_id)
+        AND users.deleted_at IS NULL
+    ORDER BY feed_maintainers.feed_id, feed_maintainers.created_at
+), reassigned AS (
+    UPDATE feeds SET user_id = heirs.heir_id, updated_at = NOW()
+    FROM heirs
+    WHERE feeds.id = heirs.feed_id
+    RETURNING feeds.id, feeds.user_id
+)
+DELETE FROM feed_maintainers
+USING reassigned
+WHERE feed_maintainers.feed_id = reassigned.id AND feed_maintainers.user_id = reassigned.user_id;
+
+-- name: OrphanSharedFeeds :exec
+-- Gives the system user the feeds of a user that others still follow
+UPDATE feeds SET user_id = sqlc.arg(system_user_id), updated_at = NOW()
+WHERE feeds.user_id = sqlc.arg(user_id) AND EXISTS(
+    SELECT 1 FROM feed_follow WHERE feed_follow.feed_id = feeds.id AND feed_follow.user_id <> sqlc.arg(user_id)
+        AND feed_follow.deleted_at IS NULL);
+
+-- name: DeleteUserFeeds :exec
+DELETE FROM feeds WHERE user_id = $1;
+
+-- name: SoftDeleteUserFeeds :exec
+UPDATE feeds SET deleted_at = $2 WHERE user_id = $1 AND deleted_at IS NULL;
+
+-- name: RestoreUserFeeds :exec
+-- Feeds deleted along with the user, at the same time
+UPDATE feeds SET deleted_at = NULL WHERE user_id = $1 AND deleted_at = $2;
@@ -0,0 +1,28 @@
+-- name: CreateOPMLImportJob :one
+INSERT INTO opml_import_jobs (id, user_id, entries)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetOPMLImportJob :one
+SELECT * FROM opml_import_jobs WHERE id = $1 AND user_id = $2;
+
+-- name: ClaimNextOPMLImportJob :one
+-- Starts the oldest queued import. Imports left running longer than the
+-- timeout belong to a worker that died and are started again
+UPDATE opml_import_jobs
+SET status = 'running', started_at = NOW(), updated_at = NOW()
+WHERE id = (
+    SELECT id FROM opml_import_jobs
+    WHERE status = 'queued'
+    OR (status = 'running' AND started_at < NOW() - make_interval(secs => sqlc.arg(timeout_seconds)::int))
+    ORDER BY created_at ASC
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: FinishOPMLImportJob :one
+UPDATE opml_import_jobs
+SET status = $2, error = $3, results = $4, finished_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING *;
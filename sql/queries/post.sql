@@ -1,11 +1,145 @@
--- name: CreatePost :one
-INSERT INTO posts (id, title, description, published_at, url, feed_id)
-VALUES ($1, $2, $3, $4, $5, $6) 
-RETURNING *;
+-- name: UpsertPosts :many
+-- Saves every item of a fetch in one statement, items are passed as parallel
+-- arrays and categories as JSON arrays. Only new and changed items are returned
+INSERT INTO posts (id, title, description, published_at, url, feed_id, guid, author, categories, content, item_key, content_hash, summary)
+SELECT item.id, item.title, NULLIF(item.description, ''), item.published_at, item.url, sqlc.arg(feed_id)::uuid,
+    NULLIF(item.guid, ''), NULLIF(item.author, ''), ARRAY(SELECT json_array_elements_text(item.categories::json)),
+    NULLIF(item.content, ''), item.item_key, item.content_hash, NULLIF(item.summary, '')
+FROM unnest(
+    sqlc.arg(ids)::uuid[],
+    sqlc.arg(titles)::text[],
+    sqlc.arg(descriptions)::text[],
+    sqlc.arg(published_at)::timestamp[],
+    sqlc.arg(urls)::text[],
+    sqlc.arg(guids)::text[],
+    sqlc.arg(authors)::text[],
+    sqlc.arg(categories)::text[],
+    sqlc.arg(contents)::text[],
+    sqlc.arg(item_keys)::text[],
+    sqlc.arg(content_hashes)::text[],
+    sqlc.arg(summaries)::text[]
+) AS item(id, title, description, published_at, url, guid, author, categories, content, item_key, content_hash, summary)
+ON CONFLICT (feed_id, item_key) DO UPDATE
+SET title = EXCLUDED.title,
+    description = EXCLUDED.description,
+    url = EXCLUDED.url,
+    author = EXCLUDED.author,
+    categories = EXCLUDED.categories,
+    content = EXCLUDED.content,
+    content_hash = EXCLUDED.content_hash,
+    summary = EXCLUDED.summary,
+    revision = posts.revision + 1,
+    updated_at = NOW()
+WHERE posts.content_hash <> EXCLUDED.content_hash
+RETURNING id, item_key, revision;
 
 -- name: GetPosts :many
 SELECT posts.* FROM posts
 JOIN feed_follow ON posts.feed_id = feed_follow.feed_id
-WHERE feed_follow.user_id = $1
+WHERE feed_follow.user_id = $1 AND feed_follow.deleted_at IS NULL AND NOT feed_follow.muted
+    AND (NOT posts.manual OR posts.published_at <= NOW())
+    AND feed_readable(posts.feed_id, $1)
 ORDER BY posts.published_at DESC
-LIMIT $2;
\ No newline at end of file
+LIMIT $2;
+
+-- name: GetFolderPosts :many
+SELECT posts.* FROM posts
+JOIN feed_follow ON posts.feed_id = feed_follow.feed_id
+WHERE feed_follow.user_id = $1 AND feed_follow.folder_id = $2 AND feed_follow.deleted_at IS NULL
+    AND (NOT posts.manual OR posts.published_at <= NOW())
+    AND feed_readable(posts.feed_id, $1)
+ORDER BY posts.published_at DESC
+LIMIT $3;
+
+-- name: GetNetworkPosts :many
+-- The timeline plus the posts of the public feeds owned by the users user_id
+-- follows, unlisted and private feeds only through a follow of their own.
+-- Muted follows stay out
+SELECT posts.* FROM posts
+JOIN feeds ON feeds.id = posts.feed_id
+LEFT JOIN feed_follow ON feed_follow.feed_id = posts.feed_id AND feed_follow.user_id = $1 AND feed_follow.deleted_at IS NULL
+WHERE (feed_follow.id IS NOT NULL
+        OR (feeds.visibility = 'public' AND feeds.user_id IN (SELECT followed_id FROM user_follows WHERE follower_id = $1)))
+    AND NOT COALESCE(feed_follow.muted, FALSE)
+    AND (NOT posts.manual OR posts.published_at <= NOW())
+    AND feed_readable(posts.feed_id, $1)
+ORDER BY posts.published_at DESC
+LIMIT $2;
+
+-- name: CreatePostEnclosures :exec
+INSERT INTO post_enclosures (id, post_id, url, type, length)
+SELECT enclosure.id, enclosure.post_id, enclosure.url, NULLIF(enclosure.type, ''), NULLIF(enclosure.length, 0)
+FROM unnest(
+    sqlc.arg(ids)::uuid[],
+    sqlc.arg(post_ids)::uuid[],
+    sqlc.arg(urls)::text[],
+    sqlc.arg(types)::text[],
+    sqlc.arg(lengths)::bigint[]
+) AS enclosure(id, post_id, url, type, length)
+ON CONFLICT (post_id, url) DO NOTHING;
+
+-- name: DeleteStalePostEnclosures :exec
+-- Drops the enclosures of post_ids that are not in the new set of enclosures,
+-- given as the parallel arrays kept_post_ids and kept_urls
+DELETE FROM post_enclosures
+WHERE post_id = ANY(sqlc.arg(post_ids)::uuid[])
+    AND NOT EXISTS (
+        SELECT 1 FROM unnest(sqlc.arg(kept_post_ids)::uuid[], sqlc.arg(kept_urls)::text[]) AS kept(post_id, url)
+        WHERE kept.post_id = post_enclosures.post_id AND kept.url = post_enclosures.url
+    );
+
+-- name: GetPostEnclosures :many
+SELECT * FROM post_enclosures
+WHERE post_id = ANY(sqlc.arg(post_ids)::uuid[])
+ORDER BY created_at;
+
+-- name: CreateManualPost :one
+-- The feed is marked as updated in the same statement, see DeleteManualPost
+WITH created AS (
+    INSERT INTO posts (id, title, description, published_at, url, feed_id, item_key, content_hash, summary, manual, body, author_id)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, TRUE, $10, $11)
+    RETURNING *
+), touched AS (
+    UPDATE feeds SET updated_at = NOW() WHERE id = $6
+)
+SELECT * FROM created;
+
+-- name: UpdateManualPost :one
+-- The feed is marked as updated in the same statement, see DeleteManualPost
+WITH updated AS (
+    UPDATE posts
+    SET title = $3, description = $4, published_at = $5, url = $6, content_hash = $7, summary = $8, body = $9,
+        revision = revision + 1, updated_at = NOW()
+    WHERE id = $1 AND feed_id = $2 AND manual
+    RETURNING *
+), touched AS (
+    UPDATE feeds SET updated_at = NOW() WHERE id IN (SELECT feed_id FROM updated)
+)
+SELECT * FROM updated;
+
+-- name: DeleteManualPost :execrows
+-- The feed is marked as updated so its documents aren't served as unchanged
+WITH deleted AS (
+    DELETE FROM posts WHERE id = $1 AND feed_id = $2 AND manual RETURNING feed_id
+)
+UPDATE feeds SET updated_at = NOW() WHERE id IN (SELECT feed_id FROM deleted);
+
+-- name: GetFeedPost :one
+SELECT * FROM posts WHERE id = $1 AND feed_id = $2;
+
+-- name: GetManualPosts :many
+-- The posts written for a feed, scheduled ones included, newest first
+SELECT * FROM posts
+WHERE feed_id = $1 AND manual
+ORDER BY published_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountManualPosts :one
+SELECT COUNT(*) FROM posts WHERE feed_id = $1 AND manual;
+
+-- name: GetFeedPublishedPosts :many
+-- The latest posts of a feed as served back out, scheduled ones left out
+SELECT * FROM posts
+WHERE feed_id = $1 AND (NOT manual OR published_at <= NOW())
+ORDER BY published_at DESC
+LIMIT $2;
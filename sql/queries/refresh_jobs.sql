@@ -0,0 +1,50 @@
+-- name: CreateRefreshJob :one
+-- No row comes back when the feed already has a job waiting or running
+INSERT INTO feed_refresh_jobs (id, feed_id, user_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (feed_id) WHERE status IN ('queued', 'running') DO NOTHING
+RETURNING *;
+
+-- name: GetRefreshJob :one
+SELECT * FROM feed_refresh_jobs WHERE id = $1 AND feed_id = $2;
+
+-- name: GetPendingRefreshJob :one
+-- A feed has at most one job waiting or running, asking again returns it
+SELECT * FROM feed_refresh_jobs
+WHERE feed_id = $1 AND status IN ('queued', 'running')
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: CountRecentFeedRefreshJobs :one
+-- Jobs still waiting or running don't count, asking again while one is
+-- pending returns it
+SELECT count(*) FROM feed_refresh_jobs
+WHERE feed_id = sqlc.arg(feed_id)::uuid
+AND status NOT IN ('queued', 'running')
+AND created_at > NOW() - make_interval(secs => sqlc.arg(window_seconds)::int);
+
+-- name: CountRecentUserRefreshJobs :one
+SELECT count(*) FROM feed_refresh_jobs
+WHERE user_id = sqlc.arg(user_id)::uuid
+AND created_at > NOW() - make_interval(secs => sqlc.arg(window_seconds)::int);
+
+-- name: ClaimNextRefreshJob :one
+-- Starts the oldest queued job. Jobs left running longer than the timeout
+-- belong to a worker that died and are started again
+UPDATE feed_refresh_jobs
+SET status = 'running', started_at = NOW(), updated_at = NOW()
+WHERE id = (
+    SELECT id FROM feed_refresh_jobs
+    WHERE status = 'queued'
+    OR (status = 'running' AND started_at < NOW() - make_interval(secs => sqlc.arg(timeout_seconds)::int))
+    ORDER BY created_at ASC
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: FinishRefreshJob :one
+UPDATE feed_refresh_jobs
+SET status = $2, error = $3, new_posts = $4, updated_posts = $5, finished_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING *;
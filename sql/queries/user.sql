@@ -6,11 +6,36 @@ RETURNING *;
 -- name: DeleteUser :exec
 DELETE FROM users WHERE id = $1;
 
+-- name: SoftDeleteUser :one
+UPDATE users SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL RETURNING *;
+
+-- name: GetDeletedUser :one
+-- The latest account with the email deleted less than grace_seconds ago
+SELECT * FROM users
+WHERE email = sqlc.arg(email)
+    AND deleted_at > NOW() - make_interval(secs => sqlc.arg(grace_seconds)::int)
+ORDER BY deleted_at DESC
+LIMIT 1;
+
+-- name: RestoreUser :one
+UPDATE users SET deleted_at = NULL WHERE id = $1 RETURNING *;
+
+-- name: GetPurgeableUsers :many
+-- Users deleted more than grace_seconds ago
+SELECT id FROM users
+WHERE deleted_at < NOW() - make_interval(secs => sqlc.arg(grace_seconds)::int);
+
 -- name: UpdateUser :one
-UPDATE users SET name = $2, email = $3 WHERE id = $1 RETURNING *;
+UPDATE users SET name = $2, email = $3, updated_at = NOW() WHERE id = $1 RETURNING *;
 
 -- name: GetUser :one
-SELECT * FROM users WHERE email = $1;
+SELECT * FROM users WHERE email = $1 AND deleted_at IS NULL;
 
 -- name: GetUserByID :one
-SELECT * FROM users WHERE id = $1;
\ No newline at end of file
+SELECT * FROM users WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: GetUserByTimelineToken :one
+SELECT * FROM users WHERE timeline_token_hash = $1 AND deleted_at IS NULL;
+
+-- name: SetTimelineToken :exec
+UPDATE users SET timeline_token_hash = $2 WHERE id = $1;
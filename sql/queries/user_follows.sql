@@ -0,0 +1,37 @@
+-- name: FollowUser :one
+-- Returns no row when the user is already followed
+INSERT INTO user_follows (follower_id, followed_id)
+VALUES ($1, $2)
+ON CONFLICT (follower_id, followed_id) DO NOTHING
+RETURNING *;
+
+-- name: UnfollowUser :execrows
+DELETE FROM user_follows WHERE follower_id = $1 AND followed_id = $2;
+
+-- name: GetUserProfile :one
+-- A user with the number of their feeds viewer_id can see, their follower
+-- and following counts, and whether viewer_id follows them
+SELECT users.id, users.name, users.created_at,
+    (SELECT COUNT(*) FROM feeds WHERE feeds.user_id = users.id AND feeds.deleted_at IS NULL
+        AND (feeds.visibility = 'public' OR feeds.user_id = sqlc.arg(viewer_id)
+            OR (feeds.visibility = 'private' AND feed_readable(feeds.id, sqlc.arg(viewer_id)))))::bigint AS feed_count,
+    (SELECT COUNT(*) FROM user_follows JOIN users AS followers ON followers.id = user_follows.follower_id
+        WHERE user_follows.followed_id = users.id AND followers.deleted_at IS NULL)::bigint AS follower_count,
+    (SELECT COUNT(*) FROM user_follows JOIN users AS followed ON followed.id = user_follows.followed_id
+        WHERE user_follows.follower_id = users.id AND followed.deleted_at IS NULL)::bigint AS following_count,
+    EXISTS(SELECT 1 FROM user_follows
+        WHERE user_follows.follower_id = sqlc.arg(viewer_id) AND user_follows.followed_id = users.id) AS followed
+FROM users
+WHERE users.id = sqlc.arg(user_id) AND users.deleted_at IS NULL;
+
+-- name: GetUserFollowers :many
+SELECT users.id, users.name, user_follows.created_at AS followed_at FROM user_follows
+JOIN users ON users.id = user_follows.follower_id
+WHERE user_follows.followed_id = $1 AND users.deleted_at IS NULL
+ORDER BY user_follows.created_at DESC;
+
+-- name: GetFollowedUsers :many
+SELECT users.id, users.name, user_follows.created_at AS followed_at FROM user_follows
+JOIN users ON users.id = user_follows.followed_id
+WHERE user_follows.follower_id = $1 AND users.deleted_at IS NULL
+ORDER BY user_follows.created_at DESC;
@@ -0,0 +1,48 @@
+-- name: UpsertWebSubSubscription :one
+-- A feed has one subscription, a new hub or topic replaces the old one and
+-- waits for the hub to verify it again
+INSERT INTO websub_subscriptions (id, feed_id, hub, topic, secret)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (feed_id) DO UPDATE
+SET hub = EXCLUDED.hub, topic = EXCLUDED.topic, secret = EXCLUDED.secret,
+    state = 'pending', expires_at = NULL, updated_at = NOW()
+RETURNING *;
+
+-- name: GetWebSubSubscription :one
+SELECT * FROM websub_subscriptions WHERE id = $1;
+
+-- name: GetLiveWebSubSubscription :one
+-- A subscription the hub verified and whose lease hasn't run out, including
+-- one being renewed. Denied and never verified requests have no expires_at
+SELECT * FROM websub_subscriptions
+WHERE id = $1 AND state <> 'denied' AND expires_at > NOW();
+
+-- name: GetFeedWebSubSubscription :one
+SELECT * FROM websub_subscriptions WHERE feed_id = $1;
+
+-- name: ActivateWebSubSubscription :one
+-- Only a request waiting for the hub is verified, a hub can't reactivate a
+-- denied subscription or renew one nobody asked to renew
+UPDATE websub_subscriptions
+SET state = 'active',
+    expires_at = NOW() + make_interval(secs => sqlc.arg(lease_seconds)::int),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)::uuid AND state = 'pending'
+RETURNING *;
+
+-- name: DenyWebSubSubscription :exec
+UPDATE websub_subscriptions SET state = 'denied', expires_at = NULL, updated_at = NOW() WHERE id = $1;
+
+-- name: ClaimWebSubRenewals :many
+-- Subscriptions close to expiry, and requests the hub never verified, are
+-- sent again. They are set back to pending so other instances skip them
+UPDATE websub_subscriptions
+SET state = 'pending', updated_at = NOW()
+WHERE id IN (
+    SELECT id FROM websub_subscriptions
+    WHERE (state = 'active' AND expires_at < NOW() + make_interval(secs => sqlc.arg(renew_before_seconds)::int))
+    OR (state = 'pending' AND updated_at < NOW() - make_interval(secs => sqlc.arg(retry_after_seconds)::int))
+    LIMIT sqlc.arg(max_subscriptions)::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
@@ -0,0 +1,18 @@
+
+--+goose Up
+ALTER TABLE feeds
+    ADD COLUMN site_url TEXT,
+    ADD COLUMN description TEXT,
+    ADD COLUMN language TEXT,
+    ADD COLUMN image_url TEXT,
+    ADD COLUMN ttl INTEGER,
+    ADD COLUMN skip_hours INTEGER[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE feeds
+    DROP COLUMN site_url,
+    DROP COLUMN description,
+    DROP COLUMN language,
+    DROP COLUMN image_url,
+    DROP COLUMN ttl,
+    DROP COLUMN skip_hours;
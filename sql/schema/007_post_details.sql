@@ -0,0 +1,25 @@
+
+--+goose Up
+ALTER TABLE posts
+    ADD COLUMN guid TEXT,
+    ADD COLUMN author TEXT,
+    ADD COLUMN categories TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN content TEXT;
+
+CREATE TABLE post_enclosures (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    type TEXT,
+    length BIGINT,
+    UNIQUE(post_id, url)
+);
+
+-- +goose Down
+DROP TABLE post_enclosures;
+ALTER TABLE posts
+    DROP COLUMN guid,
+    DROP COLUMN author,
+    DROP COLUMN categories,
+    DROP COLUMN content;
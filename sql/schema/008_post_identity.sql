@@ -0,0 +1,23 @@
+
+--+goose Up
+-- Posts are identified per feed by their GUID, or their URL when the
+-- publisher gives no GUID, so two feeds can share an article URL
+ALTER TABLE posts
+    DROP CONSTRAINT posts_url_key,
+    ADD COLUMN item_key TEXT,
+    ADD COLUMN content_hash TEXT NOT NULL DEFAULT '',
+    ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+
+UPDATE posts SET item_key = COALESCE(guid, url);
+
+ALTER TABLE posts
+    ALTER COLUMN item_key SET NOT NULL,
+    ADD CONSTRAINT posts_feed_item_key UNIQUE (feed_id, item_key);
+
+-- +goose Down
+ALTER TABLE posts
+    DROP CONSTRAINT posts_feed_item_key,
+    DROP COLUMN item_key,
+    DROP COLUMN content_hash,
+    DROP COLUMN revision,
+    ADD CONSTRAINT posts_url_key UNIQUE (url);
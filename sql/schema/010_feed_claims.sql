@@ -0,0 +1,15 @@
+
+--+goose Up
+-- Scraper instances lease feeds before fetching them so replicas don't fetch
+-- the same feed, an expired lease means the worker died and can be reclaimed
+ALTER TABLE feeds
+    ADD COLUMN claimed_by TEXT,
+    ADD COLUMN claim_expires_at TIMESTAMP;
+
+CREATE INDEX feeds_last_fetch_idx ON feeds (last_fetch NULLS FIRST);
+
+-- +goose Down
+DROP INDEX feeds_last_fetch_idx;
+ALTER TABLE feeds
+    DROP COLUMN claimed_by,
+    DROP COLUMN claim_expires_at;
@@ -0,0 +1,19 @@
+
+--+goose Up
+-- Every feed is polled on its own interval, computed from how often it
+-- publishes unless the owner sets an override
+ALTER TABLE feeds
+    ADD COLUMN fetch_interval INTEGER NOT NULL DEFAULT 3600,
+    ADD COLUMN fetch_interval_override INTEGER,
+    ADD COLUMN next_fetch_at TIMESTAMP;
+
+DROP INDEX feeds_last_fetch_idx;
+CREATE INDEX feeds_next_fetch_at_idx ON feeds (next_fetch_at NULLS FIRST);
+
+-- +goose Down
+DROP INDEX feeds_next_fetch_at_idx;
+CREATE INDEX feeds_last_fetch_idx ON feeds (last_fetch NULLS FIRST);
+ALTER TABLE feeds
+    DROP COLUMN fetch_interval,
+    DROP COLUMN fetch_interval_override,
+    DROP COLUMN next_fetch_at;
@@ -0,0 +1,23 @@
+
+--+goose Up
+-- Refreshes asked for by users, a job is queued until a worker picks it up
+CREATE TABLE feed_refresh_jobs (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'succeeded', 'failed')),
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    error TEXT,
+    new_posts INTEGER NOT NULL DEFAULT 0,
+    updated_posts INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX feed_refresh_jobs_feed_idx ON feed_refresh_jobs (feed_id, created_at);
+CREATE INDEX feed_refresh_jobs_user_idx ON feed_refresh_jobs (user_id, created_at);
+CREATE INDEX feed_refresh_jobs_pending_idx ON feed_refresh_jobs (created_at) WHERE status IN ('queued', 'running');
+
+-- +goose Down
+DROP TABLE feed_refresh_jobs;
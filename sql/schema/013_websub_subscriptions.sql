@@ -0,0 +1,20 @@
+
+--+goose Up
+-- WebSub subscriptions of feeds that advertise a hub, the hub pushes new
+-- content to /websub/{id} until the lease expires
+CREATE TABLE websub_subscriptions (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    feed_id UUID NOT NULL UNIQUE REFERENCES feeds(id) ON DELETE CASCADE,
+    hub TEXT NOT NULL,
+    topic TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    state TEXT NOT NULL DEFAULT 'pending' CHECK (state IN ('pending', 'active', 'denied')),
+    expires_at TIMESTAMP
+);
+
+CREATE INDEX websub_subscriptions_expires_at_idx ON websub_subscriptions (expires_at);
+
+-- +goose Down
+DROP TABLE websub_subscriptions;
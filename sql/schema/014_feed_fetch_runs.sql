@@ -0,0 +1,22 @@
+
+--+goose Up
+-- One row per fetch of a feed, kept for FETCH_HISTORY_RETENTION
+CREATE TABLE feed_fetch_runs (
+    id UUID PRIMARY KEY,
+    feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    source TEXT NOT NULL CHECK (source IN ('scheduled', 'refresh', 'push')),
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NOT NULL,
+    http_status INTEGER,
+    bytes BIGINT NOT NULL DEFAULT 0,
+    items_seen INTEGER NOT NULL DEFAULT 0,
+    new_posts INTEGER NOT NULL DEFAULT 0,
+    updated_posts INTEGER NOT NULL DEFAULT 0,
+    error TEXT
+);
+
+CREATE INDEX feed_fetch_runs_feed_idx ON feed_fetch_runs (feed_id, started_at DESC);
+CREATE INDEX feed_fetch_runs_started_at_idx ON feed_fetch_runs (started_at);
+
+-- +goose Down
+DROP TABLE feed_fetch_runs;
@@ -0,0 +1,8 @@
+
+--+goose Up
+-- bytes is the decoded size of a document, compressed_bytes what was
+-- transferred to get it
+ALTER TABLE feed_fetch_runs ADD COLUMN compressed_bytes BIGINT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE feed_fetch_runs DROP COLUMN compressed_bytes;
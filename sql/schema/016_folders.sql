@@ -0,0 +1,19 @@
+
+--+goose Up
+-- Folders a user sorts the feeds they follow into, a follow is in at most one
+CREATE TABLE folders (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    UNIQUE(user_id, name)
+);
+
+ALTER TABLE feed_follow ADD COLUMN folder_id UUID REFERENCES folders(id) ON DELETE SET NULL;
+CREATE INDEX feed_follow_folder_idx ON feed_follow (folder_id);
+
+-- +goose Down
+ALTER TABLE feed_follow DROP COLUMN folder_id;
+DROP TABLE folders;
@@ -0,0 +1,12 @@
+
+--+goose Up
+-- Per user settings of a follow: a display title replacing the feed name,
+-- muted feeds stay out of the default timeline, follows are listed by priority
+ALTER TABLE feed_follow ADD COLUMN title TEXT;
+ALTER TABLE feed_follow ADD COLUMN muted BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE feed_follow ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE feed_follow DROP COLUMN priority;
+ALTER TABLE feed_follow DROP COLUMN muted;
+ALTER TABLE feed_follow DROP COLUMN title;
@@ -0,0 +1,10 @@
+
+--+goose Up
+-- Follower counts and the "followed" filter of the feed listing look follows
+-- up by feed, the unique (user_id, feed_id) index only helps per user
+CREATE INDEX feed_follow_feed_idx ON feed_follow (feed_id);
+CREATE INDEX posts_feed_published_idx ON posts (feed_id, published_at DESC);
+
+-- +goose Down
+DROP INDEX posts_feed_published_idx;
+DROP INDEX feed_follow_feed_idx;
@@ -0,0 +1,42 @@
+
+--+goose Up
+-- Owner of the feeds left behind by deleted users that others still follow.
+-- Its password is not a bcrypt hash, nobody can log in as it
+INSERT INTO users (id, name, email, password)
+VALUES ('00000000-0000-0000-0000-000000000000', 'System', 'system@localhost', '!');
+
+-- Deleting a user must not take shared feeds with it, handlerDeleteUser hands
+-- them over first and deletes the rest itself
+ALTER TABLE feeds DROP CONSTRAINT feeds_user_id_fkey;
+ALTER TABLE feeds ADD CONSTRAINT feeds_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT;
+
+-- Users who manage a feed besides its owner
+CREATE TABLE feed_maintainers (
+    feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (feed_id, user_id)
+);
+CREATE INDEX feed_maintainers_user_idx ON feed_maintainers (user_id);
+
+-- An owner offering a feed to another user, who accepts or declines it
+CREATE TABLE feed_ownership_transfers (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    from_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    to_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined', 'cancelled'))
+);
+-- A feed has at most one pending transfer
+CREATE UNIQUE INDEX feed_ownership_transfers_pending_idx ON feed_ownership_transfers (feed_id) WHERE status = 'pending';
+CREATE INDEX feed_ownership_transfers_to_user_idx ON feed_ownership_transfers (to_user_id, status);
+
+-- +goose Down
+DROP TABLE feed_ownership_transfers;
+DROP TABLE feed_maintainers;
+ALTER TABLE feeds DROP CONSTRAINT feeds_user_id_fkey;
+ALTER TABLE feeds ADD CONSTRAINT feeds_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+DELETE FROM feeds WHERE user_id = '00000000-0000-0000-0000-000000000000';
+DELETE FROM users WHERE id = '00000000-0000-0000-0000-000000000000';
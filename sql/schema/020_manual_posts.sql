@@ -0,0 +1,14 @@
+
+--+goose Up
+-- Posts written through the API rather than scraped. body keeps the Markdown
+-- source, description holds its sanitized HTML rendering. They stay out of the
+-- timelines until published_at
+ALTER TABLE posts ADD COLUMN manual BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE posts ADD COLUMN body TEXT;
+ALTER TABLE posts ADD COLUMN author_id UUID REFERENCES users(id) ON DELETE SET NULL;
+
+-- +goose Down
+DELETE FROM posts WHERE manual;
+ALTER TABLE posts DROP COLUMN author_id;
+ALTER TABLE posts DROP COLUMN body;
+ALTER TABLE posts DROP COLUMN manual;
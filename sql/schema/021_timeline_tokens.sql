@@ -0,0 +1,8 @@
+
+--+goose Up
+-- Secret token giving read access to a user's timeline as a feed. Only its
+-- SHA-256 is kept, the token itself is shown once when it is created
+ALTER TABLE users ADD COLUMN timeline_token_hash TEXT UNIQUE;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN timeline_token_hash;
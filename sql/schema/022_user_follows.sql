@@ -0,0 +1,15 @@
+
+--+goose Up
+-- Users following other users, the network timeline reads the posts of the
+-- feeds they own
+CREATE TABLE user_follows (
+    follower_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followed_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (follower_id, followed_id),
+    CHECK (follower_id <> followed_id)
+);
+CREATE INDEX user_follows_followed_idx ON user_follows (followed_id);
+
+-- +goose Down
+DROP TABLE user_follows;
@@ -0,0 +1,45 @@
+
+--+goose Up
+-- Public feeds are listed and followable by everyone, unlisted ones only
+-- reachable by their ID, private ones only by their owner, maintainers and
+-- the users given access
+ALTER TABLE feeds ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public' CHECK (visibility IN ('public', 'unlisted', 'private'));
+
+-- Allowlist of a private feed
+CREATE TABLE feed_access (
+    feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (feed_id, user_id)
+);
+CREATE INDEX feed_access_user_idx ON feed_access (user_id);
+
+-- Links adding whoever opens them to the allowlist. Only the SHA-256 of the
+-- token is kept
+CREATE TABLE feed_invites (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    expires_at TIMESTAMP,
+    max_uses INTEGER,
+    uses INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX feed_invites_feed_idx ON feed_invites (feed_id);
+
+-- Whether viewer can read the feed, the one rule every query checks
+-- +goose StatementBegin
+CREATE FUNCTION feed_readable(feed UUID, viewer UUID) RETURNS BOOLEAN AS $$
+    SELECT feeds.visibility <> 'private' OR feeds.user_id = viewer
+        OR EXISTS(SELECT 1 FROM feed_maintainers WHERE feed_maintainers.feed_id = feeds.id AND feed_maintainers.user_id = viewer)
+        OR EXISTS(SELECT 1 FROM feed_access WHERE feed_access.feed_id = feeds.id AND feed_access.user_id = viewer)
+    FROM feeds WHERE feeds.id = feed
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION feed_readable;
+DROP TABLE feed_invites;
+DROP TABLE feed_access;
+ALTER TABLE feeds DROP COLUMN visibility;
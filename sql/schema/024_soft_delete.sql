@@ -0,0 +1,34 @@
+
+--+goose Up
+-- Deleted users, feeds and follows are kept for DELETION_GRACE_PERIOD so they
+-- can be restored, then purged. Everything deleted along with a user shares
+-- the user's deleted_at
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE feeds ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE feed_follow ADD COLUMN deleted_at TIMESTAMP;
+CREATE INDEX users_deleted_idx ON users (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX feeds_deleted_idx ON feeds (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX feed_follow_deleted_idx ON feed_follow (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Deleted feeds can't be read by anybody
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION feed_readable(feed UUID, viewer UUID) RETURNS BOOLEAN AS $$
+    SELECT feeds.deleted_at IS NULL AND (feeds.visibility <> 'private' OR feeds.user_id = viewer
+        OR EXISTS(SELECT 1 FROM feed_maintainers WHERE feed_maintainers.feed_id = feeds.id AND feed_maintainers.user_id = viewer)
+        OR EXISTS(SELECT 1 FROM feed_access WHERE feed_access.feed_id = feeds.id AND feed_access.user_id = viewer))
+    FROM feeds WHERE feeds.id = feed
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION feed_readable(feed UUID, viewer UUID) RETURNS BOOLEAN AS $$
+    SELECT feeds.visibility <> 'private' OR feeds.user_id = viewer
+        OR EXISTS(SELECT 1 FROM feed_maintainers WHERE feed_maintainers.feed_id = feeds.id AND feed_maintainers.user_id = viewer)
+        OR EXISTS(SELECT 1 FROM feed_access WHERE feed_access.feed_id = feeds.id AND feed_access.user_id = viewer)
+    FROM feeds WHERE feeds.id = feed
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+ALTER TABLE feed_follow DROP COLUMN deleted_at;
+ALTER TABLE feeds DROP COLUMN deleted_at;
+ALTER TABLE users DROP COLUMN deleted_at;
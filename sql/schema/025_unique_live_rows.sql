@@ -0,0 +1,14 @@
+
+--+goose Up
+-- Deleted users and feeds don't hold on to their email and URL, so a deleted
+-- feed can be added again and a deleted account's email signed up with again
+ALTER TABLE users DROP CONSTRAINT users_email_key;
+ALTER TABLE feeds DROP CONSTRAINT feeds_url_key;
+CREATE UNIQUE INDEX users_email_live_idx ON users (email) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX feeds_url_live_idx ON feeds (url) WHERE deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX feeds_url_live_idx;
+DROP INDEX users_email_live_idx;
+ALTER TABLE feeds ADD CONSTRAINT feeds_url_key UNIQUE (url);
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
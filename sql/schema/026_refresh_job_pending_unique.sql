@@ -0,0 +1,13 @@
+
+--+goose Up
+-- A feed has at most one job waiting or running, older duplicates left by
+-- concurrent requests are marked failed first
+UPDATE feed_refresh_jobs SET status = 'failed', error = 'Superseded by a newer job', finished_at = NOW(), updated_at = NOW()
+WHERE status IN ('queued', 'running') AND EXISTS(
+    SELECT 1 FROM feed_refresh_jobs newer
+    WHERE newer.feed_id = feed_refresh_jobs.feed_id AND newer.status IN ('queued', 'running')
+        AND (newer.created_at, newer.id) > (feed_refresh_jobs.created_at, feed_refresh_jobs.id));
+CREATE UNIQUE INDEX feed_refresh_jobs_feed_pending_idx ON feed_refresh_jobs (feed_id) WHERE status IN ('queued', 'running');
+
+-- +goose Down
+DROP INDEX feed_refresh_jobs_feed_pending_idx;
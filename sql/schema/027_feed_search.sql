@@ -0,0 +1,14 @@
+
+--+goose Up
+-- The feed search matches anywhere in the name, URL and description, which
+-- only trigram indexes can serve. pg_trgm is a trusted extension, the database
+-- owner can create it
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX feeds_name_trgm_idx ON feeds USING gin (name gin_trgm_ops);
+CREATE INDEX feeds_url_trgm_idx ON feeds USING gin (url gin_trgm_ops);
+CREATE INDEX feeds_description_trgm_idx ON feeds USING gin (description gin_trgm_ops);
+
+-- +goose Down
+DROP INDEX feeds_description_trgm_idx;
+DROP INDEX feeds_url_trgm_idx;
+DROP INDEX feeds_name_trgm_idx;
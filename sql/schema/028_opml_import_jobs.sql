@@ -0,0 +1,22 @@
+
+--+goose Up
+-- OPML imports run in the background, entries holds the feeds read from the
+-- file and results what happened to each once the job is done
+CREATE TABLE opml_import_jobs (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'succeeded', 'failed')),
+    entries JSONB NOT NULL,
+    results JSONB NOT NULL DEFAULT '[]',
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    error TEXT
+);
+
+CREATE INDEX opml_import_jobs_user_idx ON opml_import_jobs (user_id, created_at);
+CREATE INDEX opml_import_jobs_pending_idx ON opml_import_jobs (created_at) WHERE status IN ('queued', 'running');
+
+-- +goose Down
+DROP TABLE opml_import_jobs;
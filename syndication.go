@@ -0,0 +1,334 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strconv"
+	"time"
+)
+
+// Posts served in a feed built by the app
+const syndicationItems = 50
+
+// Media types of the feeds served back out
+const (
+	rssContentType      = "application/rss+xml; charset=utf-8"
+	atomContentType     = "application/atom+xml; charset=utf-8"
+	jsonFeedContentType = "application/feed+json; charset=utf-8"
+)
+
+// syndicationFeed is what the RSS, Atom and JSON Feed encoders share, posts
+// are newest first
+type syndicationFeed struct {
+	ID          string // Stable identifier of the feed, used by Atom
+	Title       string
+	Description string
+	Language    string
+	SiteURL     string
+	SelfURL     string
+	Author      string
+	Updated     time.Time // Last change to the feed itself, used when no post is newer
+	Posts       []Post
+}
+
+// lastModified is the latest change to the feed or any of its posts
+func (feed syndicationFeed) lastModified() time.Time {
+	modified := feed.Updated
+	for _, post := range feed.Posts {
+		for _, t := range []time.Time{post.PublishedAt, post.UpdatedAt} {
+			if t.After(modified) {
+				modified = t
+			}
+		}
+	}
+	return modified.UTC()
+}
+
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	Content string     `xml:"xmlns:content,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	AtomLink      rssAtomLink `xml:"atom:link"`
+	Title         string      `xml:"title"`
+	Link          string      `xml:"link"`
+	Description   string      `xml:"description"`
+	Language      string      `xml:"language,omitempty"`
+	LastBuildDate string      `xml:"lastBuildDate"`
+	Items         []rssEntry  `xml:"item"`
+}
+
+type rssAtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssEntry struct {
+	Title       string         `xml:"title"`
+	Link        string         `xml:"link,omitempty"`
+	Description string         `xml:"description,omitempty"`
+	Content     string         `xml:"content:encoded,omitempty"`
+	PubDate     string         `xml:"pubDate"`
+	GUID        rssGUID        `xml:"guid"`
+	Creator     string         `xml:"dc:creator,omitempty"`
+	Categories  []string       `xml:"category"`
+	Enclosures  []RSSEnclosure `xml:"enclosure"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink string `xml:"isPermaLink,attr"`
+}
+
+// encodeRSS writes an RSS 2.0 document
+func encodeRSS(feed syndicationFeed) ([]byte, error) {
+	doc := rssDocument{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Content: "http://purl.org/rss/1.0/modules/content/",
+	}
+	doc.Channel = rssChannel{
+		AtomLink:      rssAtomLink{Href: feed.SelfURL, Rel: "self", Type: "application/rss+xml"},
+		Title:         feed.Title,
+		Link:          firstNonEmpty(feed.SiteURL, feed.SelfURL),
+		Description:   firstNonEmpty(feed.Description, feed.Title),
+		Language:      feed.Language,
+		LastBuildDate: feed.lastModified().Format(time.RFC1123Z),
+		Items:         []rssEntry{},
+	}
+	for _, post := range feed.Posts {
+		item := rssEntry{
+			Title:       post.Title,
+			Link:        post.Url,
+			Description: stringValue(post.Description),
+			Content:     stringValue(post.Content),
+			PubDate:     post.PublishedAt.UTC().Format(time.RFC1123Z),
+			GUID:        rssGUID{Value: post.ID.String(), IsPermaLink: "false"},
+			Creator:     stringValue(post.Author),
+			Categories:  post.Categories,
+		}
+		for _, enclosure := range post.Enclosures {
+			item.Enclosures = append(item.Enclosures, RSSEnclosure{
+				URL:    enclosure.URL,
+				Type:   stringValue(enclosure.Type),
+				Length: firstNonEmpty(int64String(enclosure.Length), "0"),
+			})
+		}
+		doc.Channel.Items = append(doc.Channel.Items, item)
+	}
+	return encodeXML(doc)
+}
+
+type atomDocument struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Lang     string      `xml:"xml:lang,attr,omitempty"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Author   AtomAuthor  `xml:"author"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+// atomLink is AtomLink without the empty attributes
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length string `xml:"length,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Links      []atomLink     `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Authors    []AtomAuthor   `xml:"author"`
+	Categories []AtomCategory `xml:"category"`
+	Summary    *atomText      `xml:"summary"`
+	Content    *atomText      `xml:"content"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// encodeAtom writes an Atom 1.0 document
+func encodeAtom(feed syndicationFeed) ([]byte, error) {
+	doc := atomDocument{
+		Lang:     feed.Language,
+		ID:       feed.ID,
+		Title:    feed.Title,
+		Subtitle: feed.Description,
+		Updated:  feed.lastModified().Format(time.RFC3339),
+		Author:   AtomAuthor{Name: feed.Author},
+		Links:    []atomLink{{Href: feed.SelfURL, Rel: "self", Type: "application/atom+xml"}},
+	}
+	if feed.SiteURL != "" {
+		doc.Links = append(doc.Links, atomLink{Href: feed.SiteURL, Rel: "alternate", Type: "text/html"})
+	}
+	for _, post := range feed.Posts {
+		entry := atomEntry{
+			ID:        "urn:uuid:" + post.ID.String(),
+			Title:     post.Title,
+			Published: post.PublishedAt.UTC().Format(time.RFC3339),
+			Updated:   post.UpdatedAt.UTC().Format(time.RFC3339),
+		}
+		if post.UpdatedAt.Before(post.PublishedAt) {
+			entry.Updated = entry.Published
+		}
+		if post.Url != "" {
+			entry.Links = append(entry.Links, atomLink{Href: post.Url, Rel: "alternate", Type: "text/html"})
+		}
+		for _, enclosure := range post.Enclosures {
+			entry.Links = append(entry.Links, atomLink{
+				Href:   enclosure.URL,
+				Rel:    "enclosure",
+				Type:   stringValue(enclosure.Type),
+				Length: int64String(enclosure.Length),
+			})
+		}
+		if post.Author != nil {
+			entry.Authors = append(entry.Authors, AtomAuthor{Name: *post.Author})
+		}
+		for _, category := range post.Categories {
+			entry.Categories = append(entry.Categories, AtomCategory{Term: category})
+		}
+		if post.Description != nil {
+			entry.Summary = &atomText{Type: "html", Value: *post.Description}
+		}
+		if post.Content != nil {
+			entry.Content = &atomText{Type: "html", Value: *post.Content}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return encodeXML(doc)
+}
+
+// JSON Feed 1.1, https://www.jsonfeed.org/version/1.1/
+type jsonFeedDocument struct {
+	Version     string           `json:"version"`
+	Title       string           `json:"title"`
+	HomePageURL string           `json:"home_page_url,omitempty"`
+	FeedURL     string           `json:"feed_url"`
+	Description string           `json:"description,omitempty"`
+	Language    string           `json:"language,omitempty"`
+	Authors     []jsonFeedAuthor `json:"authors,omitempty"`
+	Items       []jsonFeedItem   `json:"items"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedItem struct {
+	ID            string               `json:"id"`
+	URL           string               `json:"url,omitempty"`
+	Title         string               `json:"title"`
+	ContentHTML   string               `json:"content_html"`
+	Summary       string               `json:"summary,omitempty"`
+	DatePublished string               `json:"date_published"`
+	DateModified  string               `json:"date_modified"`
+	Authors       []jsonFeedAuthor     `json:"authors,omitempty"`
+	Tags          []string             `json:"tags,omitempty"`
+	Attachments   []jsonFeedAttachment `json:"attachments,omitempty"`
+}
+
+type jsonFeedAttachment struct {
+	URL         string `json:"url"`
+	MimeType    string `json:"mime_type"`
+	SizeInBytes *int64 `json:"size_in_bytes,omitempty"`
+}
+
+// encodeJSONFeed writes a JSON Feed 1.1 document
+func encodeJSONFeed(feed syndicationFeed) ([]byte, error) {
+	doc := jsonFeedDocument{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       feed.Title,
+		HomePageURL: feed.SiteURL,
+		FeedURL:     feed.SelfURL,
+		Description: feed.Description,
+		Language:    feed.Language,
+		Items:       []jsonFeedItem{},
+	}
+	if feed.Author != "" {
+		doc.Authors = []jsonFeedAuthor{{Name: feed.Author}}
+	}
+	for _, post := range feed.Posts {
+		item := jsonFeedItem{
+			ID:            post.ID.String(),
+			URL:           post.Url,
+			Title:         post.Title,
+			ContentHTML:   firstNonEmpty(stringValue(post.Content), stringValue(post.Description)),
+			Summary:       stringValue(post.Summary),
+			DatePublished: post.PublishedAt.UTC().Format(time.RFC3339),
+			DateModified:  post.UpdatedAt.UTC().Format(time.RFC3339),
+			Tags:          post.Categories,
+		}
+		if post.UpdatedAt.Before(post.PublishedAt) {
+			item.DateModified = item.DatePublished
+		}
+		if post.Author != nil {
+			item.Authors = []jsonFeedAuthor{{Name: *post.Author}}
+		}
+		for _, enclosure := range post.Enclosures {
+			item.Attachments = append(item.Attachments, jsonFeedAttachment{
+				URL:         enclosure.URL,
+				MimeType:    firstNonEmpty(stringValue(enclosure.Type), "application/octet-stream"),
+				SizeInBytes: enclosure.Length,
+			})
+		}
+		doc.Items = append(doc.Items, item)
+	}
+	buffer := bytes.Buffer{}
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(doc)
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func encodeXML(doc interface{}) ([]byte, error) {
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(bytes.TrimSpace(data), '\n')...), nil
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func int64String(value *int64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatInt(*value, 10)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
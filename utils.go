@@ -1,9 +1,16 @@
 package main
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
 	"log"
 	"net/url"
+	"strings"
 
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -36,3 +43,32 @@ func isValidURL(str string) bool {
 	}
 	return true
 }
+
+// nullString converts blank strings to NULL
+func nullString(str string) sql.NullString {
+	str = strings.TrimSpace(str)
+	return sql.NullString{String: str, Valid: str != ""}
+}
+
+// newSecretToken returns a random token for URLs that grant access on their own
+func newSecretToken() (string, error) {
+	token := make([]byte, 32)
+	_, err := rand.Read(token)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// hashSecretToken is what is stored for a token, a leaked database doesn't
+// give the access away
+func hashSecretToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// isUniqueViolation reports whether err comes from a unique constraint or index
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
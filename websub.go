@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"log"
+	"net/http"
+	"net/url"
+	"project_1/internal/database"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Public URL of this server, hubs call back WEBSUB_CALLBACK_URL/websub/{id}.
+// WebSub is off when it is not set.
+var webSubCallbackURL = ""
+
+// Lease asked from hubs, they may grant a different one
+const webSubLeaseSeconds = 10 * 24 * 60 * 60
+
+// Subscriptions are renewed this long before they expire, and requests the
+// hub did not verify are sent again after the same delay
+const webSubRenewBefore = time.Hour
+
+// How often the renewer looks for subscriptions to send again
+const webSubRenewInterval = 10 * time.Minute
+
+const webSubRequestTimeout = 10 * time.Second
+
+// Hash functions hubs may sign content with in X-Hub-Signature
+var webSubSignatures = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha384": sha512.New384,
+	"sha512": sha512.New,
+}
+
+// webSubLinks returns the hub advertised by a feed and the topic URL to
+// subscribe to, the feed's rel="self" link when it has one
+func webSubLinks(rssFeed RSSFeed, feedURL string) (string, string) {
+	hub, topic := "", feedURL
+	for _, link := range rssFeed.Channel.AtomLinks {
+		switch {
+		case containsField(link.Rel, "hub") && hub == "":
+			hub = strings.TrimSpace(link.Href)
+		case containsField(link.Rel, "self") && strings.TrimSpace(link.Href) != "":
+			topic = strings.TrimSpace(link.Href)
+		}
+	}
+	return hub, topic
+}
+
+// linkHeaderLinks reads the links of HTTP Link headers,
+// e.g. `<https://hub.example/>; rel="hub"`
+func linkHeaderLinks(headers []string) []AtomLink {
+	links := []AtomLink{}
+	for _, header := range headers {
+		for _, value := range strings.Split(header, ",") {
+			parts := strings.Split(value, ";")
+			href := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(href, "<") || !strings.HasSuffix(href, ">") {
+				continue
+			}
+			link := AtomLink{Href: strings.Trim(href, "<>")}
+			for _, param := range parts[1:] {
+				key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if strings.EqualFold(key, "rel") {
+					link.Rel = strings.ToLower(strings.Trim(value, `"`))
+				}
+			}
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+func newWebSubSecret() (string, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// subscribeToHub subscribes to the hub advertised by a feed when the feed has
+// no subscription to that hub and topic yet. Existing ones are left to the
+// renewer, failures are only logged since polling still works.
+func subscribeToHub(ctx context.Context, db *database.Queries, feed database.Feed, rssFeed RSSFeed) {
+	hub, topic := webSubLinks(rssFeed, feed.Url)
+	if webSubCallbackURL == "" || hub == "" {
+		return
+	}
+	existing, err := db.GetFeedWebSubSubscription(ctx, feed.ID)
+	if err == nil && existing.Hub == hub && existing.Topic == topic {
+		return
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Error reading WebSub subscription of %v: %v", feed.Name, err)
+		return
+	}
+
+	secret, err := newWebSubSecret()
+	if err != nil {
+		log.Printf("Error creating WebSub secret: %v", err)
+		return
+	}
+	// Saved before asking the hub, which may verify before it answers
+	subscription, err := db.UpsertWebSubSubscription(ctx, database.UpsertWebSubSubscriptionParams{
+		ID:     uuid.New(),
+		FeedID: feed.ID,
+		Hub:    hub,
+		Topic:  topic,
+		Secret: secret,
+	})
+	if err != nil {
+		log.Printf("Error saving WebSub subscription of %v: %v", feed.Name, err)
+		return
+	}
+	err = sendWebSubRequest(ctx, subscription)
+	if err != nil {
+		log.Printf("Error subscribing %v to %v: %v", feed.Name, hub, err)
+	}
+}
+
+// sendWebSubRequest asks the hub for a subscription, the hub then verifies it
+// through the callback
+func sendWebSubRequest(ctx context.Context, subscription database.WebsubSubscription) error {
+	ctx, cancel := context.WithTimeout(ctx, webSubRequestTimeout)
+	defer cancel()
+	form := url.Values{
+		"hub.callback":      {strings.TrimRight(webSubCallbackURL, "/") + "/websub/" + subscription.ID.String()},
+		"hub.mode":          {"subscribe"},
+		"hub.topic":         {subscription.Topic},
+		"hub.secret":        {subscription.Secret},
+		"hub.lease_seconds": {strconv.Itoa(webSubLeaseSeconds)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Hub, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", userAgent)
+	httpClient := http.Client{Transport: outboundTransport}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %v", resp.Status)
+	}
+	return nil
+}
+
+// startWebSubRenewer sends subscriptions again before their lease runs out
+func startWebSubRenewer(conn *sql.DB) {
+	if webSubCallbackURL == "" {
+		return
+	}
+	db := database.New(conn)
+	ticker := time.NewTicker(webSubRenewInterval)
+	for ; ; <-ticker.C {
+		subscriptions, err := db.ClaimWebSubRenewals(context.Background(), database.ClaimWebSubRenewalsParams{
+			RenewBeforeSeconds: int32(webSubRenewBefore / time.Second),
+			RetryAfterSeconds:  int32(webSubRenewBefore / time.Second),
+			MaxSubscriptions:   100,
+		})
+		if err != nil {
+			log.Printf("Error claiming WebSub renewals: %v", err)
+			continue
+		}
+		for _, subscription := range subscriptions {
+			err = sendWebSubRequest(context.Background(), subscription)
+			if err != nil {
+				log.Printf("Error renewing WebSub subscription %v: %v", subscription.ID, err)
+			}
+		}
+	}
+}
+
+// validWebSubSignature checks an X-Hub-Signature header, "method=hex digest",
+// against the HMAC of the body keyed with the subscription secret
+func validWebSubSignature(secret string, header string, body []byte) bool {
+	method, signature, found := strings.Cut(strings.TrimSpace(header), "=")
+	newHash, ok := webSubSignatures[strings.ToLower(method)]
+	if !found || !ok {
+		return false
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}